@@ -0,0 +1,175 @@
+// Command vai is a Vim-style terminal client for AI chat.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/fingergohappy/vai/internal/app"
+	"github.com/fingergohappy/vai/internal/config"
+)
+
+// Version is set at build time with -ldflags "-X main.Version=...".
+var Version = "dev"
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, "vai:", err)
+		}
+		os.Exit(1)
+	}
+}
+
+// run parses the command line and starts the TUI or a one-shot prompt.
+func run(args []string) error {
+	if len(args) > 0 && args[0] == "config" {
+		return runConfig(args[1:])
+	}
+
+	fs := flag.NewFlagSet("vai", flag.ContinueOnError)
+	printMode := fs.Bool("p", false, "send one prompt, print the reply and exit")
+	sessionName := fs.String("session", "", "with -p, append the exchange to this session (title or ID)")
+	jsonOut := fs.Bool("json", false, "with -p, write JSON events instead of text")
+	profile := fs.String("profile", "", "use this config profile")
+	showVersion := fs.Bool("version", false, "print the version and exit")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage:\n  vai [flags]\n  vai -p [flags] [prompt...]\n  vai config check|init [--force] [--profile name]\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *showVersion {
+		fmt.Println("vai", Version)
+		return nil
+	}
+	if !*printMode && (*sessionName != "" || *jsonOut || fs.NArg() > 0) {
+		return fmt.Errorf("--session, --json and a prompt argument require -p")
+	}
+
+	loader := config.NewLoader()
+	loader.SetProfile(*profile)
+	cfg, err := loader.Load()
+	if err != nil {
+		return err
+	}
+
+	if *printMode {
+		return runOnce(cfg, strings.Join(fs.Args(), " "), *sessionName, *jsonOut)
+	}
+
+	return runTUI(loader, cfg)
+}
+
+// runConfig runs the config subcommands:
+//
+//	vai config check [--profile name] report problems in the config files
+//	vai config init [--force]         write a commented default config file
+func runConfig(args []string) error {
+	fs := flag.NewFlagSet("vai config", flag.ContinueOnError)
+	force := fs.Bool("force", false, "with init, overwrite an existing file")
+	profile := fs.String("profile", "", "with check, apply this profile")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage:\n  vai config check [--profile name]\n  vai config init [--force]\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	if len(args) == 0 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+	sub := args[0]
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	loader := config.NewLoader()
+	loader.SetProfile(*profile)
+	switch sub {
+	case "check":
+		cfg, err := loader.Load()
+		if err != nil {
+			return err
+		}
+		if cfg.Path == "" && cfg.Overlay == "" {
+			fmt.Printf("%s: not found, using defaults\n", loader.Path())
+			return nil
+		}
+		files := cfg.Path
+		if cfg.Overlay != "" {
+			files = strings.TrimPrefix(files+" and "+cfg.Overlay, " and ")
+		}
+		issues := app.CheckConfig(cfg)
+		for _, issue := range issues {
+			fmt.Println(issue)
+		}
+		if len(issues) > 0 {
+			return fmt.Errorf("%d problems in %s", len(issues), files)
+		}
+		fmt.Printf("%s: OK\n", files)
+		return nil
+	case "init":
+		if err := loader.Init(*force); err != nil {
+			return err
+		}
+		fmt.Printf("wrote %s\n", loader.Path())
+		return nil
+	}
+	return fmt.Errorf("unknown config command %q (want check or init)", sub)
+}
+
+// runTUI starts the interactive interface. Piped input is attached to a
+// fresh session and keys are read from the terminal instead.
+func runTUI(loader *config.Loader, cfg config.Config) error {
+	model := app.NewModel(cfg)
+	model.SetLoader(loader)
+	opts := []tea.ProgramOption{tea.WithAltScreen()}
+	if piped(os.Stdin) {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return fmt.Errorf("read stdin: %w", err)
+		}
+		model.AttachStdin(string(data))
+		opts = append(opts, tea.WithInputTTY())
+	}
+
+	_, err := tea.NewProgram(model, opts...).Run()
+	return err
+}
+
+// runOnce reads piped input, if any, and sends a single prompt.
+func runOnce(cfg config.Config, prompt, sessionName string, jsonOut bool) error {
+	var stdin string
+	if piped(os.Stdin) {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return fmt.Errorf("read stdin: %w", err)
+		}
+		stdin = string(data)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	return app.RunOnce(ctx, cfg, app.OneShot{
+		Prompt:  prompt,
+		Stdin:   stdin,
+		Session: sessionName,
+		JSON:    jsonOut,
+		Out:     os.Stdout,
+	})
+}
+
+// piped reports whether f is a pipe or file rather than a terminal.
+func piped(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice == 0
+}
@@ -0,0 +1,357 @@
+package app
+
+import (
+	"fmt"
+	"maps"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/fingergohappy/vai/internal/input"
+	"github.com/fingergohappy/vai/internal/register"
+	ui "github.com/fingergohappy/vai/internal/ui"
+	"github.com/fingergohappy/vai/internal/vim"
+)
+
+// action is a named operation that keys can be bound to.
+type action struct {
+	desc  string
+	flags vim.Flags
+	run   func(m *Model, ctx vim.Context) tea.Cmd
+
+	// repeatable actions change the prompt and are repeated by '.'
+	repeatable bool
+}
+
+// actions lists every bindable action by name.
+var actions = newActions()
+
+// newActions builds the action table from the pane actions, the prompt
+// editor's commands and its motions.
+func newActions() map[string]action {
+	all := make(map[string]action, len(paneActions)+len(editingActions)+len(input.Motions))
+	maps.Copy(all, paneActions)
+	maps.Copy(all, editingActions)
+	for _, mo := range input.Motions {
+		name := mo.Name
+		all[name] = action{desc: mo.Desc, run: func(m *Model, ctx vim.Context) tea.Cmd {
+			m.Input.Move(name, ctx.Count)
+			return nil
+		}}
+	}
+	return all
+}
+
+// paneActions are the actions for switching modes and panes, the chat
+// buffer, the prompt and the session list.
+var paneActions = map[string]action{
+	// Modes and panes
+	"insert": {desc: "Enter INSERT mode in the input area", repeatable: true, run: func(m *Model, ctx vim.Context) tea.Cmd {
+		return m.startInsert(input.InsertAtCursor)
+	}},
+	"normal": {desc: "Return to NORMAL mode", run: func(m *Model, ctx vim.Context) tea.Cmd {
+		m.stopInsert()
+		return nil
+	}},
+	"command-line": {desc: "Open the command line", run: func(m *Model, ctx vim.Context) tea.Cmd {
+		return m.CmdLine.Open(':')
+	}},
+	"focus-left": {desc: "Focus the session list", run: func(m *Model, ctx vim.Context) tea.Cmd {
+		if m.focusable(ui.FocusHistory) {
+			m.setFocus(ui.FocusHistory)
+		}
+		return nil
+	}},
+	"focus-right": {desc: "Focus the chat buffer", run: func(m *Model, ctx vim.Context) tea.Cmd {
+		m.setFocus(ui.FocusBuffer)
+		return nil
+	}},
+	"focus-down": {desc: "Focus the input area", run: func(m *Model, ctx vim.Context) tea.Cmd {
+		m.setFocus(ui.FocusInput)
+		return nil
+	}},
+	"focus-up": {desc: "Focus the previous pane", run: func(m *Model, ctx vim.Context) tea.Cmd {
+		m.cycleFocus(true)
+		return nil
+	}},
+	"focus-next": {desc: "Focus the next pane", run: func(m *Model, ctx vim.Context) tea.Cmd {
+		m.cycleFocus(false)
+		return nil
+	}},
+	"toggle-history": {desc: "Show or hide the session list", run: func(m *Model, ctx vim.Context) tea.Cmd {
+		m.reportError(m.toggleHistory())
+		return nil
+	}},
+	"narrow-history": {desc: "Make the session list [count] columns narrower", run: func(m *Model, ctx vim.Context) tea.Cmd {
+		m.reportError(m.resizeHistory(-ctx.CountOr(1)))
+		return nil
+	}},
+	"widen-history": {desc: "Make the session list [count] columns wider", run: func(m *Model, ctx vim.Context) tea.Cmd {
+		m.reportError(m.resizeHistory(ctx.CountOr(1)))
+		return nil
+	}},
+	"zoom": {desc: "Maximise the focused pane, or restore the layout", run: func(m *Model, ctx vim.Context) tea.Cmd {
+		m.zoomed = !m.zoomed
+		return nil
+	}},
+	"new-session": {desc: "Start a new session", run: func(m *Model, ctx vim.Context) tea.Cmd {
+		m.newSession()
+		return nil
+	}},
+	"quit": {desc: "Quit vai", run: func(m *Model, ctx vim.Context) tea.Cmd {
+		m.reportError(m.saveDraft())
+		m.quitting = true
+		return tea.Quit
+	}},
+
+	// Chat buffer movement
+	"cursor-down": {desc: "Move the cursor down [count] lines", run: func(m *Model, ctx vim.Context) tea.Cmd {
+		m.Chat.MoveCursor(ctx.CountOr(1))
+		return nil
+	}},
+	"cursor-up": {desc: "Move the cursor up [count] lines", run: func(m *Model, ctx vim.Context) tea.Cmd {
+		m.Chat.MoveCursor(-ctx.CountOr(1))
+		return nil
+	}},
+	"cursor-left": {desc: "Move the cursor left [count] characters", run: func(m *Model, ctx vim.Context) tea.Cmd {
+		m.Chat.MoveColumn(-ctx.CountOr(1))
+		return nil
+	}},
+	"cursor-right": {desc: "Move the cursor right [count] characters", run: func(m *Model, ctx vim.Context) tea.Cmd {
+		m.Chat.MoveColumn(ctx.CountOr(1))
+		return nil
+	}},
+	"cursor-word-forward": {desc: "Move to the start of the [count]th next word", run: func(m *Model, ctx vim.Context) tea.Cmd {
+		m.Chat.WordForward(ctx.CountOr(1))
+		return nil
+	}},
+	"cursor-word-backward": {desc: "Move to the start of the [count]th previous word", run: func(m *Model, ctx vim.Context) tea.Cmd {
+		m.Chat.WordBackward(ctx.CountOr(1))
+		return nil
+	}},
+	"cursor-word-end": {desc: "Move to the end of the [count]th word", run: func(m *Model, ctx vim.Context) tea.Cmd {
+		m.Chat.WordEnd(ctx.CountOr(1))
+		return nil
+	}},
+	"cursor-line-start": {desc: "Move to the first character of the line", run: func(m *Model, ctx vim.Context) tea.Cmd {
+		m.Chat.ColumnStart()
+		return nil
+	}},
+	"cursor-line-end": {desc: "Move to the last character of the line", run: func(m *Model, ctx vim.Context) tea.Cmd {
+		m.Chat.ColumnEnd()
+		return nil
+	}},
+	"scroll-down": {desc: "Scroll down [count] lines", run: func(m *Model, ctx vim.Context) tea.Cmd {
+		m.Chat.ScrollLines(ctx.CountOr(1))
+		return nil
+	}},
+	"scroll-up": {desc: "Scroll up [count] lines", run: func(m *Model, ctx vim.Context) tea.Cmd {
+		m.Chat.ScrollLines(-ctx.CountOr(1))
+		return nil
+	}},
+	"page-down": {desc: "Scroll down [count] pages", run: func(m *Model, ctx vim.Context) tea.Cmd {
+		m.Chat.ScrollPages(float64(ctx.CountOr(1)))
+		return nil
+	}},
+	"page-up": {desc: "Scroll up [count] pages", run: func(m *Model, ctx vim.Context) tea.Cmd {
+		m.Chat.ScrollPages(-float64(ctx.CountOr(1)))
+		return nil
+	}},
+	"half-page-down": {desc: "Scroll down half a page", run: func(m *Model, ctx vim.Context) tea.Cmd {
+		m.Chat.ScrollPages(0.5)
+		return nil
+	}},
+	"half-page-up": {desc: "Scroll up half a page", run: func(m *Model, ctx vim.Context) tea.Cmd {
+		m.Chat.ScrollPages(-0.5)
+		return nil
+	}},
+	"goto-top": {desc: "Go to the first line, or line [count]", run: func(m *Model, ctx vim.Context) tea.Cmd {
+		m.Chat.GotoTop(ctx.Count)
+		return nil
+	}},
+	"goto-bottom": {desc: "Go to the last line, or line [count]", run: func(m *Model, ctx vim.Context) tea.Cmd {
+		m.Chat.GotoBottom(ctx.Count)
+		return nil
+	}},
+	"next-code-block": {desc: "Jump [count] code blocks forward", run: func(m *Model, ctx vim.Context) tea.Cmd {
+		if !m.Chat.NextCodeBlock(ctx.CountOr(1)) {
+			m.CmdLine.SetError(fmt.Errorf("no next code block"))
+		}
+		return nil
+	}},
+	"prev-code-block": {desc: "Jump [count] code blocks back", run: func(m *Model, ctx vim.Context) tea.Cmd {
+		if !m.Chat.PrevCodeBlock(ctx.CountOr(1)) {
+			m.CmdLine.SetError(fmt.Errorf("no previous code block"))
+		}
+		return nil
+	}},
+
+	// Search
+	"search-forward": {desc: "Search forward", run: func(m *Model, ctx vim.Context) tea.Cmd {
+		return m.CmdLine.Open('/')
+	}},
+	"search-backward": {desc: "Search backward", run: func(m *Model, ctx vim.Context) tea.Cmd {
+		return m.CmdLine.Open('?')
+	}},
+	"search-next": {desc: "Go to the next match", run: func(m *Model, ctx vim.Context) tea.Cmd {
+		for range ctx.CountOr(1) {
+			m.CmdLine.SetError(m.Chat.NextMatch())
+		}
+		return nil
+	}},
+	"search-prev": {desc: "Go to the previous match", run: func(m *Model, ctx vim.Context) tea.Cmd {
+		for range ctx.CountOr(1) {
+			m.CmdLine.SetError(m.Chat.PrevMatch())
+		}
+		return nil
+	}},
+	"search-word": {desc: "Search forward for the word under the cursor", run: func(m *Model, ctx vim.Context) tea.Cmd {
+		m.CmdLine.SetError(m.Chat.SearchWord())
+		return nil
+	}},
+	"clear-search": {desc: "Clear search highlighting", run: func(m *Model, ctx vim.Context) tea.Cmd {
+		m.Chat.ClearSearch()
+		return nil
+	}},
+
+	"toggle-context": {desc: "Expand or collapse the attachment under the cursor", run: func(m *Model, ctx vim.Context) tea.Cmd {
+		if !m.Chat.ToggleContext() {
+			m.CmdLine.SetError(fmt.Errorf("no attachment under the cursor"))
+		}
+		return nil
+	}},
+
+	"toggle-system": {desc: "Expand or collapse the system prompt header", run: func(m *Model, ctx vim.Context) tea.Cmd {
+		if !m.Chat.ToggleSystem() {
+			m.CmdLine.SetError(fmt.Errorf("no system prompt (:system to add one)"))
+		}
+		return nil
+	}},
+
+	// Registers
+	"yank": {desc: "Yank {motion} into [register]", flags: vim.FlagOperator, run: func(m *Model, ctx vim.Context) tea.Cmd {
+		m.yankMotion(ctx)
+		return nil
+	}},
+	"code-block": {desc: "Motion: the current code block, or code block [count]"},
+	"message":    {desc: "Motion: the current message"},
+	"paste-register": {desc: "Insert the content of register {char}", flags: vim.FlagChar, run: func(m *Model, ctx vim.Context) tea.Cmd {
+		m.pasteRegister(ctx.Char)
+		return nil
+	}},
+
+	// Prompt
+	"help": {desc: "Show the keys and commands", run: func(m *Model, ctx vim.Context) tea.Cmd {
+		return emit(showHelpMsg{})
+	}},
+	"send": {desc: "Send the prompt, or run the /command typed in it", run: func(m *Model, ctx vim.Context) tea.Cmd {
+		return m.sendPrompt()
+	}},
+	"complete-mention": {desc: "Complete the /command or the @file mention before the cursor", run: func(m *Model, ctx vim.Context) tea.Cmd {
+		if slashTyped(m.Input.Value()) {
+			m.completeSlash()
+			return nil
+		}
+		m.completeMention()
+		return nil
+	}},
+	"newline": {desc: "Run the /command typed in the prompt, or insert a line break", run: func(m *Model, ctx vim.Context) tea.Cmd {
+		if slashPrompt(m.Input.Value()) {
+			return m.runSlash()
+		}
+		key := tea.KeyMsg{Type: tea.KeyEnter}
+		m.recordKey(key)
+		model, cmd := m.Input.Update(key)
+		m.Input = model.(input.Model)
+		return cmd
+	}},
+	"history-prev": {desc: "Move up, or recall the previous prompt on the first line", run: func(m *Model, ctx vim.Context) tea.Cmd {
+		m.Input.HistoryPrev()
+		return nil
+	}},
+	"history-next": {desc: "Move down, or recall the next prompt on the last line", run: func(m *Model, ctx vim.Context) tea.Cmd {
+		m.Input.HistoryNext()
+		return nil
+	}},
+	"history-search": {desc: "Search the prompt history backward", run: func(m *Model, ctx vim.Context) tea.Cmd {
+		m.Input.StartSearch()
+		m.CmdLine.SetMessage(m.Input.SearchStatus())
+		return nil
+	}},
+	"edit-prompt": {desc: "Edit the prompt in $VISUAL or $EDITOR", run: func(m *Model, ctx vim.Context) tea.Cmd {
+		return m.editPrompt()
+	}},
+
+	// Session list
+	"select-next": {desc: "Select the next [count] session", run: func(m *Model, ctx vim.Context) tea.Cmd {
+		m.Session.Select(m.Session.SelectedIndex + ctx.CountOr(1))
+		return nil
+	}},
+	"select-prev": {desc: "Select the previous [count] session", run: func(m *Model, ctx vim.Context) tea.Cmd {
+		m.Session.Select(m.Session.SelectedIndex - ctx.CountOr(1))
+		return nil
+	}},
+	"select-first": {desc: "Select the first session", run: func(m *Model, ctx vim.Context) tea.Cmd {
+		m.Session.Select(0)
+		return nil
+	}},
+	"select-last": {desc: "Select the last session", run: func(m *Model, ctx vim.Context) tea.Cmd {
+		m.Session.Select(len(m.Session.Sessions) - 1)
+		return nil
+	}},
+	"open-session": {desc: "Open the selected session", run: func(m *Model, ctx vim.Context) tea.Cmd {
+		if sess := m.Session.Selected(); sess != nil {
+			m.switchTo(*sess)
+		}
+		return nil
+	}},
+}
+
+// actionNames returns all action names, sorted.
+func actionNames() []string {
+	names := make([]string, 0, len(actions))
+	for name := range actions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// runAction runs a resolved binding: a named action, or an ex command
+// when name starts with ':'.
+func (m *Model) runAction(name string, ctx vim.Context) tea.Cmd {
+	if line, ok := strings.CutPrefix(name, ":"); ok {
+		cmd, err := m.Commands.Execute(line)
+		m.reportError(err)
+		return cmd
+	}
+	a, ok := actions[name]
+	if !ok || a.run == nil {
+		m.CmdLine.SetError(fmt.Errorf("unknown action: %s", name))
+		return nil
+	}
+	if ctx.Register != 0 && !register.Valid(ctx.Register) {
+		m.CmdLine.SetError(fmt.Errorf("invalid register: %q", ctx.Register))
+		return nil
+	}
+	if a.repeatable && m.Focus == ui.FocusInput {
+		m.lastChange = &change{run: a.run, ctx: ctx}
+	}
+	return a.run(m, ctx)
+}
+
+// setFocus moves focus to a pane and updates which component shows a cursor.
+func (m *Model) setFocus(focus ui.Focus) {
+	m.Focus = focus
+	if focus == ui.FocusBuffer {
+		m.Chat.Focus()
+	} else {
+		m.Chat.Blur()
+	}
+	if focus == ui.FocusInput {
+		m.Input.Focus()
+	} else {
+		m.Input.Blur()
+	}
+}
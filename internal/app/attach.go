@@ -0,0 +1,200 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/fingergohappy/vai/internal/attach"
+	"github.com/fingergohappy/vai/internal/chat"
+	ui "github.com/fingergohappy/vai/internal/ui"
+)
+
+// completion is the state of Tab completion in the prompt, of an @path
+// mention or a /command. Pressing Tab again cycles through the candidates.
+type completion struct {
+	candidates []string
+	idx        int
+}
+
+// maxMentionCandidates is the number of files offered for a mention.
+const maxMentionCandidates = 50
+
+// completeMention completes the @path mention before the cursor. Without
+// a mention, Tab inserts spaces up to the next tab stop.
+func (m *Model) completeMention() {
+	word := m.Input.WordBeforeCursor()
+	if !strings.HasPrefix(word, "@") {
+		m.mention = nil
+		m.Input.InsertString(strings.Repeat(" ", max(m.Config.Editor.TabWidth, 1)))
+		return
+	}
+
+	mc := m.mention
+	if mc != nil && word == "@"+mc.candidates[mc.idx] {
+		mc.idx = (mc.idx + 1) % len(mc.candidates)
+	} else {
+		candidates := attach.Complete(word[1:], maxMentionCandidates)
+		if len(candidates) == 0 {
+			m.mention = nil
+			m.CmdLine.SetError(fmt.Errorf("no file matches %s", word[1:]))
+			return
+		}
+		mc = &completion{candidates: candidates}
+		m.mention = mc
+	}
+	m.Input.ReplaceWordBeforeCursor("@" + mc.candidates[mc.idx])
+	m.CmdLine.SetMessage(fmt.Sprintf("[%d/%d] %s", mc.idx+1, len(mc.candidates), mc.candidates[mc.idx]))
+}
+
+// attachFiles adds the files matching pattern to the next message (:attach).
+func (m *Model) attachFiles(pattern string) error {
+	paths, err := attach.Glob(pattern)
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		return fmt.Errorf("no match: %s", pattern)
+	}
+
+	files := m.attachments
+	for _, path := range paths {
+		if hasAttachment(files, path) {
+			continue
+		}
+		f, err := attach.Read(path, m.maxFileSize())
+		if err != nil {
+			return err
+		}
+		files = append(files, f)
+	}
+	if err := m.checkTotalSize(files); err != nil {
+		return err
+	}
+	m.attachments = files
+	m.CmdLine.SetMessage(m.attachmentSummary(files))
+	return nil
+}
+
+// detachFiles removes all pending attachments (:detach).
+func (m *Model) detachFiles() {
+	m.attachments = nil
+	m.CmdLine.SetMessage("attachments cleared")
+}
+
+// showAttachments lists the pending attachments (:attach without a pattern).
+func (m *Model) showAttachments() {
+	var lines []string
+	for _, f := range m.attachments {
+		lines = append(lines, fmt.Sprintf("%-40s %5d lines  ~%d tokens", f.Path, len(f.Lines()), f.Tokens()))
+	}
+	if len(lines) == 0 {
+		lines = []string{"(no attachments; use :attach {glob} or @path)"}
+	}
+	m.popup = ui.NewPopup(m.Styles, "Attachments", lines)
+}
+
+// promptAttachments returns the pending attachments plus the files
+// mentioned as @path in text. Mentions that are not existing files are
+// left alone, so @someone stays plain text.
+func (m *Model) promptAttachments(text string) ([]attach.File, error) {
+	files := append([]attach.File(nil), m.attachments...)
+	for _, path := range attach.Mentions(text) {
+		if info, err := os.Stat(attach.ExpandHome(path)); err != nil || info.IsDir() || hasAttachment(files, path) {
+			continue
+		}
+		f, err := attach.Read(path, m.maxFileSize())
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+	if err := m.checkTotalSize(files); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// contextBlocks turns attached files into collapsed chat context blocks.
+func contextBlocks(files []attach.File) []chat.Block {
+	blocks := make([]chat.Block, len(files))
+	for i, f := range files {
+		blocks[i] = chat.NewContextBlock(f.Path, f.Lang, f.Lines())
+	}
+	return blocks
+}
+
+// attachmentSummary describes attachments, warning when they exceed the
+// configured token budget.
+func (m *Model) attachmentSummary(files []attach.File) string {
+	tokens := 0
+	for _, f := range files {
+		tokens += f.Tokens()
+	}
+	summary := fmt.Sprintf("%d file(s) attached, ~%d tokens", len(files), tokens)
+	if warn := m.Config.Attachments.WarnTokens; warn > 0 && tokens > warn {
+		summary = fmt.Sprintf("warning: %s (over %d)", summary, warn)
+	}
+	return summary
+}
+
+// maxFileSize returns the per-file limit in bytes.
+func (m *Model) maxFileSize() int64 {
+	return int64(m.Config.Attachments.MaxFileSize) * 1024
+}
+
+// checkTotalSize enforces the limit on all attachments of one message.
+func (m *Model) checkTotalSize(files []attach.File) error {
+	limit := int64(m.Config.Attachments.MaxTotalSize) * 1024
+	var total int64
+	for _, f := range files {
+		total += int64(len(f.Content))
+	}
+	if limit > 0 && total > limit {
+		return fmt.Errorf("attachments too large (%d KiB, limit %d KiB)", (total+1023)/1024, limit/1024)
+	}
+	return nil
+}
+
+// hasAttachment reports whether path is already among files.
+func hasAttachment(files []attach.File, path string) bool {
+	for _, f := range files {
+		if f.Path == path {
+			return true
+		}
+	}
+	return false
+}
+
+// AttachStdin starts a fresh session with piped input attached to its
+// first message and the prompt ready for typing. Input beyond the total
+// attachment limit is cut from the start, keeping the most recent lines
+// (e.g. of a log).
+func (m *Model) AttachStdin(text string) {
+	if strings.TrimSpace(text) == "" {
+		return
+	}
+	if attach.IsBinary([]byte(text)) {
+		m.CmdLine.SetError(fmt.Errorf("stdin: %w", attach.ErrBinary))
+		return
+	}
+
+	m.startSession(nil)
+	limit := m.Config.Attachments.MaxTotalSize * 1024
+	truncated := limit > 0 && len(text) > limit
+	if truncated {
+		text = text[len(text)-limit:]
+		if i := strings.IndexByte(text, '\n'); i >= 0 {
+			text = text[i+1:]
+		}
+		text = strings.ToValidUTF8(text, "")
+	}
+	m.attachments = []attach.File{{Path: "stdin", Content: text}}
+
+	summary := m.attachmentSummary(m.attachments)
+	if truncated {
+		summary += fmt.Sprintf(" (stdin cut to the last %d KiB)", limit/1024)
+	}
+	m.CmdLine.SetMessage(summary)
+	m.enterInsert()
+}
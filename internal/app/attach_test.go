@@ -0,0 +1,24 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPromptAttachmentsExpandHome(t *testing.T) {
+	m, _ := newTestModel(t, "", "")
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	if err := os.WriteFile(filepath.Join(home, "notes.md"), []byte("# Notes\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := m.promptAttachments("summarize @~/notes.md and ask @someone")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || files[0].Path != "~/notes.md" || files[0].Content != "# Notes\n" {
+		t.Errorf("promptAttachments() = %+v, want ~/notes.md attached", files)
+	}
+}
@@ -0,0 +1,481 @@
+package app
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/fingergohappy/vai/internal/clipboard"
+	"github.com/fingergohappy/vai/internal/command"
+	"github.com/fingergohappy/vai/internal/config"
+	"github.com/fingergohappy/vai/internal/register"
+	"github.com/fingergohappy/vai/internal/session"
+	"github.com/fingergohappy/vai/internal/templates"
+	ui "github.com/fingergohappy/vai/internal/ui"
+)
+
+// Messages emitted by built-in ex commands. Handlers run outside the Model,
+// so they describe the change and Update applies it.
+type (
+	quitMsg          struct{}
+	writeSessionMsg  struct{ path string }
+	newSessionMsg    struct{}
+	editSessionMsg   struct{ name string }
+	editPromptMsg    struct{}
+	setModelMsg      struct{ name string }
+	setOptionMsg     struct{ args []string }
+	showHelpMsg      struct{ query string }
+	showRegistersMsg struct{}
+	showConfigMsg    struct{}
+	sourceConfigMsg  struct{}
+	profileMsg       struct{ name string }
+	clearSessionMsg  struct{}
+	templateMsg      struct{ name string }
+	attachMsg        struct{ pattern string }
+	detachMsg        struct{}
+	noHighlightMsg   struct{}
+)
+
+// editSystemMsg sets, resets or edits the system prompt (:system).
+type editSystemMsg struct {
+	text  string
+	reset bool
+}
+
+// saveTemplateMsg saves the prompt as a template (:template-save).
+type saveTemplateMsg struct {
+	name      string
+	overwrite bool
+}
+
+// sendPaneMsg sends a register to a tmux pane (:send-pane).
+type sendPaneMsg struct {
+	target   string
+	register rune
+	enter    bool
+}
+
+// emit returns a command that sends msg.
+func emit(msg tea.Msg) tea.Cmd {
+	return func() tea.Msg { return msg }
+}
+
+// completions holds the completion candidates that come from the config.
+// The registry keeps a pointer, so a reloaded config updates them.
+type completions struct {
+	models   []string
+	profiles []string
+
+	// provider and model are the configured provider and its default
+	// model, which :model checks names against along with models
+	provider string
+	model    string
+}
+
+// newCompletions returns the completion candidates of cfg.
+func newCompletions(cfg config.Config) *completions {
+	profiles := make([]string, 0, len(cfg.Profiles))
+	for name := range cfg.Profiles {
+		profiles = append(profiles, name)
+	}
+	sort.Strings(profiles)
+	return &completions{
+		models:   cfg.Provider.Models,
+		profiles: profiles,
+		provider: cfg.Provider.Name,
+		model:    cfg.Provider.Model,
+	}
+}
+
+// checkModel returns an error unless name is the provider's default model
+// or one of provider.models. Any name is accepted when no models are listed.
+func (c *completions) checkModel(name string) error {
+	if len(c.models) == 0 || name == c.model || slices.Contains(c.models, name) {
+		return nil
+	}
+	return fmt.Errorf("unknown model for %s: %s (provider.models lists %s)", c.provider, name, strings.Join(c.models, ", "))
+}
+
+// registerCommands adds the built-in ex commands to the registry.
+func registerCommands(reg *command.Registry, store *session.Store, library *templates.Library, comp *completions) {
+	reg.MustRegister(command.Command{
+		Name:        "quit",
+		Aliases:     []string{"q"},
+		Description: "Quit vai",
+		Run: func(ctx command.Context) (tea.Cmd, error) {
+			return emit(quitMsg{}), nil
+		},
+	})
+
+	reg.MustRegister(command.Command{
+		Name:        "write",
+		Aliases:     []string{"w"},
+		Usage:       "[file]",
+		Description: "Save the session, or export it as markdown to file",
+		Run: func(ctx command.Context) (tea.Cmd, error) {
+			return emit(writeSessionMsg{path: ctx.Raw}), nil
+		},
+		Complete: func(args []string, argIdx int) []string {
+			return command.CompleteFiles(args[argIdx])
+		},
+	})
+
+	reg.MustRegister(command.Command{
+		Name:        "wq",
+		Aliases:     []string{"x"},
+		Description: "Save the session and quit",
+		Run: func(ctx command.Context) (tea.Cmd, error) {
+			return tea.Sequence(emit(writeSessionMsg{}), emit(quitMsg{})), nil
+		},
+	})
+
+	reg.MustRegister(command.Command{
+		Name:        "new",
+		Aliases:     []string{"ne"},
+		Description: "Start a new session",
+		Run: func(ctx command.Context) (tea.Cmd, error) {
+			return emit(newSessionMsg{}), nil
+		},
+	})
+
+	reg.MustRegister(command.Command{
+		Name:        "edit",
+		Aliases:     []string{"e"},
+		Usage:       "[session]",
+		Description: "Open a session by title or ID, or the prompt in $EDITOR",
+		Run: func(ctx command.Context) (tea.Cmd, error) {
+			if ctx.Raw == "" {
+				return emit(editPromptMsg{}), nil
+			}
+			return emit(editSessionMsg{name: ctx.Raw}), nil
+		},
+		Complete: func(args []string, argIdx int) []string {
+			return sessionNames(store)
+		},
+	})
+
+	reg.MustRegister(command.Command{
+		Name:        "model",
+		Aliases:     []string{"mo"},
+		Usage:       "{name}",
+		Description: "Set the model of the current session",
+		Slash:       true,
+		Run: func(ctx command.Context) (tea.Cmd, error) {
+			if ctx.Raw == "" {
+				return nil, fmt.Errorf("argument required")
+			}
+			if err := comp.checkModel(ctx.Raw); err != nil {
+				return nil, err
+			}
+			return emit(setModelMsg{name: ctx.Raw}), nil
+		},
+		Complete: func(args []string, argIdx int) []string {
+			return comp.models
+		},
+	})
+
+	reg.MustRegister(command.Command{
+		Name:        "system",
+		Aliases:     []string{"sy"},
+		Usage:       "[text]",
+		Description: "Set the system prompt of the session, or edit it in $EDITOR; ! resets it to the default",
+		Slash:       true,
+		Run: func(ctx command.Context) (tea.Cmd, error) {
+			return emit(editSystemMsg{text: ctx.Raw, reset: ctx.Bang}), nil
+		},
+	})
+
+	reg.MustRegister(command.Command{
+		Name:        "clear",
+		Aliases:     []string{"cl"},
+		Description: "Remove all messages from the current session",
+		Slash:       true,
+		Run: func(ctx command.Context) (tea.Cmd, error) {
+			return emit(clearSessionMsg{}), nil
+		},
+	})
+
+	reg.MustRegister(command.Command{
+		Name:        "export",
+		Usage:       "{file}",
+		Description: "Export the session as markdown to file",
+		Slash:       true,
+		Run: func(ctx command.Context) (tea.Cmd, error) {
+			if ctx.Raw == "" {
+				return nil, fmt.Errorf("argument required")
+			}
+			return emit(writeSessionMsg{path: ctx.Raw}), nil
+		},
+		Complete: func(args []string, argIdx int) []string {
+			return command.CompleteFiles(args[argIdx])
+		},
+	})
+
+	reg.MustRegister(command.Command{
+		Name:        "template",
+		Aliases:     []string{"te"},
+		Usage:       "[name]",
+		Description: "Expand a prompt template into the input area, or pick one",
+		Slash:       true,
+		Run: func(ctx command.Context) (tea.Cmd, error) {
+			return emit(templateMsg{name: ctx.Raw}), nil
+		},
+		Complete: func(args []string, argIdx int) []string {
+			names, _ := library.Names()
+			return names
+		},
+	})
+
+	reg.MustRegister(command.Command{
+		Name:        "template-save",
+		Usage:       "{name}",
+		Description: "Save the prompt as a template; ! overwrites an existing one",
+		Run: func(ctx command.Context) (tea.Cmd, error) {
+			if ctx.Raw == "" {
+				return nil, fmt.Errorf("argument required")
+			}
+			return emit(saveTemplateMsg{name: ctx.Raw, overwrite: ctx.Bang}), nil
+		},
+		Complete: func(args []string, argIdx int) []string {
+			names, _ := library.Names()
+			return names
+		},
+	})
+
+	reg.MustRegister(command.Command{
+		Name:        "set",
+		Aliases:     []string{"se"},
+		Usage:       "{option}[=value] | no{option} | {option}?",
+		Description: "Show or change an option",
+		Run: func(ctx command.Context) (tea.Cmd, error) {
+			return emit(setOptionMsg{args: ctx.Args}), nil
+		},
+		Complete: func(args []string, argIdx int) []string {
+			return optionNames()
+		},
+	})
+
+	reg.MustRegister(command.Command{
+		Name:        "help",
+		Aliases:     []string{"h"},
+		Usage:       "[text]",
+		Description: "Show the keys and commands, or those that mention text",
+		Run: func(ctx command.Context) (tea.Cmd, error) {
+			return emit(showHelpMsg{query: ctx.Raw}), nil
+		},
+	})
+
+	reg.MustRegister(command.Command{
+		Name:        "registers",
+		Aliases:     []string{"reg", "di"},
+		Description: "List register contents",
+		Run: func(ctx command.Context) (tea.Cmd, error) {
+			return emit(showRegistersMsg{}), nil
+		},
+	})
+
+	reg.MustRegister(command.Command{
+		Name:        "config",
+		Description: "List problems in the configuration",
+		Run: func(ctx command.Context) (tea.Cmd, error) {
+			return emit(showConfigMsg{}), nil
+		},
+	})
+
+	reg.MustRegister(command.Command{
+		Name:        "source",
+		Aliases:     []string{"so"},
+		Description: "Reload the config file",
+		Run: func(ctx command.Context) (tea.Cmd, error) {
+			return emit(sourceConfigMsg{}), nil
+		},
+	})
+
+	reg.MustRegister(command.Command{
+		Name:        "profile",
+		Usage:       "[name]",
+		Description: "Switch to a config profile, or show the current one",
+		Run: func(ctx command.Context) (tea.Cmd, error) {
+			return emit(profileMsg{name: ctx.Raw}), nil
+		},
+		Complete: func(args []string, argIdx int) []string {
+			return comp.profiles
+		},
+	})
+
+	reg.MustRegister(command.Command{
+		Name:        "attach",
+		Usage:       "[glob]",
+		Description: "Attach matching files to the next message, or list attachments",
+		Slash:       true,
+		Run: func(ctx command.Context) (tea.Cmd, error) {
+			return emit(attachMsg{pattern: ctx.Raw}), nil
+		},
+		Complete: func(args []string, argIdx int) []string {
+			return command.CompleteFiles(args[argIdx])
+		},
+	})
+
+	reg.MustRegister(command.Command{
+		Name:        "detach",
+		Description: "Remove all attachments from the next message",
+		Run: func(ctx command.Context) (tea.Cmd, error) {
+			return emit(detachMsg{}), nil
+		},
+	})
+
+	reg.MustRegister(command.Command{
+		Name:        "send-pane",
+		Usage:       "{target} [x]",
+		Description: "Send register x (default: last yank) to a tmux pane; ! presses Enter",
+		Run: func(ctx command.Context) (tea.Cmd, error) {
+			if ctx.Arg(0) == "" {
+				return nil, fmt.Errorf("argument required")
+			}
+			var name rune
+			if arg := ctx.Arg(1); arg != "" {
+				runes := []rune(arg)
+				if len(runes) != 1 || !register.Valid(runes[0]) {
+					return nil, fmt.Errorf("invalid register: %s", arg)
+				}
+				name = runes[0]
+			}
+			return emit(sendPaneMsg{target: ctx.Arg(0), register: name, enter: ctx.Bang}), nil
+		},
+		Complete: func(args []string, argIdx int) []string {
+			if argIdx > 0 {
+				return nil
+			}
+			return append([]string{"{last}", "{next}", "{left}", "{right}", "{up}", "{down}"}, clipboard.NewTmux().Panes()...)
+		},
+	})
+
+	registerMapCommands(reg)
+
+	reg.MustRegister(command.Command{
+		Name:        "nohlsearch",
+		Aliases:     []string{"noh"},
+		Description: "Clear search highlighting",
+		Run: func(ctx command.Context) (tea.Cmd, error) {
+			return emit(noHighlightMsg{}), nil
+		},
+	})
+}
+
+// handleSubmit runs a submitted command line or search.
+func (m Model) handleSubmit(msg command.SubmitMsg) (Model, tea.Cmd) {
+	switch msg.Prompt {
+	case ':':
+		if strings.TrimSpace(msg.Value) == "" {
+			return m, nil
+		}
+		cmd, err := m.Commands.Execute(msg.Value)
+		if err != nil {
+			m.CmdLine.SetError(err)
+			return m, nil
+		}
+		return m, cmd
+	case '/', '?':
+		m.CmdLine.SetError(m.Chat.Search(msg.Value, msg.Prompt == '?'))
+	case command.InputPrompt:
+		cmd, err := m.answerTemplate(msg.Value)
+		m.reportError(err)
+		return m, cmd
+	}
+	return m, nil
+}
+
+// handleCommandMsg applies messages emitted by built-in commands.
+// It returns false if msg is not a command message.
+func (m Model) handleCommandMsg(msg tea.Msg) (Model, tea.Cmd, bool) {
+	switch msg := msg.(type) {
+	case quitMsg:
+		m.reportError(m.saveDraft())
+		m.quitting = true
+		return m, tea.Quit, true
+	case writeSessionMsg:
+		m.reportError(m.writeSession(msg.path))
+	case newSessionMsg:
+		m.newSession()
+	case editSessionMsg:
+		m.reportError(m.openSession(msg.name))
+	case setModelMsg:
+		m.setModel(msg.name)
+	case setOptionMsg:
+		prevTheme := m.Config.Theme.Name
+		m.reportError(m.setOptions(msg.args))
+		if m.Config.Theme.Name != prevTheme {
+			m.reportError(m.applyTheme())
+		}
+	case showHelpMsg:
+		m.showHelp(msg.query)
+	case attachMsg:
+		if msg.pattern == "" {
+			m.showAttachments()
+		} else {
+			m.reportError(m.attachFiles(msg.pattern))
+		}
+	case detachMsg:
+		m.detachFiles()
+	case sendPaneMsg:
+		m.reportError(m.sendPane(msg))
+	case showRegistersMsg:
+		m.showRegisters()
+	case showConfigMsg:
+		m.showConfigIssues()
+	case sourceConfigMsg:
+		return m, m.loadConfig(true), true
+	case profileMsg:
+		cmd, err := m.switchProfile(msg.name)
+		m.reportError(err)
+		return m, cmd, true
+	case noHighlightMsg:
+		m.Chat.ClearSearch()
+	case mapMsg:
+		m.reportError(m.handleMap(msg))
+	case editPromptMsg:
+		return m, m.editPrompt(), true
+	case editorDoneMsg:
+		m.reportError(m.finishEdit(msg))
+	case editSystemMsg:
+		switch {
+		case msg.reset:
+			m.reportError(m.setSystem(m.Config.SystemPrompt))
+		case msg.text != "":
+			m.reportError(m.setSystem(msg.text))
+		default:
+			return m, m.editSystem(), true
+		}
+	case clearSessionMsg:
+		m.reportError(m.clearSession())
+	case templateMsg:
+		cmd, err := m.pickTemplate(msg.name)
+		m.reportError(err)
+		return m, cmd, true
+	case saveTemplateMsg:
+		m.reportError(m.saveTemplate(msg.name, msg.overwrite))
+	default:
+		return m, nil, false
+	}
+	return m, nil, true
+}
+
+// reportError shows err in the command line. A nil error leaves any
+// message set by the command in place.
+func (m *Model) reportError(err error) {
+	if err != nil {
+		m.CmdLine.SetError(config.RedactError(err))
+	}
+}
+
+// showErrors opens a popup listing errors, e.g. invalid config entries.
+func (m *Model) showErrors(title string, errs []error) {
+	lines := make([]string, len(errs))
+	for i, err := range errs {
+		lines[i] = err.Error()
+	}
+	m.popup = ui.NewPopup(m.Styles, title, lines)
+}
@@ -0,0 +1,57 @@
+package app
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fingergohappy/vai/internal/config"
+)
+
+func TestCheckModel(t *testing.T) {
+	tests := []struct {
+		name   string
+		models []string
+		model  string
+		arg    string
+		ok     bool
+	}{
+		{"listed", []string{"gpt-4", "gpt-4o"}, "gpt-4", "gpt-4o", true},
+		{"default model", []string{"gpt-4o"}, "gpt-4", "gpt-4", true},
+		{"not listed", []string{"gpt-4", "gpt-4o"}, "gpt-4", "gpt-5", false},
+		{"prefix of a listed model", []string{"gpt-4o"}, "gpt-4o", "gpt", false},
+		{"no list accepts any", nil, "llama3", "mistral", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			comp := &completions{models: tt.models, provider: "openai", model: tt.model}
+			if err := comp.checkModel(tt.arg); (err == nil) != tt.ok {
+				t.Errorf("checkModel(%q) = %v, want ok %v", tt.arg, err, tt.ok)
+			}
+		})
+	}
+}
+
+func TestModelCommand(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	cfg := config.Parse([]byte("provider:\n  name: openai\n  model: gpt-4\n  models: [gpt-4, gpt-4o]\n"), "config.yaml")
+	m := NewModel(cfg)
+
+	for _, line := range []string{"model gpt-4o", "/model gpt-4o"} {
+		run := m.Commands.Execute
+		if strings.HasPrefix(line, "/") {
+			run = m.Commands.ExecuteSlash
+		}
+		if _, err := run(line); err != nil {
+			t.Errorf("%s: %v", line, err)
+		}
+	}
+
+	want := "unknown model for openai: gpt-5 (provider.models lists gpt-4, gpt-4o)"
+	if _, err := m.Commands.Execute("model gpt-5"); err == nil || err.Error() != want {
+		t.Errorf(":model gpt-5 error = %v, want %q", err, want)
+	}
+	if _, err := m.Commands.ExecuteSlash("/model gpt-5"); err == nil || err.Error() != want {
+		t.Errorf("/model gpt-5 error = %v, want %q", err, want)
+	}
+}
@@ -0,0 +1,241 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/fingergohappy/vai/internal/clipboard"
+	"github.com/fingergohappy/vai/internal/command"
+	"github.com/fingergohappy/vai/internal/config"
+	"github.com/fingergohappy/vai/internal/provider"
+	"github.com/fingergohappy/vai/internal/register"
+	"github.com/fingergohappy/vai/internal/session"
+	"github.com/fingergohappy/vai/internal/templates"
+	"github.com/fingergohappy/vai/internal/theme"
+	ui "github.com/fingergohappy/vai/internal/ui"
+	"github.com/fingergohappy/vai/internal/vim"
+)
+
+// CheckConfig returns every problem with cfg: those found while loading
+// the file and those in the settings checked by the app (theme, clipboard
+// backend, provider and keybindings). It backs vai config check.
+func CheckConfig(cfg config.Config) []error {
+	commands := command.NewRegistry()
+	registerCommands(commands, session.DefaultStore(), templates.DefaultLibrary(), newCompletions(cfg))
+	m := Model{
+		Config:    cfg,
+		Styles:    ui.DefaultStyles(),
+		Registers: register.New(nil, ""),
+		Router:    vim.NewRouter(),
+		Commands:  commands,
+	}
+	m.applyConfig()
+	return m.configIssues
+}
+
+// applyConfig applies the theme, clipboard backend and keybindings of
+// m.Config and collects all problems with the configuration. Settings with
+// problems fall back to their defaults.
+func (m *Model) applyConfig() {
+	cfg := m.Config
+	m.configIssues = nil
+	m.issuesSeen = false
+	for _, issue := range cfg.Issues {
+		m.configIssues = append(m.configIssues, issue)
+	}
+	fail := func(key string, err error) {
+		m.configIssues = append(m.configIssues, cfg.IssueAt(key, err))
+	}
+
+	if err := m.applyTheme(); err != nil {
+		fail("theme", err)
+	}
+
+	cb, err := clipboard.New(cfg.Clipboard.Backend)
+	if err != nil {
+		fail("clipboard.backend", err)
+		cb = clipboard.Detect()
+	}
+	m.Registers.SetClipboard(cb)
+
+	if _, err := provider.New(cfg.Provider); err != nil {
+		fail("provider.name", err)
+	}
+
+	m.Router.SetKeymap(defaultKeymap())
+	m.configIssues = append(m.configIssues, m.applyKeybindings(cfg.Keybindings, cfg.Origin("keybindings.leader").Path)...)
+}
+
+// applyTheme loads the configured theme and restyles every component. If
+// the theme has errors, the usable part of it is still applied.
+func (m *Model) applyTheme() error {
+	t, err := theme.Load(m.Config.Theme, theme.DefaultDir())
+	*m.Styles = *ui.NewStyles(t)
+	m.CmdLine.SetStyles(commandLineStyles(m.Styles))
+	m.Chat.SetTheme(t)
+	return err
+}
+
+// bannerVisible returns true while there are config problems that have
+// not been listed yet.
+func (m Model) bannerVisible() bool {
+	return len(m.configIssues) > 0 && !m.issuesSeen
+}
+
+// renderBanner renders the config warning line: the number of problems,
+// how to list them, and the first one.
+func (m Model) renderBanner() string {
+	text := "config problem"
+	if n := len(m.configIssues); n > 1 {
+		text = fmt.Sprintf("%d config problems", n)
+	}
+	text += " (:config to list): " + m.configIssues[0].Error()
+	return m.Styles.Banner.Width(m.size.Width).Render(ansi.Truncate(text, m.size.Width, "…"))
+}
+
+// showConfigIssues lists the config problems in a popup (:config) and
+// hides the banner.
+func (m *Model) showConfigIssues() {
+	if len(m.configIssues) == 0 {
+		path := m.Config.Path
+		if path == "" {
+			path = "defaults"
+		}
+		m.CmdLine.SetMessage("config OK: " + path)
+		return
+	}
+	m.showErrors("Config problems", m.configIssues)
+	if !m.issuesSeen {
+		m.issuesSeen = true
+		m.resize(m.size)
+	}
+}
+
+// configPollInterval is how often the config file is checked for changes.
+const configPollInterval = 2 * time.Second
+
+// configPollMsg reports a poll that found the config file unchanged.
+type configPollMsg struct{}
+
+// configChangedMsg carries a reloaded config; profile is the profile that
+// was asked for and manual is set for :source and :profile, which do not
+// continue the polling.
+type configChangedMsg struct {
+	cfg     config.Config
+	err     error
+	profile string
+	manual  bool
+}
+
+// newConfigWatcher watches the files the loader reads.
+func newConfigWatcher(loader *config.Loader) *config.Watcher {
+	paths := []string{loader.Path()}
+	if project := loader.ProjectPath(); project != "" {
+		paths = append(paths, project)
+	}
+	return config.NewWatcher(paths...)
+}
+
+// SetLoader sets the loader that read the config, so that reloads use the
+// same files and profile.
+func (m *Model) SetLoader(loader *config.Loader) {
+	m.loader = loader
+	m.configWatcher = newConfigWatcher(loader)
+}
+
+// watchConfig polls the config files once after configPollInterval and
+// loads them if they changed.
+func (m Model) watchConfig() tea.Cmd {
+	if m.configWatcher == nil {
+		return nil
+	}
+	w := m.configWatcher
+	load := m.loadConfig(false)
+	return tea.Tick(configPollInterval, func(time.Time) tea.Msg {
+		if !w.Changed() {
+			return configPollMsg{}
+		}
+		return load()
+	})
+}
+
+// loadConfig returns a command that loads the config files.
+func (m Model) loadConfig(manual bool) tea.Cmd {
+	return loadConfigFiles(*m.loader, manual)
+}
+
+// loadConfigFiles returns a command that loads the config with a copy of
+// the loader, so the running one keeps its profile until the new config
+// is applied.
+func loadConfigFiles(loader config.Loader, manual bool) tea.Cmd {
+	return func() tea.Msg {
+		cfg, err := loader.Load()
+		return configChangedMsg{cfg: cfg, err: err, profile: loader.Profile(), manual: manual}
+	}
+}
+
+// reloadConfig applies a reloaded config: theme, keybindings, clipboard,
+// editor and provider settings. If a file does not parse, the old config
+// stays; other problems are reported as at startup.
+func (m *Model) reloadConfig(msg configChangedMsg) {
+	if msg.err == nil {
+		for _, issue := range msg.cfg.Issues {
+			if issue.Fatal {
+				msg.err = issue
+				break
+			}
+		}
+	}
+	if msg.err != nil {
+		m.CmdLine.SetError(fmt.Errorf("config not reloaded: %w", msg.err))
+		return
+	}
+
+	prev := m.Config.Profile
+	m.Config = msg.cfg
+	m.loader.SetProfile(msg.profile)
+	m.optionsSet = nil
+	*m.completions = *newCompletions(msg.cfg)
+	m.applyConfig()
+	m.resize(m.size)
+
+	switch {
+	case len(m.configIssues) > 0:
+		m.CmdLine.SetError(fmt.Errorf("config reloaded with problems (:config to list)"))
+	case m.Config.Profile != prev:
+		m.CmdLine.SetMessage("profile: " + profileName(m.Config.Profile))
+	default:
+		m.CmdLine.SetMessage("config reloaded")
+	}
+}
+
+// switchProfile reloads the config with the named profile (:profile), or
+// shows the current profile and the others.
+func (m *Model) switchProfile(name string) (tea.Cmd, error) {
+	if len(m.Config.Profiles) == 0 {
+		return nil, fmt.Errorf("no profiles in the config")
+	}
+	if name == "" {
+		m.CmdLine.SetMessage(fmt.Sprintf("profile: %s (%s)",
+			profileName(m.Config.Profile), strings.Join(m.completions.profiles, ", ")))
+		return nil, nil
+	}
+	if _, ok := m.Config.Profiles[name]; !ok {
+		return nil, fmt.Errorf("unknown profile: %s", name)
+	}
+	loader := *m.loader
+	loader.SetProfile(name)
+	return loadConfigFiles(loader, true), nil
+}
+
+// profileName returns the name of a profile for messages.
+func profileName(name string) string {
+	if name == "" {
+		return "none"
+	}
+	return name
+}
@@ -0,0 +1,40 @@
+package app
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// draftDelay is how long the prompt must stay unchanged before its draft
+// is saved, so a crash loses at most that much typing.
+const draftDelay = time.Second
+
+// draftTickMsg asks to save the draft; only the tick of the latest edit
+// (seq) saves.
+type draftTickMsg struct{ seq int }
+
+// scheduleDraftSave starts the delay before saving the draft, superseding
+// any earlier pending save.
+func (m *Model) scheduleDraftSave() tea.Cmd {
+	m.draftSeq++
+	seq := m.draftSeq
+	return tea.Tick(draftDelay, func(time.Time) tea.Msg {
+		return draftTickMsg{seq: seq}
+	})
+}
+
+// saveDraft stores the unsent prompt in the current session. The session's
+// update time is kept so that a draft does not reorder the session list.
+func (m *Model) saveDraft() error {
+	sess := m.Session.Current()
+	draft := m.Input.Value()
+	if sess == nil || sess.Draft == draft {
+		return nil
+	}
+	updated := sess.UpdatedAt
+	m.syncCurrentSession()
+	sess.UpdatedAt = updated
+	sess.Draft = draft
+	return m.Store.Save(*sess)
+}
@@ -0,0 +1,39 @@
+package app
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestQuitSavesDraft(t *testing.T) {
+	tests := []struct {
+		name string
+		key  tea.KeyMsg
+	}{
+		{"ctrl-c", tea.KeyMsg{Type: tea.KeyCtrlC}},
+		{"ctrl-q", tea.KeyMsg{Type: tea.KeyCtrlQ}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, _ := newTestModel(t, "", "")
+			sess := m.Session.Current()
+			if sess == nil {
+				t.Fatal("no current session")
+			}
+			m.Input.SetValue("half a thought")
+
+			updated, cmd := m.Update(tt.key)
+			if cmd == nil || !updated.(Model).quitting {
+				t.Fatalf("%s did not quit", tt.name)
+			}
+			saved, err := m.Store.Load(sess.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if saved.Draft != "half a thought" {
+				t.Errorf("saved draft = %q, want %q", saved.Draft, "half a thought")
+			}
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package app
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/fingergohappy/vai/internal/input"
+	"github.com/fingergohappy/vai/internal/register"
+	ui "github.com/fingergohappy/vai/internal/ui"
+	"github.com/fingergohappy/vai/internal/vim"
+)
+
+// change is the last prompt change, repeated by '.'. Keys typed in the
+// INSERT mode it started are replayed after it.
+type change struct {
+	run  func(m *Model, ctx vim.Context) tea.Cmd
+	ctx  vim.Context
+	keys []tea.KeyMsg
+}
+
+// editingActions are the NORMAL mode commands of the prompt editor.
+var editingActions = map[string]action{
+	"append": {desc: "Append after the cursor", repeatable: true, run: func(m *Model, ctx vim.Context) tea.Cmd {
+		return m.startInsert(input.InsertAfterCursor)
+	}},
+	"insert-line-start": {desc: "Insert before the first non-blank of the line", repeatable: true, run: func(m *Model, ctx vim.Context) tea.Cmd {
+		return m.startInsert(input.InsertLineStart)
+	}},
+	"append-line-end": {desc: "Append at the end of the line", repeatable: true, run: func(m *Model, ctx vim.Context) tea.Cmd {
+		return m.startInsert(input.InsertLineEnd)
+	}},
+	"open-below": {desc: "Open a line below the cursor", repeatable: true, run: func(m *Model, ctx vim.Context) tea.Cmd {
+		return m.startInsert(input.InsertBelow)
+	}},
+	"open-above": {desc: "Open a line above the cursor", repeatable: true, run: func(m *Model, ctx vim.Context) tea.Cmd {
+		return m.startInsert(input.InsertAbove)
+	}},
+	"delete": {desc: "Delete {motion} into [register]", flags: vim.FlagOperator, repeatable: true, run: func(m *Model, ctx vim.Context) tea.Cmd {
+		text, ok := m.Input.Delete(ctx.Motion, ctx.Count)
+		if !ok {
+			m.CmdLine.SetError(fmt.Errorf("cannot delete %s", ctx.Motion))
+			return nil
+		}
+		m.store(ctx.Register, text)
+		return nil
+	}},
+	"change": {desc: "Change {motion} into [register]", flags: vim.FlagOperator, repeatable: true, run: func(m *Model, ctx vim.Context) tea.Cmd {
+		text, ok := m.Input.Change(ctx.Motion, ctx.Count)
+		if !ok {
+			m.CmdLine.SetError(fmt.Errorf("cannot change %s", ctx.Motion))
+			return nil
+		}
+		m.store(ctx.Register, text)
+		return m.enterInsert()
+	}},
+	"delete-char": {desc: "Delete [count] characters under the cursor", repeatable: true, run: func(m *Model, ctx vim.Context) tea.Cmd {
+		m.store(ctx.Register, m.Input.DeleteChar(ctx.Count))
+		return nil
+	}},
+	"delete-to-end": {desc: "Delete to the end of the line", repeatable: true, run: func(m *Model, ctx vim.Context) tea.Cmd {
+		text, _ := m.Input.Delete("line-end", ctx.Count)
+		m.store(ctx.Register, text)
+		return nil
+	}},
+	"change-to-end": {desc: "Change to the end of the line", repeatable: true, run: func(m *Model, ctx vim.Context) tea.Cmd {
+		text, _ := m.Input.Change("line-end", ctx.Count)
+		m.store(ctx.Register, text)
+		return m.enterInsert()
+	}},
+	"put-after": {desc: "Put [register] after the cursor", repeatable: true, run: func(m *Model, ctx vim.Context) tea.Cmd {
+		m.put(ctx, false)
+		return nil
+	}},
+	"put-before": {desc: "Put [register] before the cursor", repeatable: true, run: func(m *Model, ctx vim.Context) tea.Cmd {
+		m.put(ctx, true)
+		return nil
+	}},
+	"undo": {desc: "Undo [count] changes to the prompt", run: func(m *Model, ctx vim.Context) tea.Cmd {
+		for range ctx.CountOr(1) {
+			if !m.Input.Undo() {
+				m.CmdLine.SetMessage("Already at oldest change")
+				break
+			}
+		}
+		return nil
+	}},
+	"redo": {desc: "Redo [count] changes to the prompt", run: func(m *Model, ctx vim.Context) tea.Cmd {
+		for range ctx.CountOr(1) {
+			if !m.Input.Redo() {
+				m.CmdLine.SetMessage("Already at newest change")
+				break
+			}
+		}
+		return nil
+	}},
+	"repeat": {desc: "Repeat the last change to the prompt", run: func(m *Model, ctx vim.Context) tea.Cmd {
+		m.repeatChange(ctx.Count)
+		return nil
+	}},
+}
+
+// startInsert positions the prompt cursor and enters INSERT mode.
+func (m *Model) startInsert(at input.InsertAt) tea.Cmd {
+	m.Input.BeginInsert(at)
+	return m.enterInsert()
+}
+
+// enterInsert switches to INSERT mode in the input area. Typed keys are
+// recorded for '.' when a repeatable change started it.
+func (m *Model) enterInsert() tea.Cmd {
+	m.recording = m.Focus == ui.FocusInput && m.lastChange != nil
+	m.Mode = vim.ModeInsert
+	m.setFocus(ui.FocusInput)
+	return nil
+}
+
+// stopInsert returns from INSERT to NORMAL mode, keeping the input area
+// focused.
+func (m *Model) stopInsert() {
+	if m.Mode == vim.ModeInsert {
+		m.Input.EndInsert()
+	}
+	m.recording = false
+	m.Mode = vim.ModeNormal
+	m.setFocus(m.Focus)
+}
+
+// recordKey remembers a key typed in INSERT mode for '.'.
+func (m *Model) recordKey(key tea.KeyMsg) {
+	if m.recording && m.lastChange != nil {
+		m.lastChange.keys = append(m.lastChange.keys, key)
+	}
+}
+
+// repeatChange repeats the last change, with a new count if given.
+func (m *Model) repeatChange(count int) {
+	last := m.lastChange
+	if last == nil || m.Focus != ui.FocusInput {
+		return
+	}
+	ctx := last.ctx
+	if count > 0 {
+		ctx.Count = count
+	}
+	last.run(m, ctx)
+	if m.Mode == vim.ModeInsert {
+		for _, key := range last.keys {
+			model, _ := m.Input.Update(key)
+			m.Input = model.(input.Model)
+		}
+		m.stopInsert()
+	}
+	m.lastChange = last
+}
+
+// store writes deleted or changed text to a register.
+func (m *Model) store(name rune, text string) {
+	if text == "" {
+		return
+	}
+	if err := m.Registers.Delete(name, text); err != nil {
+		m.CmdLine.SetError(err)
+	}
+}
+
+// put inserts a register into the prompt.
+func (m *Model) put(ctx vim.Context, before bool) {
+	name := ctx.Register
+	if name == 0 {
+		name = register.Unnamed
+	}
+	text, err := m.Registers.Read(name)
+	if err != nil {
+		m.CmdLine.SetError(err)
+		return
+	}
+	m.Input.Put(normalizeNewlines(text), before, ctx.Count)
+}
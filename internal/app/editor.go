@@ -0,0 +1,144 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// editorDoneMsg is sent when the external editor exits.
+type editorDoneMsg struct {
+	path    string
+	text    string    // content written before the editor ran
+	modTime time.Time // modification time before the editor ran
+	system  bool      // the file holds the system prompt (:system)
+	err     error
+}
+
+// editorCommand returns the editor to run: $VISUAL, then $EDITOR, then vi.
+// The variable may include arguments (e.g. "code --wait").
+func editorCommand() []string {
+	for _, env := range []string{"VISUAL", "EDITOR"} {
+		if fields := strings.Fields(os.Getenv(env)); len(fields) > 0 {
+			return fields
+		}
+	}
+	return []string{"vi"}
+}
+
+// editPrompt suspends the TUI and opens the prompt in the external editor
+// through a temporary markdown file.
+func (m *Model) editPrompt() tea.Cmd {
+	return m.openEditor(m.Input.Value(), "vai-prompt-*.md", false)
+}
+
+// editSystem opens the system prompt of the current session in the
+// external editor (:system).
+func (m *Model) editSystem() tea.Cmd {
+	sess := m.Session.Current()
+	if sess == nil {
+		m.CmdLine.SetError(fmt.Errorf("no current session"))
+		return nil
+	}
+	return m.openEditor(sess.System, "vai-system-*.md", true)
+}
+
+// openEditor suspends the TUI and edits text in the external editor
+// through a temporary file named after pattern.
+func (m *Model) openEditor(text, pattern string, system bool) tea.Cmd {
+	f, err := os.CreateTemp("", pattern)
+	if err != nil {
+		m.CmdLine.SetError(err)
+		return nil
+	}
+	_, err = f.WriteString(text)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	info, serr := os.Stat(f.Name())
+	if err == nil {
+		err = serr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		m.CmdLine.SetError(err)
+		return nil
+	}
+
+	args := editorCommand()
+	cmd := exec.Command(args[0], append(args[1:], f.Name())...)
+	path, modTime := f.Name(), info.ModTime()
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		return editorDoneMsg{path: path, text: text, modTime: modTime, system: system, err: err}
+	})
+}
+
+// saved reports whether the file was saved in the editor: its content or
+// its modification time changed. Comparing content catches saves within
+// the same mtime tick on filesystems with coarse timestamps.
+func (msg editorDoneMsg) saved(info os.FileInfo, data []byte) bool {
+	return string(data) != msg.text || !info.ModTime().Equal(msg.modTime)
+}
+
+// finishEdit applies the file edited in the external editor.
+func (m *Model) finishEdit(msg editorDoneMsg) error {
+	if msg.system {
+		return m.finishEditSystem(msg)
+	}
+	return m.finishEditPrompt(msg)
+}
+
+// finishEditSystem stores the edited system prompt in the current session
+// and saves it. Quitting the editor without saving changes nothing.
+func (m *Model) finishEditSystem(msg editorDoneMsg) error {
+	defer os.Remove(msg.path)
+	if msg.err != nil {
+		return fmt.Errorf("editor: %w", msg.err)
+	}
+	data, err := os.ReadFile(msg.path)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(msg.path)
+	if err != nil {
+		return err
+	}
+	if !msg.saved(info, data) {
+		return nil
+	}
+	return m.setSystem(strings.TrimSpace(string(data)))
+}
+
+// finishEditPrompt loads the edited file back into the input area. If the
+// file was saved and 'sendonsave' is set, the prompt is sent right away.
+func (m *Model) finishEditPrompt(msg editorDoneMsg) error {
+	defer os.Remove(msg.path)
+	if msg.err != nil {
+		return fmt.Errorf("editor: %w", msg.err)
+	}
+
+	data, err := os.ReadFile(msg.path)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(msg.path)
+	if err != nil {
+		return err
+	}
+	saved := msg.saved(info, data)
+	text := strings.TrimRight(string(data), "\n")
+
+	if text != m.Input.Value() {
+		m.Input.Replace(text)
+	}
+	// A slash command stays in the prompt to be run from there
+	if saved && m.Config.Editor.SendOnSave && strings.TrimSpace(text) != "" && !slashPrompt(text) {
+		m.sendPrompt()
+		m.stopInsert()
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package app
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	ui "github.com/fingergohappy/vai/internal/ui"
+	"github.com/fingergohappy/vai/internal/vim"
+)
+
+// helpModes are the modes listed by the help overlay, in order.
+var helpModes = []vim.Mode{vim.ModeNormal, vim.ModeInsert, vim.ModeVisual, vim.ModeOperatorPending}
+
+// helpFocuses are the focus areas listed by the help overlay, in order.
+var helpFocuses = []vim.Focus{vim.FocusAny, vim.FocusHistory, vim.FocusBuffer, vim.FocusInput}
+
+// showHelp opens the help overlay at the keys of the focused pane, or
+// searching for query if it is not empty.
+func (m *Model) showHelp(query string) {
+	m.help = ui.NewViewer(m.Styles, "Help", m.helpSections())
+	if query != "" {
+		m.help.Search(query)
+		return
+	}
+	m.help.ScrollToSection(helpTitle(vim.ModeNormal, vim.Focus(m.Focus)))
+}
+
+// helpSections builds the help from the live keymap, one section per mode
+// and focus area, and from the command registry. Bindings that differ
+// from the defaults are marked as user mappings, and default bindings the
+// user removed are listed as unmapped.
+func (m *Model) helpSections() []ui.Section {
+	current := m.Router.Keymap().Bindings()
+	defaults := defaultKeymap().Bindings()
+
+	var sections []ui.Section
+	for _, mode := range helpModes {
+		for _, focus := range helpFocuses {
+			var lines []string
+			for _, b := range current {
+				if b.Mode != mode || b.Focus != focus {
+					continue
+				}
+				mark := ""
+				if !slices.ContainsFunc(defaults, func(d vim.Binding) bool { return sameBinding(d, b) && d.Action == b.Action }) {
+					mark = "[user]"
+				}
+				lines = append(lines, helpLine(b.Keys, b.Action, mark, m.describeAction(b.Action)))
+			}
+			for _, d := range defaults {
+				if d.Mode != mode || d.Focus != focus {
+					continue
+				}
+				if !slices.ContainsFunc(current, func(b vim.Binding) bool { return sameBinding(d, b) }) {
+					lines = append(lines, helpLine(d.Keys, d.Action, "[unmapped]", m.describeAction(d.Action)))
+				}
+			}
+			if len(lines) > 0 {
+				sections = append(sections, ui.Section{Title: helpTitle(mode, focus), Lines: lines})
+			}
+		}
+	}
+
+	var lines []string
+	for _, c := range m.Commands.Commands() {
+		name := ":" + c.Name
+		if c.Usage != "" {
+			name += " " + c.Usage
+		}
+		desc := c.Description
+		if c.Slash {
+			desc += " (also /" + c.Name + ")"
+		}
+		lines = append(lines, fmt.Sprintf("%-28s %s", name, desc))
+	}
+	return append(sections, ui.Section{Title: "Ex commands", Lines: lines})
+}
+
+// helpTitle returns the section title of a mode and focus area.
+func helpTitle(mode vim.Mode, focus vim.Focus) string {
+	if focus == vim.FocusAny {
+		return mode.String() + " · all panes"
+	}
+	return mode.String() + " · " + focusName(focus)
+}
+
+// helpLine formats a binding for the help overlay.
+func helpLine(keys []string, action, mark, desc string) string {
+	return strings.TrimRight(fmt.Sprintf("%-12s %-20s %-10s %s", vim.FormatKeys(keys), action, mark, desc), " ")
+}
+
+// sameBinding returns true if a and b bind the same keys in the same mode
+// and focus area.
+func sameBinding(a, b vim.Binding) bool {
+	return a.Mode == b.Mode && a.Focus == b.Focus && slices.Equal(a.Keys, b.Keys)
+}
+
+// describeAction returns the description of an action, or of the ex
+// command a ":command" mapping runs.
+func (m *Model) describeAction(name string) string {
+	if line, ok := strings.CutPrefix(name, ":"); ok {
+		return "Run :" + line
+	}
+	if a, ok := actions[name]; ok {
+		return a.desc
+	}
+	return "unknown action"
+}
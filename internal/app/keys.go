@@ -0,0 +1,224 @@
+package app
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/fingergohappy/vai/internal/input"
+	ui "github.com/fingergohappy/vai/internal/ui"
+	"github.com/fingergohappy/vai/internal/vim"
+)
+
+// Pane scopes for bindings, in vim.Focus terms.
+const (
+	anyPane     = vim.FocusAny
+	historyPane = vim.FocusHistory
+	bufferPane  = vim.FocusBuffer
+	inputPane   = vim.FocusInput
+)
+
+// defaultBindings lists the built-in key bindings in Vim notation.
+var defaultBindings = []struct {
+	mode   vim.Mode
+	focus  vim.Focus
+	keys   string
+	action string
+}{
+	// NORMAL mode, any pane
+	{vim.ModeNormal, anyPane, "i", "insert"},
+	{vim.ModeNormal, anyPane, "a", "insert"},
+	{vim.ModeNormal, anyPane, ":", "command-line"},
+	{vim.ModeNormal, anyPane, "<F1>", "help"},
+	{vim.ModeNormal, historyPane, "?", "help"},
+	{vim.ModeNormal, inputPane, "?", "help"},
+	{vim.ModeNormal, anyPane, "<C-w>h", "focus-left"},
+	{vim.ModeNormal, anyPane, "<C-w>l", "focus-right"},
+	{vim.ModeNormal, anyPane, "<C-w>j", "focus-down"},
+	{vim.ModeNormal, anyPane, "<C-w>k", "focus-up"},
+	{vim.ModeNormal, anyPane, "<C-w>w", "focus-next"},
+	{vim.ModeNormal, anyPane, "<C-w><C-w>", "focus-next"},
+	{vim.ModeNormal, anyPane, "<C-w>o", "toggle-history"},
+	{vim.ModeNormal, anyPane, "<C-w><lt>", "narrow-history"},
+	{vim.ModeNormal, anyPane, "<C-w>>", "widen-history"},
+	{vim.ModeNormal, anyPane, "<C-w>z", "zoom"},
+	{vim.ModeNormal, anyPane, "<C-t>", "new-session"},
+	{vim.ModeNormal, anyPane, "<C-q>", "quit"},
+
+	// NORMAL mode, chat buffer
+	{vim.ModeNormal, bufferPane, "j", "cursor-down"},
+	{vim.ModeNormal, bufferPane, "<Down>", "cursor-down"},
+	{vim.ModeNormal, bufferPane, "k", "cursor-up"},
+	{vim.ModeNormal, bufferPane, "<Up>", "cursor-up"},
+	{vim.ModeNormal, bufferPane, "h", "cursor-left"},
+	{vim.ModeNormal, bufferPane, "<Left>", "cursor-left"},
+	{vim.ModeNormal, bufferPane, "l", "cursor-right"},
+	{vim.ModeNormal, bufferPane, "<Right>", "cursor-right"},
+	{vim.ModeNormal, bufferPane, "w", "cursor-word-forward"},
+	{vim.ModeNormal, bufferPane, "b", "cursor-word-backward"},
+	{vim.ModeNormal, bufferPane, "e", "cursor-word-end"},
+	{vim.ModeNormal, bufferPane, "0", "cursor-line-start"},
+	{vim.ModeNormal, bufferPane, "$", "cursor-line-end"},
+	{vim.ModeNormal, bufferPane, "<C-e>", "scroll-down"},
+	{vim.ModeNormal, bufferPane, "<C-y>", "scroll-up"},
+	{vim.ModeNormal, bufferPane, "<C-f>", "page-down"},
+	{vim.ModeNormal, bufferPane, "<C-b>", "page-up"},
+	{vim.ModeNormal, bufferPane, "<C-d>", "half-page-down"},
+	{vim.ModeNormal, bufferPane, "<C-u>", "half-page-up"},
+	{vim.ModeNormal, bufferPane, "gg", "goto-top"},
+	{vim.ModeNormal, bufferPane, "G", "goto-bottom"},
+	{vim.ModeNormal, bufferPane, "]c", "next-code-block"},
+	{vim.ModeNormal, bufferPane, "[c", "prev-code-block"},
+	{vim.ModeNormal, bufferPane, "/", "search-forward"},
+	{vim.ModeNormal, bufferPane, "?", "search-backward"},
+	{vim.ModeNormal, bufferPane, "n", "search-next"},
+	{vim.ModeNormal, bufferPane, "N", "search-prev"},
+	{vim.ModeNormal, bufferPane, "*", "search-word"},
+	{vim.ModeNormal, bufferPane, "<Esc>", "clear-search"},
+	{vim.ModeNormal, bufferPane, "za", "toggle-context"},
+	{vim.ModeNormal, bufferPane, "<Tab>", "toggle-context"},
+	{vim.ModeNormal, bufferPane, "zs", "toggle-system"},
+	{vim.ModeNormal, bufferPane, "y", "yank"},
+
+	// Motions after an operator
+	{vim.ModeOperatorPending, bufferPane, "c", "code-block"},
+	{vim.ModeOperatorPending, bufferPane, "m", "message"},
+
+	// NORMAL mode, prompt editor
+	{vim.ModeNormal, inputPane, "h", "char-left"},
+	{vim.ModeNormal, inputPane, "<Left>", "char-left"},
+	{vim.ModeNormal, inputPane, "l", "char-right"},
+	{vim.ModeNormal, inputPane, "<Right>", "char-right"},
+	{vim.ModeNormal, inputPane, "j", "line-down"},
+	{vim.ModeNormal, inputPane, "<Down>", "line-down"},
+	{vim.ModeNormal, inputPane, "k", "line-up"},
+	{vim.ModeNormal, inputPane, "<Up>", "line-up"},
+	{vim.ModeNormal, inputPane, "w", "word-forward"},
+	{vim.ModeNormal, inputPane, "b", "word-backward"},
+	{vim.ModeNormal, inputPane, "e", "word-end"},
+	{vim.ModeNormal, inputPane, "W", "bigword-forward"},
+	{vim.ModeNormal, inputPane, "B", "bigword-backward"},
+	{vim.ModeNormal, inputPane, "E", "bigword-end"},
+	{vim.ModeNormal, inputPane, "0", "line-start"},
+	{vim.ModeNormal, inputPane, "^", "first-non-blank"},
+	{vim.ModeNormal, inputPane, "$", "line-end"},
+	{vim.ModeNormal, inputPane, "gg", "first-line"},
+	{vim.ModeNormal, inputPane, "G", "last-line"},
+	{vim.ModeNormal, inputPane, "a", "append"},
+	{vim.ModeNormal, inputPane, "I", "insert-line-start"},
+	{vim.ModeNormal, inputPane, "A", "append-line-end"},
+	{vim.ModeNormal, inputPane, "o", "open-below"},
+	{vim.ModeNormal, inputPane, "O", "open-above"},
+	{vim.ModeNormal, inputPane, "d", "delete"},
+	{vim.ModeNormal, inputPane, "c", "change"},
+	{vim.ModeNormal, inputPane, "y", "yank"},
+	{vim.ModeNormal, inputPane, "x", "delete-char"},
+	{vim.ModeNormal, inputPane, "D", "delete-to-end"},
+	{vim.ModeNormal, inputPane, "C", "change-to-end"},
+	{vim.ModeNormal, inputPane, "p", "put-after"},
+	{vim.ModeNormal, inputPane, "P", "put-before"},
+	{vim.ModeNormal, inputPane, "u", "undo"},
+	{vim.ModeNormal, inputPane, "<C-r>", "redo"},
+	{vim.ModeNormal, inputPane, ".", "repeat"},
+	{vim.ModeNormal, inputPane, "<CR>", "send"},
+	{vim.ModeNormal, inputPane, "<C-x><C-e>", "edit-prompt"},
+
+	// Motions and text objects after d, c and y in the prompt editor
+	{vim.ModeOperatorPending, inputPane, "h", "char-left"},
+	{vim.ModeOperatorPending, inputPane, "l", "char-right"},
+	{vim.ModeOperatorPending, inputPane, "j", "line-down"},
+	{vim.ModeOperatorPending, inputPane, "k", "line-up"},
+	{vim.ModeOperatorPending, inputPane, "w", "word-forward"},
+	{vim.ModeOperatorPending, inputPane, "b", "word-backward"},
+	{vim.ModeOperatorPending, inputPane, "e", "word-end"},
+	{vim.ModeOperatorPending, inputPane, "W", "bigword-forward"},
+	{vim.ModeOperatorPending, inputPane, "B", "bigword-backward"},
+	{vim.ModeOperatorPending, inputPane, "E", "bigword-end"},
+	{vim.ModeOperatorPending, inputPane, "0", "line-start"},
+	{vim.ModeOperatorPending, inputPane, "^", "first-non-blank"},
+	{vim.ModeOperatorPending, inputPane, "$", "line-end"},
+	{vim.ModeOperatorPending, inputPane, "gg", "first-line"},
+	{vim.ModeOperatorPending, inputPane, "G", "last-line"},
+	{vim.ModeOperatorPending, inputPane, "iw", "inner-word"},
+	{vim.ModeOperatorPending, inputPane, "aw", "a-word"},
+	{vim.ModeOperatorPending, inputPane, "d", "line"},
+	{vim.ModeOperatorPending, inputPane, "c", "line"},
+	{vim.ModeOperatorPending, inputPane, "y", "line"},
+
+	// NORMAL mode, session list
+	{vim.ModeNormal, historyPane, "j", "select-next"},
+	{vim.ModeNormal, historyPane, "<Down>", "select-next"},
+	{vim.ModeNormal, historyPane, "k", "select-prev"},
+	{vim.ModeNormal, historyPane, "<Up>", "select-prev"},
+	{vim.ModeNormal, historyPane, "gg", "select-first"},
+	{vim.ModeNormal, historyPane, "G", "select-last"},
+	{vim.ModeNormal, historyPane, "<CR>", "open-session"},
+
+	// INSERT mode
+	{vim.ModeInsert, anyPane, "<Esc>", "normal"},
+	{vim.ModeInsert, anyPane, "<F1>", "help"},
+	{vim.ModeInsert, anyPane, "<C-r>", "paste-register"},
+	{vim.ModeInsert, inputPane, "<C-s>", "history-search"},
+	{vim.ModeInsert, inputPane, "<Tab>", "complete-mention"},
+	{vim.ModeInsert, inputPane, "<CR>", "newline"},
+	{vim.ModeInsert, inputPane, "<Up>", "history-prev"},
+	{vim.ModeInsert, inputPane, "<C-p>", "history-prev"},
+	{vim.ModeInsert, inputPane, "<Down>", "history-next"},
+	{vim.ModeInsert, inputPane, "<C-n>", "history-next"},
+	{vim.ModeInsert, anyPane, "<C-x><C-e>", "edit-prompt"},
+}
+
+// defaultKeymap builds the keymap from the default bindings.
+func defaultKeymap() *vim.Keymap {
+	km := vim.NewKeymap()
+	for name, a := range actions {
+		if a.flags != 0 {
+			km.SetFlags(name, a.flags)
+		}
+	}
+	for _, b := range defaultBindings {
+		km.MustBind(b.mode, b.focus, b.keys, b.action)
+	}
+	return km
+}
+
+// routeKey sends a key through the router and runs the resolved action.
+func (m Model) routeKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	res := m.Router.Route(msg, m.Mode, vim.Focus(m.Focus))
+	return m.applyRoute(res, msg)
+}
+
+// applyRoute acts on a routing result. Unbound keys typed in INSERT mode
+// go to the input area; key is nil when the result comes from a timeout.
+func (m Model) applyRoute(res vim.Result, key tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	switch res.Status {
+	case vim.Matched:
+		cmd = m.runAction(res.Action, res.Ctx)
+	case vim.Pending:
+		cmd = res.Cmd
+	case vim.Unhandled:
+		if key, ok := key.(tea.KeyMsg); ok && m.Mode == vim.ModeInsert && m.Focus == ui.FocusInput {
+			m.recordKey(key)
+			var model tea.Model
+			model, cmd = m.Input.Update(key)
+			m.Input = model.(input.Model)
+		}
+	}
+	m.CmdLine.SetShowCmd(m.Router.Pending())
+	if len(res.Replay) > 0 {
+		return m.replayKeys(res.Replay, cmd)
+	}
+	return m, cmd
+}
+
+// replayKeys handles the keys of a sequence that matched no binding: the
+// first as an unbound key, the rest routed again.
+func (m Model) replayKeys(keys []tea.KeyMsg, cmd tea.Cmd) (tea.Model, tea.Cmd) {
+	cmds := []tea.Cmd{cmd}
+	model, c := m.applyRoute(vim.Result{Status: vim.Unhandled}, keys[0])
+	cmds = append(cmds, c)
+	for _, key := range keys[1:] {
+		model, c = model.(Model).routeKey(key)
+		cmds = append(cmds, c)
+	}
+	return model, tea.Batch(cmds...)
+}
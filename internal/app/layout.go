@@ -0,0 +1,49 @@
+package app
+
+import (
+	"strconv"
+
+	ui "github.com/fingergohappy/vai/internal/ui"
+)
+
+// toggleHistory shows or hides the session list (Ctrl-w o), as
+// :set history! does.
+func (m *Model) toggleHistory() error {
+	opt, _ := lookupOption("history")
+	return m.setOptionValue(opt, strconv.FormatBool(!m.Config.Layout.History))
+}
+
+// resizeHistory widens the session list by delta columns (Ctrl-w > and
+// Ctrl-w <), keeping at least 10 columns for it and 20 for the chat buffer.
+func (m *Model) resizeHistory(delta int) error {
+	width := m.Config.Layout.HistoryWidth
+	if width == 0 {
+		width = m.Layout.SessionList.Width
+	}
+	width = max(min(width+delta, m.size.Width-20), 10)
+	opt, _ := lookupOption("historywidth")
+	return m.setOptionValue(opt, strconv.Itoa(width))
+}
+
+// focusable reports whether a pane can take focus: the session list can't
+// while it is hidden.
+func (m *Model) focusable(focus ui.Focus) bool {
+	return focus != ui.FocusHistory || m.Config.Layout.History
+}
+
+// cycleFocus moves focus to the next pane (or the previous one when back is
+// set), skipping panes that can't take it.
+func (m *Model) cycleFocus(back bool) {
+	focus := m.Focus
+	for range 3 {
+		if back {
+			focus = focus.Prev()
+		} else {
+			focus = focus.Next()
+		}
+		if m.focusable(focus) {
+			break
+		}
+	}
+	m.setFocus(focus)
+}
@@ -0,0 +1,204 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/fingergohappy/vai/internal/command"
+	"github.com/fingergohappy/vai/internal/config"
+	ui "github.com/fingergohappy/vai/internal/ui"
+	"github.com/fingergohappy/vai/internal/vim"
+)
+
+// mapMsg is emitted by :map, :nmap, :unmap and friends.
+type mapMsg struct {
+	mode  vim.Mode
+	args  string
+	unmap bool
+}
+
+// focusNames maps the focus areas used in config and listings.
+var focusNames = map[string]vim.Focus{
+	"any":     vim.FocusAny,
+	"history": vim.FocusHistory,
+	"buffer":  vim.FocusBuffer,
+	"input":   vim.FocusInput,
+}
+
+// focusName returns the config name of a focus area.
+func focusName(f vim.Focus) string {
+	for name, focus := range focusNames {
+		if focus == f {
+			return name
+		}
+	}
+	return "?"
+}
+
+// registerMapCommands adds :map and :unmap and their per-mode variants.
+func registerMapCommands(reg *command.Registry) {
+	variants := []struct {
+		prefix string
+		mode   vim.Mode
+		label  string
+	}{
+		{"", vim.ModeNormal, "NORMAL"},
+		{"n", vim.ModeNormal, "NORMAL"},
+		{"i", vim.ModeInsert, "INSERT"},
+		{"v", vim.ModeVisual, "VISUAL"},
+	}
+	for _, v := range variants {
+		mode := v.mode
+		reg.MustRegister(command.Command{
+			Name:        v.prefix + "map",
+			Usage:       "[{lhs} [{action} | :{command}]]",
+			Description: "Map keys in " + v.label + " mode, or list mappings",
+			Run: func(ctx command.Context) (tea.Cmd, error) {
+				return emit(mapMsg{mode: mode, args: ctx.Raw}), nil
+			},
+			Complete: func(args []string, argIdx int) []string {
+				if argIdx == 0 {
+					return nil
+				}
+				return actionNames()
+			},
+		})
+		reg.MustRegister(command.Command{
+			Name:        v.prefix + "unmap",
+			Usage:       "{lhs}",
+			Description: "Remove a mapping in " + v.label + " mode",
+			Run: func(ctx command.Context) (tea.Cmd, error) {
+				if ctx.Raw == "" {
+					return nil, fmt.Errorf("argument required")
+				}
+				return emit(mapMsg{mode: mode, args: ctx.Raw, unmap: true}), nil
+			},
+		})
+	}
+}
+
+// handleMap applies :map and :unmap for the running session only.
+func (m *Model) handleMap(msg mapMsg) error {
+	lhs, rhs, _ := strings.Cut(strings.TrimSpace(msg.args), " ")
+	rhs = strings.TrimSpace(rhs)
+	km := m.Router.Keymap()
+
+	switch {
+	case msg.unmap:
+		ok, err := km.Unmap(msg.mode, vim.FocusAny, lhs)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("no such mapping: %s", lhs)
+		}
+		return nil
+	case rhs == "":
+		m.showMappings(msg.mode, lhs)
+		return nil
+	}
+
+	// Like Vim, a command mapping may end in <CR>
+	rhs = strings.TrimSuffix(rhs, "<CR>")
+	if err := m.checkAction(rhs); err != nil {
+		return err
+	}
+	return km.Map(msg.mode, vim.FocusAny, lhs, rhs)
+}
+
+// showMappings opens a popup with the mappings of a mode whose keys start
+// with prefix.
+func (m *Model) showMappings(mode vim.Mode, prefix string) {
+	var lines []string
+	for _, b := range m.Router.Keymap().Bindings() {
+		keys := vim.FormatKeys(b.Keys)
+		if b.Mode != mode || !strings.HasPrefix(keys, prefix) {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("%-8s %-12s %s", focusName(b.Focus), keys, b.Action))
+	}
+	if len(lines) == 0 {
+		lines = []string{"No mapping found"}
+	}
+	m.popup = ui.NewPopup(m.Styles, mode.String()+" mappings", lines)
+}
+
+// checkAction returns an error unless name is a known action or an
+// existing ex command prefixed with ':'.
+func (m *Model) checkAction(name string) error {
+	if line, ok := strings.CutPrefix(name, ":"); ok {
+		ctx, err := command.Parse(line)
+		if err != nil {
+			return err
+		}
+		_, err = m.Commands.Lookup(ctx.Name)
+		return err
+	}
+	if _, ok := actions[name]; !ok {
+		return fmt.Errorf("unknown action: %s", name)
+	}
+	return nil
+}
+
+// applyKeybindings adds the bindings from the configuration to the keymap.
+// It returns one error per invalid binding, with the file and line;
+// leaderPath is the file that sets the leader.
+func (m *Model) applyKeybindings(cfg config.KeybindingsConfig, leaderPath string) []error {
+	km := m.Router.Keymap()
+	var errs []error
+	fail := func(file string, line int, err error) {
+		if file == "" {
+			file = "config"
+		}
+		if line == 0 {
+			errs = append(errs, fmt.Errorf("%s: %w", file, err))
+			return
+		}
+		errs = append(errs, fmt.Errorf("%s:%d: %w", file, line, err))
+	}
+
+	if cfg.Leader != "" {
+		if err := km.SetLeader(cfg.Leader); err != nil {
+			fail(leaderPath, 0, err)
+		}
+	}
+
+	modes := []struct {
+		mode     vim.Mode
+		bindings config.ModeBindings
+	}{
+		{vim.ModeNormal, cfg.Normal},
+		{vim.ModeInsert, cfg.Insert},
+		{vim.ModeVisual, cfg.Visual},
+		{vim.ModeOperatorPending, cfg.Operator},
+	}
+	for _, mb := range modes {
+		for _, b := range mb.bindings {
+			focus, ok := focusNames[b.Focus]
+			if !ok {
+				fail(b.Path, b.Line, fmt.Errorf("unknown focus area %q (want any, history, buffer or input)", b.Focus))
+				continue
+			}
+			if b.Action == config.Unbind || b.Action == "" {
+				ok, err := km.Unmap(mb.mode, focus, b.Keys)
+				if err == nil && !ok {
+					err = fmt.Errorf("nothing bound to %s", b.Keys)
+				}
+				if err != nil {
+					fail(b.Path, b.Line, err)
+				}
+				continue
+			}
+			if err := m.checkAction(b.Action); err != nil {
+				fail(b.Path, b.Line, err)
+				continue
+			}
+			if err := km.Map(mb.mode, focus, b.Keys, b.Action); err != nil {
+				fail(b.Path, b.Line, err)
+			}
+		}
+	}
+	return errs
+}
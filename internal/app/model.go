@@ -2,13 +2,21 @@
 package app
 
 import (
+	"fmt"
+	"strings"
+
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 
+	"github.com/fingergohappy/vai/internal/attach"
 	"github.com/fingergohappy/vai/internal/chat"
+	"github.com/fingergohappy/vai/internal/command"
 	"github.com/fingergohappy/vai/internal/config"
+	"github.com/fingergohappy/vai/internal/history"
 	"github.com/fingergohappy/vai/internal/input"
+	"github.com/fingergohappy/vai/internal/register"
 	"github.com/fingergohappy/vai/internal/session"
+	"github.com/fingergohappy/vai/internal/templates"
 	ui "github.com/fingergohappy/vai/internal/ui"
 	"github.com/fingergohappy/vai/internal/vim"
 )
@@ -35,6 +43,78 @@ type Model struct {
 	Layout   ui.Layout    // Computed layout for panes
 	Styles   *ui.Styles   // Lipgloss styles
 
+	// Registers holds yanked text ("a-"z, "0-"9, "+ ...)
+	Registers *register.Registers
+
+	// History holds the prompts sent in all sessions
+	History *history.History
+
+	// popup is a modal overlay (e.g. :registers); any key closes it
+	popup *ui.Popup
+
+	// help is the help overlay, open until closed with q or Esc
+	help *ui.Viewer
+
+	// picker is a modal fuzzy picker (e.g. :template); pick runs with the
+	// chosen item
+	picker *ui.Picker
+	pick   func(m *Model, choice string) tea.Cmd
+
+	// Router resolves key sequences, counts and register prefixes into
+	// named actions
+	Router *vim.Router
+
+	// lastChange is the last prompt edit, repeated by '.'; recording is set
+	// while the INSERT mode it started records typed keys
+	lastChange *change
+	recording  bool
+
+	// Commands is the ex command registry; CmdLine is the bottom command line
+	Commands *command.Registry
+	CmdLine  command.Line
+
+	// Store persists sessions
+	Store *session.Store
+
+	// Templates is the prompt template library; expanding is the template
+	// whose questions are being asked
+	Templates *templates.Library
+	expanding *expansion
+
+	// attachments are files added with :attach for the next message;
+	// mention and slash are the state of @path and /command completion
+	attachments []attach.File
+	mention     *completion
+	slash       *completion
+
+	// draftSeq numbers prompt edits; the draft is saved when the tick of
+	// the latest edit arrives
+	draftSeq int
+
+	// loader reads the config files, which configWatcher polls so that
+	// changes apply while vai runs; completions are the candidates from
+	// the config offered by ex commands
+	loader        *config.Loader
+	configWatcher *config.Watcher
+	completions   *completions
+
+	// optionsSet are the options changed with :set since the config was
+	// loaded
+	optionsSet map[string]bool
+
+	// configIssues are the problems with the configuration, shown in a
+	// banner until listed with :config
+	configIssues []error
+	issuesSeen   bool
+
+	// size is the terminal size the layout was calculated for, and
+	// layoutOpts the options it was calculated with
+	size       tea.WindowSizeMsg
+	layoutOpts ui.LayoutOptions
+
+	// zoomed gives the focused pane all the space (Ctrl-w z)
+	zoomed bool
+
 	// Ready flag indicates if the layout has been calculated
 	ready bool
 
@@ -47,18 +127,63 @@ func NewModel(cfg config.Config) Model {
 	styles := ui.DefaultStyles()
 	titleBar := ui.NewTitleBar(styles)
 
-	return Model{
+	store := session.DefaultStore()
+	loader := config.NewLoader()
+	comp := newCompletions(cfg)
+	commands := command.NewRegistry()
+	library := templates.DefaultLibrary()
+	registerCommands(commands, store, library, comp)
+
+	m := Model{
 		Mode:     vim.ModeNormal,
 		Focus:    ui.FocusBuffer, // Default to chat buffer
 		Config:   cfg,
 		Styles:   styles,
 		TitleBar: titleBar,
 		ready:    false,
+
+		Registers: register.New(nil, register.DefaultPath()),
+		History:   history.New(history.DefaultPath()),
+		Router:    vim.NewRouter(),
+		Commands:  commands,
+		CmdLine:   command.NewLine(commands, commandLineStyles(styles)),
+		Store:     store,
+		Templates: library,
+
+		loader:        loader,
+		configWatcher: newConfigWatcher(loader),
+		completions:   comp,
+
 		// Sub-models initialized with defaults
 		Session: session.NewModel(),
 		Chat:    chat.NewModel(),
 		Input:   input.NewModel(),
 	}
+
+	m.applyConfig()
+	m.Chat.Focus()
+
+	if err := m.Registers.Load(); err != nil {
+		m.CmdLine.SetError(fmt.Errorf("registers: %w", err))
+	}
+	if err := m.History.Load(); err != nil {
+		m.CmdLine.SetError(fmt.Errorf("history: %w", err))
+	}
+	m.Input.SetHistory(m.History.Texts())
+	if err := m.loadSessions(); err != nil {
+		m.CmdLine.SetError(err)
+	}
+	return m
+}
+
+// commandLineStyles maps the shared styles onto the command line.
+func commandLineStyles(styles *ui.Styles) command.LineStyles {
+	return command.LineStyles{
+		Text:  styles.CommandLine,
+		Info:  styles.InfoMessage,
+		Error: styles.ErrorMessage,
+		Menu:  styles.CommandMenu,
+	}
 }
 
 // Init initializes the top-level Model.
@@ -68,80 +193,122 @@ func (m Model) Init() tea.Cmd {
 		m.Session.Init(),
 		m.Chat.Init(),
 		m.Input.Init(),
+		m.watchConfig(),
 	)
 }
 
-// Update handles messages and routes them to appropriate sub-models.
+// Update handles messages and routes them to appropriate sub-models. Edits
+// to the prompt schedule saving it as the session's draft, and config file
+// changes are applied as they are found. The layout is recalculated when
+// anything it depends on changes, such as the prompt's height.
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if tick, ok := msg.(draftTickMsg); ok {
+		if tick.seq == m.draftSeq {
+			m.reportError(m.saveDraft())
+		}
+		return m, nil
+	}
+	// Config reloads arrive whatever has the input
+	switch msg := msg.(type) {
+	case configPollMsg:
+		return m, m.watchConfig()
+	case configChangedMsg:
+		m.reloadConfig(msg)
+		if msg.manual {
+			return m, nil
+		}
+		return m, m.watchConfig()
+	}
+
+	prev := m.Input.Value()
+	model, cmd := m.update(msg)
+	next := model.(Model)
+	if next.Input.Value() != prev && !next.quitting {
+		cmd = tea.Batch(cmd, next.scheduleDraftSave())
+	}
+	if next.ready && next.layoutOptions() != next.layoutOpts {
+		next.resize(next.size)
+	}
+	return next, cmd
+}
+
+// update routes a message to the command line, ex command handlers, the
+// key router or the sub-models.
+func (m Model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	// The command line takes all input while open
+	if m.CmdLine.Active() {
+		var cmd tea.Cmd
+		m.CmdLine, cmd = m.CmdLine.Update(msg)
+		return m, cmd
+	}
+
+	if m, cmd, ok := m.handleCommandMsg(msg); ok {
+		return m, cmd
+	}
+
 	// Handle global messages first
 	switch msg := msg.(type) {
+	case command.SubmitMsg:
+		return m.handleSubmit(msg)
+
+	case command.CancelMsg:
+		// Cancelling a template question drops the expansion
+		if msg.Prompt == command.InputPrompt {
+			m.expanding = nil
+		}
+		return m, nil
+
 	case tea.KeyMsg:
 		// Handle quit keys
 		if msg.Type == tea.KeyCtrlC {
+			m.reportError(m.saveDraft())
 			m.quitting = true
 			return m, tea.Quit
 		}
 
-		// Handle focus switching with Ctrl+w (only in NORMAL mode)
-		if msg.Type == tea.KeyCtrlW && m.Mode == vim.ModeNormal {
-			m.Focus = m.Focus.Next()
+		// Any key dismisses an open popup
+		if m.popup != nil {
+			m.popup = nil
 			return m, nil
 		}
 
-		// Handle mode transitions
-		switch msg.Type {
-		case tea.KeyRunes:
-			// Enter INSERT mode with 'i' or 'a' in NORMAL mode
-			if m.Mode == vim.ModeNormal {
-				for _, r := range msg.Runes {
-					if r == 'i' || r == 'a' {
-						m.Mode = vim.ModeInsert
-						m.Focus = ui.FocusInput
-						m.Input.Focus()
-						return m, nil
-					}
-				}
-			}
-		case tea.KeyEsc:
-			// Return to NORMAL mode from INSERT mode
-			if m.Mode == vim.ModeInsert {
-				m.Mode = vim.ModeNormal
-				m.Focus = ui.FocusBuffer
-				m.Input.Blur()
-				return m, nil
+		// The help overlay takes all keys until it closes
+		if m.help != nil {
+			if m.help.HandleKey(msg) {
+				m.help = nil
 			}
+			return m, nil
 		}
 
-	case tea.WindowSizeMsg:
-		// Calculate layout based on terminal size
-		m.Layout = ui.CalculateLayout(msg)
-		m.ready = true
-		// Update sub-model sizes
-		m.TitleBar.SetWidth(msg.Width)
-
-		chatStyle := m.getPaneStyle(m.Focus == ui.FocusBuffer)
-		chatFrameX, chatFrameY := chatStyle.GetFrameSize()
-		chatInnerWidth := m.Layout.ChatBuffer.Width - chatFrameX
-		chatInnerHeight := m.Layout.ChatBuffer.Height - chatFrameY
-		if chatInnerWidth < 0 {
-			chatInnerWidth = 0
+		// An open picker takes all keys until it closes
+		if m.picker != nil {
+			return m.updatePicker(msg)
 		}
-		if chatInnerHeight < 0 {
-			chatInnerHeight = 0
-		}
-		m.Chat.SetSize(chatInnerWidth, chatInnerHeight)
 
-		inputStyle := m.getPaneStyle(m.Focus == ui.FocusInput)
-		inputFrameX, inputFrameY := inputStyle.GetFrameSize()
-		inputInnerWidth := m.Layout.InputArea.Width - inputFrameX
-		inputInnerHeight := m.Layout.InputArea.Height - inputFrameY
-		if inputInnerWidth < 0 {
-			inputInnerWidth = 0
+		// A Ctrl-s history search takes keys until it ends
+		if m.Input.Searching() {
+			return m.updateHistorySearch(msg)
 		}
-		if inputInnerHeight < 0 {
-			inputInnerHeight = 0
+
+		// Any key clears the last command line message
+		m.CmdLine.ClearMessage()
+
+		// Bracketed paste is text, not keystrokes to map
+		if msg.Paste {
+			if m.Mode == vim.ModeInsert {
+				m.recordKey(msg)
+			}
+			m.pasteText(string(msg.Runes))
+			return m, nil
 		}
-		m.Input.SetSize(inputInnerWidth, inputInnerHeight)
+		return m.routeKey(msg)
+
+	case vim.TimeoutMsg:
+		res := m.Router.HandleTimeout(msg)
+		return m.applyRoute(res, nil)
+
+	case tea.WindowSizeMsg:
+		m.resize(msg)
 	}
 
 	// Route messages to sub-models based on Mode and Focus
@@ -175,43 +342,65 @@ func (m Model) View() string {
 	// Render title bar
 	titleBar := m.renderTitleBar()
 
-	// Render session list pane with placeholder content
-	sessionPane := m.renderSessionPane()
-
-	// // Render chat buffer pane with placeholder content
-	chatPane := m.renderChatPane()
-
-	// Render input area pane with placeholder content
-	inputPane := m.renderInputPane()
+	// Render the visible panes: the session list beside or above the chat
+	// buffer, then the input area
+	var top []string
+	if m.Layout.SessionList.Visible() {
+		top = append(top, m.renderSessionPane())
+	}
+	if m.Layout.ChatBuffer.Visible() {
+		chatPane := m.renderChatPane()
+		if m.popup != nil {
+			chatPane = m.renderPopupPane(m.popup.Render)
+		} else if m.picker != nil {
+			chatPane = m.renderPopupPane(m.picker.Render)
+		}
+		top = append(top, chatPane)
+	}
+	var panes []string
+	switch {
+	case len(top) == 0:
+	case m.Layout.Vertical:
+		panes = append(panes, lipgloss.JoinVertical(lipgloss.Left, top...))
+	default:
+		panes = append(panes, lipgloss.JoinHorizontal(lipgloss.Left, top...))
+	}
+	if m.Layout.InputArea.Visible() {
+		panes = append(panes, m.renderInputPane())
+	}
+	body := lipgloss.JoinVertical(lipgloss.Left, panes...)
 
-	// Join session list and chat buffer horizontally (top section)
-	topSection := lipgloss.JoinHorizontal(
-		lipgloss.Left,
-		sessionPane,
-		chatPane,
-	)
+	// The slash command menu opens just above the input area
+	if m.slashMenuVisible() {
+		menu := m.renderSlashMenu()
+		y := m.Layout.InputArea.Y - m.Layout.TitleBar.Height - lipgloss.Height(menu)
+		body = ui.Overlay(body, menu, 1, max(y, 0))
+	}
 
-	// Join title bar, top section, and input area vertically
-	mainContent := lipgloss.JoinVertical(
-		lipgloss.Top,
-		titleBar,
-		topSection,
-		inputPane,
-	)
+	// Join title bar, config banner, top section, input area and command
+	// line vertically
+	rows := []string{titleBar}
+	if m.bannerVisible() {
+		rows = append(rows, m.renderBanner())
+	}
+	rows = append(rows, body, m.CmdLine.View())
+	mainContent := lipgloss.JoinVertical(lipgloss.Top, rows...)
+
+	// The help overlay covers everything but the title bar and command line
+	if m.help != nil {
+		box := m.help.Render(m.size.Width-4, m.size.Height-2)
+		x := (m.size.Width - lipgloss.Width(box)) / 2
+		y := (m.size.Height - lipgloss.Height(box)) / 2
+		mainContent = ui.Overlay(mainContent, box, x, y)
+	}
 
 	return mainContent
 }
 
-// renderSessionPane renders the session list pane with static placeholder content.
+// renderSessionPane renders the session list pane, marking the current session.
 func (m Model) renderSessionPane() string {
 	style := m.getPaneStyle(m.Focus == ui.FocusHistory)
 
-	placeholder := "  [Sessions]\n\n" +
-		"  • Session 1\n" +
-		"  • Session 2\n" +
-		"  • Session 3\n\n" +
-		"  (TODO)"
-
 	frameX, frameY := style.GetFrameSize()
 	w := m.Layout.SessionList.Width - frameX
 	h := m.Layout.SessionList.Height - frameY
@@ -222,10 +411,31 @@ func (m Model) renderSessionPane() string {
 		h = 0
 	}
 
+	lines := []string{"  [Sessions]", ""}
+	for i, sess := range m.Session.Sessions {
+		marker := "  "
+		if sess.ID == m.Session.CurrentID {
+			marker = "▸ "
+		} else if i == m.Session.SelectedIndex && m.Focus == ui.FocusHistory {
+			marker = "› "
+		}
+		draft := sess.Draft
+		if sess.ID == m.Session.CurrentID {
+			draft = m.Input.Value()
+		}
+		if strings.TrimSpace(draft) != "" {
+			marker += sess.Title + " " + m.Styles.DraftMarker.Render("draft")
+		} else {
+			marker += sess.Title
+		}
+		lines = append(lines, marker)
+	}
+
 	return style.
 		Width(w).
 		Height(h).
-		Render(placeholder)
+		MaxHeight(h + frameY).
+		Render(strings.Join(lines, "\n"))
 }
 
 // renderChatPane renders the chat buffer pane with static placeholder content.
@@ -248,6 +458,27 @@ func (m Model) renderChatPane() string {
 		Render(m.Chat.View())
 }
 
+// renderPopupPane renders the open popup or picker in place of the chat
+// buffer pane.
+func (m Model) renderPopupPane(render func(width, height int) string) string {
+	style := m.getPaneStyle(m.Focus == ui.FocusBuffer)
+
+	frameX, frameY := style.GetFrameSize()
+	w := m.Layout.ChatBuffer.Width - frameX
+	h := m.Layout.ChatBuffer.Height - frameY
+	if w < 0 {
+		w = 0
+	}
+	if h < 0 {
+		h = 0
+	}
+
+	return style.
+		Width(w).
+		Height(h).
+		Render(render(w, h))
+}
+
 // renderInputPane renders the input area pane with the Input sub-model.
 func (m Model) renderInputPane() string {
 	style := m.getPaneStyle(m.Focus == ui.FocusInput)
@@ -270,13 +501,94 @@ func (m Model) renderInputPane() string {
 		Render(inputContent)
 }
 
+// resize calculates the layout for the terminal size and sizes the
+// sub-models. The config banner takes a line from the panes.
+func (m *Model) resize(size tea.WindowSizeMsg) {
+	m.size = size
+	if m.bannerVisible() {
+		size.Height--
+	}
+
+	// The session list can't keep focus once it is hidden
+	if !m.focusable(m.Focus) {
+		m.setFocus(ui.FocusBuffer)
+	}
+
+	// Calculate layout based on terminal size
+	m.layoutOpts = m.layoutOptions()
+	m.Layout = ui.CalculateLayout(size, m.layoutOpts)
+	m.ready = true
+	// Update sub-model sizes; hidden panes keep theirs
+	m.TitleBar.SetWidth(size.Width)
+	m.CmdLine.SetWidth(m.Layout.CommandLine.Width)
+
+	if m.Layout.ChatBuffer.Visible() {
+		chatStyle := m.getPaneStyle(m.Focus == ui.FocusBuffer)
+		chatFrameX, chatFrameY := chatStyle.GetFrameSize()
+		chatInnerWidth := m.Layout.ChatBuffer.Width - chatFrameX
+		chatInnerHeight := m.Layout.ChatBuffer.Height - chatFrameY
+		if chatInnerWidth < 0 {
+			chatInnerWidth = 0
+		}
+		if chatInnerHeight < 0 {
+			chatInnerHeight = 0
+		}
+		m.Chat.SetSize(chatInnerWidth, chatInnerHeight)
+	}
+
+	if m.Layout.InputArea.Visible() {
+		inputStyle := m.getPaneStyle(m.Focus == ui.FocusInput)
+		inputFrameX, inputFrameY := inputStyle.GetFrameSize()
+		inputInnerWidth := m.Layout.InputArea.Width - inputFrameX
+		inputInnerHeight := m.Layout.InputArea.Height - inputFrameY
+		if inputInnerWidth < 0 {
+			inputInnerWidth = 0
+		}
+		if inputInnerHeight < 0 {
+			inputInnerHeight = 0
+		}
+		m.Input.SetSize(inputInnerWidth, inputInnerHeight)
+	}
+}
+
+// layoutOptions returns the options for the pane layout: the layout config
+// (changed by :set and Ctrl-w), the zoomed pane and an input area tall
+// enough for the prompt, between input_height and input_max_height rows.
+// Popups and pickers show in the chat buffer, so it is zoomed while one is
+// open.
+func (m Model) layoutOptions() ui.LayoutOptions {
+	cfg := m.Config.Layout
+	frameX, frameY := m.getPaneStyle(false).GetFrameSize()
+	rows := m.Input.Rows(m.size.Width - frameX)
+	rows = min(max(rows, cfg.InputHeight), max(cfg.InputMaxHeight, cfg.InputHeight))
+
+	opts := ui.LayoutOptions{
+		History:      cfg.History,
+		HistoryWidth: cfg.HistoryWidth,
+		InputHeight:  rows + frameY,
+		Vertical:     cfg.VerticalBelow > 0 && m.size.Width < cfg.VerticalBelow,
+		Zoom:         m.zoomed,
+	}
+	if m.zoomed {
+		opts.Focus = m.Focus
+		if m.popup != nil || m.picker != nil {
+			opts.Focus = ui.FocusBuffer
+		}
+	}
+	return opts
+}
+
 // renderTitleBar renders the title bar with the current session title.
 func (m Model) renderTitleBar() string {
 	currentTitle := m.Session.GetCurrentTitle()
 	if currentTitle == "" {
 		currentTitle = "New Chat"
 	}
-	return m.TitleBar.Render(currentTitle)
+	status := m.Chat.SearchStatus()
+	if n := len(m.attachments); n > 0 {
+		status = strings.TrimSpace(fmt.Sprintf("%s +%d attached", status, n))
+	}
+	return m.TitleBar.Render(currentTitle, status)
 }
 
 // getPaneStyle returns the appropriate border style based on focus and mode.
@@ -0,0 +1,163 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/fingergohappy/vai/internal/chat"
+	"github.com/fingergohappy/vai/internal/config"
+	"github.com/fingergohappy/vai/internal/provider"
+	"github.com/fingergohappy/vai/internal/session"
+)
+
+// OneShot describes a non-interactive run (vai -p).
+type OneShot struct {
+	Prompt  string    // Prompt text from the command line
+	Stdin   string    // Piped input, attached as context
+	Session string    // Title or ID of a session to append the exchange to
+	JSON    bool      // Write JSON events instead of plain text
+	Out     io.Writer // Destination of the reply
+}
+
+// oneShotEvent is a line of --json output.
+type oneShotEvent struct {
+	Type     string `json:"type"` // start, delta, done or error
+	Provider string `json:"provider,omitempty"`
+	Model    string `json:"model,omitempty"`
+	Session  string `json:"session,omitempty"`
+	Text     string `json:"text,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// RunOnce sends a single prompt through the configured provider and
+// streams the reply to opts.Out. With opts.Session the earlier messages of
+// that session are sent along and the exchange is appended to it; a
+// session that does not exist yet is created with that title.
+func RunOnce(ctx context.Context, cfg config.Config, opts OneShot) error {
+	user := newUserMessage(opts.Prompt, opts.Stdin)
+	if len(user.Blocks) == 0 {
+		return fmt.Errorf("no prompt given")
+	}
+
+	store := session.DefaultStore()
+	var sess *session.Session
+	if opts.Session != "" {
+		var err error
+		if sess, err = findOrCreateSession(store, opts.Session, cfg); err != nil {
+			return err
+		}
+	}
+
+	model, system := cfg.Provider.Model, cfg.SystemPrompt
+	var messages []chat.Message
+	if sess != nil {
+		if sess.Model != "" {
+			model = sess.Model
+		}
+		system = sess.System
+		for _, msg := range sess.Messages {
+			messages = append(messages, toChatMessage(msg))
+		}
+	}
+	messages = append(messages, user)
+
+	p, err := provider.New(cfg.Provider)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(opts.Out)
+	emitEvent := func(ev oneShotEvent) error {
+		if !opts.JSON {
+			return nil
+		}
+		return enc.Encode(ev)
+	}
+	sessionID := ""
+	if sess != nil {
+		sessionID = sess.ID
+	}
+	if err := emitEvent(oneShotEvent{Type: "start", Provider: p.Name(), Model: model, Session: sessionID}); err != nil {
+		return err
+	}
+
+	var reply strings.Builder
+	err = p.Stream(ctx, provider.Request{Model: model, System: system, Messages: toProviderMessages(messages)}, func(text string) error {
+		reply.WriteString(text)
+		if opts.JSON {
+			return emitEvent(oneShotEvent{Type: "delta", Text: text})
+		}
+		_, err := io.WriteString(opts.Out, text)
+		return err
+	})
+	if err != nil {
+		emitEvent(oneShotEvent{Type: "error", Error: err.Error()})
+		return err
+	}
+	if !opts.JSON && !strings.HasSuffix(reply.String(), "\n") {
+		io.WriteString(opts.Out, "\n")
+	}
+
+	if sess != nil {
+		assistant := chat.NewMessage(chat.RoleAssistant, toChatBlocks(reply.String()))
+		sess.Messages = append(sess.Messages, toSessionMessage(user), toSessionMessage(assistant))
+		sess.UpdatedAt = assistant.CreatedAt
+		if err := store.Save(*sess); err != nil {
+			return err
+		}
+	}
+	return emitEvent(oneShotEvent{Type: "done", Session: sessionID, Text: reply.String()})
+}
+
+// newUserMessage builds a user message from prompt text and piped input,
+// which is attached as a context block.
+func newUserMessage(prompt, stdin string) chat.Message {
+	var blocks []chat.Block
+	if text := strings.TrimSpace(prompt); text != "" {
+		blocks = toChatBlocks(text)
+	}
+	if strings.TrimSpace(stdin) != "" {
+		lines := strings.Split(strings.TrimRight(stdin, "\n"), "\n")
+		blocks = append(blocks, chat.NewContextBlock("stdin", "", lines))
+	}
+	return chat.NewMessage(chat.RoleUser, blocks)
+}
+
+// findOrCreateSession returns the stored session with the given title or
+// ID, or a new session with that title using the model and system prompt
+// of cfg.
+func findOrCreateSession(store *session.Store, name string, cfg config.Config) (*session.Session, error) {
+	sessions, err := store.List()
+	if err != nil {
+		return nil, fmt.Errorf("load sessions: %w", err)
+	}
+	for i := range sessions {
+		if sessions[i].ID == name || sessions[i].Title == name {
+			return &sessions[i], nil
+		}
+	}
+	sess := session.NewSession()
+	sess.Title = name
+	if cfg.Provider.Model != "" {
+		sess.Model = cfg.Provider.Model
+	}
+	sess.System = cfg.SystemPrompt
+	return &sess, nil
+}
+
+// toProviderMessages converts chat messages into the plain text form sent
+// to a provider; attachments become fenced code labelled with their path.
+func toProviderMessages(messages []chat.Message) []provider.Message {
+	out := make([]provider.Message, 0, len(messages))
+	for _, msg := range messages {
+		role := provider.RoleUser
+		if msg.Role == chat.RoleAssistant {
+			role = provider.RoleAssistant
+		}
+		out = append(out, provider.Message{Role: role, Content: msg.Text()})
+	}
+	return out
+}
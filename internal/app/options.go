@@ -0,0 +1,286 @@
+package app
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/fingergohappy/vai/internal/config"
+	"github.com/fingergohappy/vai/internal/theme"
+	ui "github.com/fingergohappy/vai/internal/ui"
+)
+
+// option is a :set option backed by a configuration field. key is the
+// field's dotted config key; options without set are read-only. value
+// returns the field as it is written to the config file.
+type option struct {
+	name   string
+	short  string
+	key    string
+	isBool bool
+	get    func(cfg *config.Config) string
+	set    func(cfg *config.Config, value string) error
+	value  func(cfg *config.Config) any
+}
+
+// options lists all :set options.
+var options = []option{
+	{
+		name: "tabwidth", short: "ts", key: "editor.tab_width",
+		get: func(cfg *config.Config) string { return strconv.Itoa(cfg.Editor.TabWidth) },
+		set: func(cfg *config.Config, value string) error {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return fmt.Errorf("invalid argument: tabwidth=%s", value)
+			}
+			cfg.Editor.TabWidth = n
+			return nil
+		},
+	},
+	boolOption("sendonsave", "", "editor.send_on_save", func(cfg *config.Config) *bool { return &cfg.Editor.SendOnSave }),
+	boolOption("history", "", "layout.history", func(cfg *config.Config) *bool { return &cfg.Layout.History }),
+	intOption("historywidth", "", "layout.history_width", func(n int) bool { return n == 0 || n >= 10 }, func(cfg *config.Config) *int { return &cfg.Layout.HistoryWidth }),
+	intOption("inputheight", "", "layout.input_height", func(n int) bool { return n >= 1 }, func(cfg *config.Config) *int { return &cfg.Layout.InputHeight }),
+	intOption("inputmaxheight", "", "layout.input_max_height", func(n int) bool { return n >= 1 }, func(cfg *config.Config) *int { return &cfg.Layout.InputMaxHeight }),
+	intOption("verticalbelow", "", "layout.vertical_below", func(n int) bool { return n >= 0 }, func(cfg *config.Config) *int { return &cfg.Layout.VerticalBelow }),
+	{
+		name: "theme", key: "theme.name",
+		get: func(cfg *config.Config) string { return cfg.Theme.Name },
+		set: func(cfg *config.Config, value string) error {
+			// A theme file with bad colors is still applied, with an error
+			if t, err := theme.Named(value, theme.DefaultDir()); t.Colors == nil {
+				return err
+			}
+			cfg.Theme.Name = value
+			return nil
+		},
+	},
+	// Set by the config files and profiles
+	{name: "profile", key: "profile", get: func(cfg *config.Config) string { return cfg.Profile }},
+	{name: "provider", key: "provider.name", get: func(cfg *config.Config) string { return cfg.Provider.Name }},
+	{name: "model", key: "provider.model", get: func(cfg *config.Config) string { return cfg.Provider.Model }},
+	{name: "baseurl", key: "provider.base_url", get: func(cfg *config.Config) string { return cfg.Provider.BaseURL }},
+}
+
+// boolOption creates a boolean option that toggles the field returned by ptr.
+func boolOption(name, short, key string, ptr func(cfg *config.Config) *bool) option {
+	return option{
+		name:   name,
+		short:  short,
+		key:    key,
+		isBool: true,
+		get: func(cfg *config.Config) string {
+			if *ptr(cfg) {
+				return name
+			}
+			return "no" + name
+		},
+		set: func(cfg *config.Config, value string) error {
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("invalid argument: %s=%s", name, value)
+			}
+			*ptr(cfg) = b
+			return nil
+		},
+		value: func(cfg *config.Config) any { return *ptr(cfg) },
+	}
+}
+
+// intOption creates a number option for the field returned by ptr; valid
+// reports whether a value is allowed.
+func intOption(name, short, key string, valid func(n int) bool, ptr func(cfg *config.Config) *int) option {
+	return option{
+		name:  name,
+		short: short,
+		key:   key,
+		get:   func(cfg *config.Config) string { return strconv.Itoa(*ptr(cfg)) },
+		set: func(cfg *config.Config, value string) error {
+			n, err := strconv.Atoi(value)
+			if err != nil || !valid(n) {
+				return fmt.Errorf("invalid argument: %s=%s", name, value)
+			}
+			*ptr(cfg) = n
+			return nil
+		},
+		value: func(cfg *config.Config) any { return *ptr(cfg) },
+	}
+}
+
+// lookupOption finds an option by full or short name.
+func lookupOption(name string) (option, bool) {
+	for _, opt := range options {
+		if opt.name == name || (opt.short != "" && opt.short == name) {
+			return opt, true
+		}
+	}
+	return option{}, false
+}
+
+// optionNames returns all option names for completion.
+func optionNames() []string {
+	names := make([]string, 0, len(options))
+	for _, opt := range options {
+		names = append(names, opt.name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// setOptions applies :set arguments to the configuration:
+//
+//	:set                show all options
+//	:set all?           list all options and where they were set
+//	:set name?          show an option
+//	:set name=value     set an option
+//	:set name / noname  enable / disable a boolean option
+//	:set invname        toggle a boolean option (also name!)
+func (m *Model) setOptions(args []string) error {
+	if len(args) == 0 || (len(args) == 1 && args[0] == "all") {
+		var shown []string
+		for _, opt := range options {
+			shown = append(shown, formatOption(opt, &m.Config))
+		}
+		m.CmdLine.SetMessage(strings.Join(shown, "  "))
+		return nil
+	}
+	if len(args) == 1 && args[0] == "all?" {
+		m.showOptions()
+		return nil
+	}
+
+	var shown []string
+	for _, arg := range args {
+		msg, err := m.setOption(arg)
+		if err != nil {
+			return err
+		}
+		if msg != "" {
+			shown = append(shown, msg)
+		}
+	}
+	if len(shown) > 0 {
+		m.CmdLine.SetMessage(strings.Join(shown, "  "))
+	}
+	return nil
+}
+
+// setOption applies a single :set argument and returns text to show, if any.
+func (m *Model) setOption(arg string) (string, error) {
+	name, value, hasValue := strings.Cut(arg, "=")
+
+	if strings.HasSuffix(name, "?") {
+		opt, ok := lookupOption(strings.TrimSuffix(name, "?"))
+		if !ok {
+			return "", fmt.Errorf("unknown option: %s", name)
+		}
+		return formatOption(opt, &m.Config), nil
+	}
+
+	if hasValue {
+		opt, ok := lookupOption(name)
+		if !ok {
+			return "", fmt.Errorf("unknown option: %s", name)
+		}
+		return "", m.setOptionValue(opt, value)
+	}
+
+	// Boolean forms: name, noname, invname, name!
+	toggle := false
+	enable := "true"
+	switch {
+	case strings.HasSuffix(name, "!"):
+		name, toggle = strings.TrimSuffix(name, "!"), true
+	case strings.HasPrefix(name, "inv"):
+		name, toggle = strings.TrimPrefix(name, "inv"), true
+	case strings.HasPrefix(name, "no"):
+		if _, ok := lookupOption(name); !ok {
+			name, enable = strings.TrimPrefix(name, "no"), "false"
+		}
+	}
+
+	opt, ok := lookupOption(name)
+	if !ok {
+		return "", fmt.Errorf("unknown option: %s", name)
+	}
+	if !opt.isBool {
+		// A bare non-boolean option shows its value, as in Vim
+		return formatOption(opt, &m.Config), nil
+	}
+	if toggle {
+		enable = strconv.FormatBool(opt.get(&m.Config) != opt.name)
+	}
+	return "", m.setOptionValue(opt, enable)
+}
+
+// setOptionValue sets an option and records that it was set with :set.
+// Layout options are also saved to the user config file.
+func (m *Model) setOptionValue(opt option, value string) error {
+	if opt.set == nil {
+		return fmt.Errorf("read-only option: %s", opt.name)
+	}
+	origin := m.Config.Origin(opt.key)
+	if err := opt.set(&m.Config, value); err != nil {
+		return err
+	}
+	if m.optionsSet == nil {
+		m.optionsSet = make(map[string]bool)
+	}
+	m.optionsSet[opt.name] = true
+	if strings.HasPrefix(opt.key, "layout.") {
+		return m.saveOption(opt, origin)
+	}
+	return nil
+}
+
+// saveOption writes an option to the user config file, unless the project
+// file sets it (origin), which would override the saved value.
+func (m *Model) saveOption(opt option, origin config.Origin) error {
+	if m.loader == nil {
+		return nil
+	}
+	path := m.loader.Path()
+	if origin.Path != "" && origin.Path != path {
+		m.CmdLine.SetMessage(fmt.Sprintf("%s is set in %s; changed for this session only", opt.name, origin))
+		return nil
+	}
+	if err := m.loader.SaveValue(opt.key, opt.value(&m.Config)); err != nil {
+		return fmt.Errorf("%s changed for this session only: %w", opt.name, err)
+	}
+	// The file now holds the running value; reloading it would only drop
+	// other options set for the session
+	if m.configWatcher != nil {
+		m.configWatcher.Skip(path)
+	}
+	return nil
+}
+
+// showOptions lists all options with their values and where they were set:
+// a config file, a profile, :set or the default (:set all?).
+func (m *Model) showOptions() {
+	width := 0
+	for _, opt := range options {
+		width = max(width, len(formatOption(opt, &m.Config)))
+	}
+	lines := make([]string, 0, len(options))
+	for _, opt := range options {
+		origin := m.Config.Origin(opt.key).String()
+		switch {
+		case m.optionsSet[opt.name]:
+			origin = ":set"
+		case opt.key == "profile" && m.loader != nil && m.loader.Profile() != "":
+			origin = "--profile or :profile"
+		}
+		lines = append(lines, fmt.Sprintf("%-*s  %s", width, formatOption(opt, &m.Config), origin))
+	}
+	m.popup = ui.NewPopup(m.Styles, "Options", lines)
+}
+
+// formatOption renders an option as Vim's :set does (name=value or [no]name).
+func formatOption(opt option, cfg *config.Config) string {
+	if opt.isBool {
+		return opt.get(cfg)
+	}
+	return opt.name + "=" + opt.get(cfg)
+}
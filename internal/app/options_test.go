@@ -0,0 +1,106 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/fingergohappy/vai/internal/config"
+)
+
+// newTestModel returns a model reading config from a temporary user config
+// file with the given content, and the working directory's project file
+// if project is not empty.
+func newTestModel(t *testing.T, user, project string) (*Model, string) {
+	t.Helper()
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	dir := t.TempDir()
+	t.Chdir(dir)
+	if project != "" {
+		if err := os.WriteFile(filepath.Join(dir, config.ProjectFile), []byte(project), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	loader := config.NewLoader()
+	if user != "" {
+		if err := os.MkdirAll(filepath.Dir(loader.Path()), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(loader.Path(), []byte(user), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cfg, err := loader.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	model := NewModel(cfg)
+	model.SetLoader(loader)
+	updated, _ := model.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	m := updated.(Model)
+	return &m, loader.Path()
+}
+
+func TestLayoutOptionsAreSaved(t *testing.T) {
+	tests := []struct {
+		name    string
+		user    string
+		project string
+		set     func(m *Model) error
+		want    string // user config file after set
+		message string // command line message
+	}{
+		{
+			name: ":set creates the file",
+			set:  func(m *Model) error { return m.setOptions([]string{"nohistory"}) },
+			want: "layout:\n  history: false\n",
+		},
+		{
+			name: "Ctrl-w o",
+			user: "# mine\nlayout:\n  history: true # shown\n",
+			set:  func(m *Model) error { return m.toggleHistory() },
+			want: "# mine\nlayout:\n  history: false # shown\n",
+		},
+		{
+			name: "Ctrl-w >",
+			user: "layout:\n  history_width: 30\n",
+			set:  func(m *Model) error { return m.resizeHistory(5) },
+			want: "layout:\n  history_width: 35\n",
+		},
+		{
+			name: "other options are not saved",
+			user: "editor:\n  tab_width: 4\n",
+			set:  func(m *Model) error { return m.setOptions([]string{"tabwidth=8", "nosendonsave"}) },
+			want: "editor:\n  tab_width: 4\n",
+		},
+		{
+			name:    "set in the project file",
+			user:    "layout:\n  input_height: 3\n",
+			project: "layout:\n  input_height: 6\n",
+			set:     func(m *Model) error { return m.setOptions([]string{"inputheight=4"}) },
+			want:    "layout:\n  input_height: 3\n",
+			message: ".vai.yaml:2; changed for this session only",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, path := newTestModel(t, tt.user, tt.project)
+			if err := tt.set(m); err != nil {
+				t.Fatal(err)
+			}
+			data, _ := os.ReadFile(path)
+			if string(data) != tt.want {
+				t.Errorf("user config =\n%s\nwant\n%s", data, tt.want)
+			}
+			m.CmdLine.SetWidth(500)
+			if tt.message != "" && !strings.Contains(m.CmdLine.View(), tt.message) {
+				t.Errorf("message = %q, want %q", strings.TrimSpace(m.CmdLine.View()), tt.message)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/fingergohappy/vai/internal/chat"
+	"github.com/fingergohappy/vai/internal/command"
+	"github.com/fingergohappy/vai/internal/input"
+	"github.com/fingergohappy/vai/internal/session"
+	"github.com/fingergohappy/vai/pkg/markdown"
+)
+
+// sendPrompt adds the prompt to the conversation as a user message, with
+// the pending and @mentioned files attached, clears the input area and
+// saves the session. If an attachment cannot be read nothing is sent. A
+// slash command runs instead, and a leading "//" sends one slash.
+func (m *Model) sendPrompt() tea.Cmd {
+	if slashPrompt(m.Input.Value()) {
+		return m.runSlash()
+	}
+	text := command.Unslash(strings.TrimSpace(m.Input.Value()))
+	if text == "" && len(m.attachments) == 0 {
+		return nil
+	}
+	files, err := m.promptAttachments(text)
+	if err != nil {
+		m.CmdLine.SetError(err)
+		return nil
+	}
+
+	blocks := append(toChatBlocks(text), contextBlocks(files)...)
+	m.Input.Reset()
+	m.attachments = nil
+	if len(files) > 0 {
+		m.CmdLine.SetMessage(m.attachmentSummary(files))
+	}
+
+	sessionID := ""
+	if sess := m.appendPrompt(chat.NewMessage(chat.RoleUser, blocks)); sess != nil {
+		sessionID = sess.ID
+		sess.Draft = ""
+		m.reportError(m.Store.Save(*sess))
+	}
+	if err := m.History.Add(text, sessionID); err != nil {
+		m.CmdLine.SetError(fmt.Errorf("history: %w", err))
+	}
+	m.Input.SetHistory(m.History.Texts())
+	return nil
+}
+
+// appendPrompt adds a user message to the conversation and returns the
+// synced current session, or nil if there is none.
+func (m *Model) appendPrompt(msg chat.Message) *session.Session {
+	m.Chat.AddMessage(msg)
+	m.Chat.GotoBottom(0)
+	return m.syncCurrentSession()
+}
+
+// updateHistorySearch passes a key to the active Ctrl-s search and shows
+// its state in the command line.
+func (m Model) updateHistorySearch(key tea.KeyMsg) (tea.Model, tea.Cmd) {
+	model, cmd := m.Input.Update(key)
+	m.Input = model.(input.Model)
+	if m.Input.Searching() {
+		m.CmdLine.SetMessage(m.Input.SearchStatus())
+	} else {
+		m.CmdLine.ClearMessage()
+	}
+	return m, cmd
+}
+
+// toChatBlocks splits markdown text into chat text and code blocks.
+func toChatBlocks(text string) []chat.Block {
+	var blocks []chat.Block
+	number := 0
+	for _, block := range markdown.NewParser().Parse(text) {
+		switch b := block.(type) {
+		case *markdown.TextBlock:
+			blocks = append(blocks, chat.NewTextBlock(b.Content))
+		case *markdown.CodeBlock:
+			number++
+			blocks = append(blocks, chat.NewCodeBlock(b.Lang, strings.Split(b.Content, "\n"), number))
+		}
+	}
+	return blocks
+}
@@ -0,0 +1,125 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fingergohappy/vai/internal/clipboard"
+	"github.com/fingergohappy/vai/internal/register"
+	ui "github.com/fingergohappy/vai/internal/ui"
+	"github.com/fingergohappy/vai/internal/vim"
+)
+
+// yankMotion runs the yank operator over its motion:
+//
+//	yc   the current code block (yNc: code block N of the current message)
+//	ym   the current message
+//
+// In the input area it yanks prompt text over any editor motion (yw, yiw, yy).
+func (m *Model) yankMotion(ctx vim.Context) {
+	if m.Focus == ui.FocusInput {
+		text, ok := m.Input.Yank(ctx.Motion, ctx.Count)
+		if !ok {
+			m.CmdLine.SetError(fmt.Errorf("cannot yank %s", ctx.Motion))
+			return
+		}
+		m.yank(ctx.Register, text, "text")
+		return
+	}
+
+	switch ctx.Motion {
+	case "code-block":
+		block := m.Chat.CurrentCodeBlock()
+		if ctx.Count > 0 {
+			block = m.Chat.CodeBlock(ctx.Count)
+		}
+		if block == nil {
+			m.CmdLine.SetError(fmt.Errorf("no code block"))
+			return
+		}
+		m.yank(ctx.Register, block.Content(), fmt.Sprintf("code block [%d]", block.Number))
+	case "message":
+		msg := m.Chat.CurrentMessage()
+		if msg == nil {
+			m.CmdLine.SetError(fmt.Errorf("no message"))
+			return
+		}
+		m.yank(ctx.Register, msg.Text(), "message")
+	default:
+		m.CmdLine.SetError(fmt.Errorf("cannot yank %s", ctx.Motion))
+	}
+}
+
+// yank stores text in a register (the unnamed register if name is 0) and
+// reports the result.
+func (m *Model) yank(name rune, text, what string) {
+	if name == 0 {
+		name = register.Unnamed
+	}
+	if err := m.Registers.Yank(name, text); err != nil {
+		m.CmdLine.SetError(err)
+		return
+	}
+	lines := strings.Count(text, "\n") + 1
+	m.CmdLine.SetMessage(fmt.Sprintf("%s yanked to \"%c (%d lines)", what, name, lines))
+}
+
+// pasteRegister inserts the content of a register at the input cursor.
+func (m *Model) pasteRegister(name rune) {
+	text, err := m.Registers.Read(name)
+	if err != nil {
+		m.CmdLine.SetError(err)
+		return
+	}
+	m.Input.InsertString(normalizeNewlines(text))
+}
+
+// pasteText inserts a bracketed paste into the prompt as literal text:
+// at the cursor in INSERT mode, or after it like p in NORMAL mode.
+func (m *Model) pasteText(text string) {
+	text = normalizeNewlines(text)
+	if m.Mode == vim.ModeInsert && m.Focus == ui.FocusInput {
+		m.Input.InsertString(text)
+		return
+	}
+	m.setFocus(ui.FocusInput)
+	m.Input.Put(text, false, 1)
+}
+
+// normalizeNewlines converts CRLF and CR line endings, as sent by some
+// terminals and clipboards, to LF.
+func normalizeNewlines(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	return strings.ReplaceAll(text, "\r", "\n")
+}
+
+// sendPane pastes a register, by default the last yank, into a tmux pane
+// and presses Enter after it with a bang (:send-pane[!]).
+func (m *Model) sendPane(msg sendPaneMsg) error {
+	text, err := m.Registers.Read(msg.register)
+	if err != nil {
+		return err
+	}
+	// A linewise yank ends in a newline, which would run the text even
+	// without the bang
+	text = strings.TrimSuffix(text, "\n")
+	if err := clipboard.NewTmux().Send(msg.target, text, msg.enter); err != nil {
+		return err
+	}
+	lines := strings.Count(text, "\n") + 1
+	m.CmdLine.SetMessage(fmt.Sprintf("%d lines sent to %s", lines, msg.target))
+	return nil
+}
+
+// showRegisters opens a popup listing all non-empty registers.
+func (m *Model) showRegisters() {
+	var lines []string
+	for _, entry := range m.Registers.Entries() {
+		content := strings.ReplaceAll(entry.Content, "\n", "^J")
+		lines = append(lines, fmt.Sprintf("\"%c   %s", entry.Name, content))
+	}
+	if len(lines) == 0 {
+		lines = []string{"(all registers are empty)"}
+	}
+	m.popup = ui.NewPopup(m.Styles, "Registers", lines)
+}
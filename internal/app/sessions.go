@@ -0,0 +1,224 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/fingergohappy/vai/internal/chat"
+	"github.com/fingergohappy/vai/internal/session"
+)
+
+// loadSessions fills the session list from the store and opens the most
+// recent session. With no stored sessions, an empty one is started.
+func (m *Model) loadSessions() error {
+	sessions, err := m.Store.List()
+	if err != nil {
+		m.startSession(nil)
+		return fmt.Errorf("load sessions: %w", err)
+	}
+
+	if len(sessions) == 0 {
+		m.startSession(nil)
+		return nil
+	}
+
+	m.Session.SetSessions(sessions)
+	m.switchTo(sessions[0])
+	return nil
+}
+
+// startSession creates a new session holding messages and makes it current.
+func (m *Model) startSession(messages []chat.Message) {
+	m.reportError(m.saveDraft())
+	sess := session.NewSession()
+	if m.Config.Provider.Model != "" {
+		sess.Model = m.Config.Provider.Model
+	}
+	sess.System = m.Config.SystemPrompt
+	m.Session.AddSession(sess)
+	m.Session.SetCurrent(sess.ID)
+	m.Chat.SetMessages(messages)
+	m.Chat.SetSystem(sess.System)
+	m.Input.Load("")
+}
+
+// newSession starts an empty session (:new).
+func (m *Model) newSession() {
+	m.startSession(nil)
+	m.CmdLine.SetMessage("new session")
+}
+
+// clearSession removes all messages from the current session and saves
+// it (:clear). The system prompt, model and draft stay.
+func (m *Model) clearSession() error {
+	n := len(m.Chat.Messages)
+	m.Chat.SetMessages(nil)
+	if sess := m.syncCurrentSession(); sess != nil {
+		if err := m.Store.Save(*sess); err != nil {
+			return err
+		}
+	}
+	m.CmdLine.SetMessage(fmt.Sprintf("%d messages cleared", n))
+	return nil
+}
+
+// switchTo makes sess the current session and shows its messages and
+// draft. The draft of the session left behind is saved.
+func (m *Model) switchTo(sess session.Session) {
+	m.reportError(m.saveDraft())
+	if cur := m.Session.Current(); cur != nil && cur.ID == sess.ID {
+		sess = *cur // reopening: keep what was just synced
+	}
+	m.Session.Put(sess)
+	m.Session.SetCurrent(sess.ID)
+
+	messages := make([]chat.Message, len(sess.Messages))
+	for i, msg := range sess.Messages {
+		messages[i] = toChatMessage(msg)
+	}
+	m.Chat.SetMessages(messages)
+	m.Chat.SetSystem(sess.System)
+	m.Input.Load(sess.Draft)
+}
+
+// openSession switches to the session with the given title or ID (:e).
+func (m *Model) openSession(name string) error {
+	sess := m.Session.Find(name)
+	if sess == nil {
+		return fmt.Errorf("no such session: %s", name)
+	}
+	if sess.ID != name && m.Session.CountTitle(name) > 1 {
+		return fmt.Errorf("several sessions are titled %q; use the session ID", name)
+	}
+	m.switchTo(*sess)
+	m.CmdLine.SetMessage(fmt.Sprintf("%q %d messages", sess.Title, len(sess.Messages)))
+	return nil
+}
+
+// syncCurrentSession copies the chat buffer into the current session.
+func (m *Model) syncCurrentSession() *session.Session {
+	sess := m.Session.Current()
+	if sess == nil {
+		return nil
+	}
+	sess.Messages = make([]session.Message, len(m.Chat.Messages))
+	for i, msg := range m.Chat.Messages {
+		sess.Messages[i] = toSessionMessage(msg)
+	}
+	sess.UpdatedAt = time.Now()
+	return sess
+}
+
+// writeSession saves the current session (:w), or exports it as markdown
+// when a path is given (:w file).
+func (m *Model) writeSession(path string) error {
+	if path != "" {
+		if err := os.WriteFile(path, []byte(m.Chat.Markdown()), 0644); err != nil {
+			return err
+		}
+		m.CmdLine.SetMessage(fmt.Sprintf("%q written", path))
+		return nil
+	}
+
+	sess := m.syncCurrentSession()
+	if sess == nil {
+		return fmt.Errorf("no current session")
+	}
+	if err := m.Store.Save(*sess); err != nil {
+		return err
+	}
+	m.CmdLine.SetMessage(fmt.Sprintf("%q written, %d messages", sess.Title, len(sess.Messages)))
+	return nil
+}
+
+// setModel sets the model of the current session (:model).
+func (m *Model) setModel(name string) {
+	if sess := m.Session.Current(); sess != nil {
+		sess.Model = name
+	}
+	m.CmdLine.SetMessage("model: " + name)
+}
+
+// setSystem sets and saves the system prompt of the current session
+// (:system).
+func (m *Model) setSystem(text string) error {
+	sess := m.syncCurrentSession()
+	if sess == nil {
+		return fmt.Errorf("no current session")
+	}
+	sess.System = text
+	m.Chat.SetSystem(text)
+	if err := m.Store.Save(*sess); err != nil {
+		return err
+	}
+	if text == "" {
+		m.CmdLine.SetMessage("system prompt cleared")
+	} else {
+		m.CmdLine.SetMessage("system prompt set")
+	}
+	return nil
+}
+
+// toSessionMessage converts a chat buffer message into its stored form.
+func toSessionMessage(msg chat.Message) session.Message {
+	content := make([]session.Block, 0, len(msg.Blocks))
+	for _, block := range msg.Blocks {
+		switch b := block.(type) {
+		case *chat.TextBlock:
+			content = append(content, &session.TextContent{Text: b.Text})
+		case *chat.CodeBlock:
+			content = append(content, &session.CodeContent{Lang: b.Lang, Lines: b.Lines})
+		case *chat.ContextBlock:
+			content = append(content, &session.ContextContent{Path: b.Path, Lang: b.Lang, Lines: b.Lines})
+		}
+	}
+	return session.Message{
+		ID:        msg.ID,
+		Role:      string(msg.Role),
+		Content:   content,
+		CreatedAt: msg.CreatedAt,
+	}
+}
+
+// toChatMessage converts a stored message into a chat buffer message.
+func toChatMessage(msg session.Message) chat.Message {
+	blocks := make([]chat.Block, 0, len(msg.Content))
+	number := 0
+	for _, block := range msg.Content {
+		switch b := block.(type) {
+		case *session.TextContent:
+			blocks = append(blocks, chat.NewTextBlock(b.Text))
+		case *session.CodeContent:
+			number++
+			blocks = append(blocks, chat.NewCodeBlock(b.Lang, b.Lines, number))
+		case *session.ContextContent:
+			blocks = append(blocks, chat.NewContextBlock(b.Path, b.Lang, b.Lines))
+		}
+	}
+	return chat.Message{
+		ID:        msg.ID,
+		Role:      chat.Role(msg.Role),
+		Blocks:    blocks,
+		CreatedAt: msg.CreatedAt,
+	}
+}
+
+// sessionNames returns the names :edit completes: a session's title when no
+// other session shares it, its ID otherwise.
+func sessionNames(store *session.Store) []string {
+	sessions, _ := store.List()
+	count := make(map[string]int, len(sessions))
+	for _, sess := range sessions {
+		count[sess.Title]++
+	}
+	names := make([]string, 0, len(sessions))
+	for _, sess := range sessions {
+		if count[sess.Title] > 1 {
+			names = append(names, sess.ID)
+		} else {
+			names = append(names, sess.Title)
+		}
+	}
+	return names
+}
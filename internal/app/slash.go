@@ -0,0 +1,145 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/fingergohappy/vai/internal/command"
+	ui "github.com/fingergohappy/vai/internal/ui"
+	"github.com/fingergohappy/vai/internal/vim"
+)
+
+// maxSlashRows is the number of rows the slash command menu shows.
+const maxSlashRows = 8
+
+// slashTyped returns true if the prompt is a slash command being typed:
+// a lone "/" or a line that command.IsSlash accepts.
+func slashTyped(text string) bool {
+	return text == "/" || command.IsSlash(text)
+}
+
+// slashPrompt returns true if the prompt is a slash command line, which
+// runs instead of being sent.
+func slashPrompt(text string) bool {
+	return command.IsSlash(strings.TrimSpace(text))
+}
+
+// runSlash runs the slash command in the prompt and clears it. If the
+// command is unknown or its arguments are missing, the prompt is kept and
+// the error shown, and nothing is sent.
+func (m *Model) runSlash() tea.Cmd {
+	text := strings.TrimSpace(m.Input.Value())
+	cmd, err := m.Commands.ExecuteSlash(text)
+	if err != nil {
+		m.CmdLine.SetError(err)
+		return nil
+	}
+	m.slash = nil
+	m.Input.Reset()
+	return cmd
+}
+
+// completeSlash completes the slash command or argument being typed,
+// cycling through the candidates on repeated presses.
+func (m *Model) completeSlash() {
+	value := m.Input.Value()
+	sc := m.slash
+	if sc != nil && value == sc.candidates[sc.idx] {
+		sc.idx = (sc.idx + 1) % len(sc.candidates)
+	} else {
+		candidates := m.Commands.CompleteSlash(value)
+		if len(candidates) == 0 {
+			m.slash = nil
+			return
+		}
+		sc = &completion{candidates: candidates}
+		m.slash = sc
+	}
+	text := sc.candidates[sc.idx]
+	// A completed command name is followed by its arguments
+	if !strings.ContainsAny(text, " \t") && len(sc.candidates) == 1 {
+		if c, err := m.Commands.LookupSlash(text[1:]); err == nil && c.Usage != "" {
+			text += " "
+		}
+	}
+	m.Input.Replace(text)
+}
+
+// slashMenuVisible returns true if the slash command menu is shown above
+// the input area.
+func (m Model) slashMenuVisible() bool {
+	return m.Mode == vim.ModeInsert && m.Focus == ui.FocusInput && slashTyped(m.Input.Value())
+}
+
+// renderSlashMenu renders the menu of the slash command being typed: the
+// matching commands with their arguments and descriptions while the name
+// is typed, then the usage of the command and its argument candidates.
+func (m Model) renderSlashMenu() string {
+	value := m.Input.Value()
+
+	// The row of the command or candidate typed in full is highlighted
+	var rows []string
+	var current int
+	if !strings.ContainsAny(value, " \t") {
+		cmds := m.Commands.SlashCommands()
+		width := 0
+		for _, c := range cmds {
+			width = max(width, len(slashSynopsis(c)))
+		}
+		for _, c := range cmds {
+			if !strings.HasPrefix(c.Name, value[1:]) {
+				continue
+			}
+			row := fmt.Sprintf("%-*s  %s", width, slashSynopsis(c), c.Description)
+			if "/"+c.Name == value {
+				current = len(rows)
+				row = m.Styles.CommandMenu.Render(row)
+			}
+			rows = append(rows, row)
+		}
+		if len(rows) == 0 {
+			rows = append(rows, m.Styles.ErrorMessage.Render("unknown command: "+value))
+		}
+	} else {
+		ctx, _ := command.Parse(value[1:])
+		c, err := m.Commands.LookupSlash(ctx.Name)
+		if err != nil {
+			rows = append(rows, m.Styles.ErrorMessage.Render(err.Error()))
+		} else {
+			rows = append(rows, slashSynopsis(c)+"  "+m.Styles.PopupHint.Render(c.Description))
+			for _, cand := range m.Commands.CompleteSlash(value) {
+				fields := strings.Fields(cand)
+				row := "  " + fields[len(fields)-1]
+				if cand == value {
+					current = len(rows)
+					row = m.Styles.CommandMenu.Render(row)
+				}
+				rows = append(rows, row)
+			}
+		}
+	}
+
+	// Keep the selected row in view
+	if len(rows) > maxSlashRows {
+		start := min(max(current-maxSlashRows+1, 0), len(rows)-maxSlashRows)
+		rows = rows[start : start+maxSlashRows]
+	}
+	frameX, _ := m.Styles.Popup.GetFrameSize()
+	width := max(m.Layout.Width-frameX-2, 1)
+	for i, row := range rows {
+		rows[i] = ansi.Truncate(row, width, "…")
+	}
+	return m.Styles.Popup.Render(strings.Join(rows, "\n"))
+}
+
+// slashSynopsis returns the name and arguments of a slash command, e.g.
+// "/model {name}".
+func slashSynopsis(c *command.Command) string {
+	if c.Usage == "" {
+		return "/" + c.Name
+	}
+	return "/" + c.Name + " " + c.Usage
+}
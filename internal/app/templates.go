@@ -0,0 +1,140 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/fingergohappy/vai/internal/register"
+	"github.com/fingergohappy/vai/internal/templates"
+	ui "github.com/fingergohappy/vai/internal/ui"
+)
+
+// expansion is a template waiting for the answers to its {{input}}
+// questions, which are asked one at a time on the command line.
+type expansion struct {
+	name      string
+	tmpl      *templates.Template
+	data      templates.Data
+	questions []string
+	answers   map[string]string
+}
+
+// pickTemplate opens the template picker, or expands the template name
+// directly (:template [name]).
+func (m *Model) pickTemplate(name string) (tea.Cmd, error) {
+	if name != "" {
+		return m.expandTemplate(name)
+	}
+	names, err := m.Templates.Names()
+	if err != nil {
+		return nil, err
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no templates in %s", m.Templates.Dir())
+	}
+	m.picker = ui.NewPicker(m.Styles, "Templates", names)
+	m.pick = func(m *Model, choice string) tea.Cmd {
+		cmd, err := m.expandTemplate(choice)
+		m.reportError(err)
+		return cmd
+	}
+	return nil, nil
+}
+
+// expandTemplate loads and parses a template and asks its questions. It
+// is inserted once all of them are answered.
+func (m *Model) expandTemplate(name string) (tea.Cmd, error) {
+	text, err := m.Templates.Load(name)
+	if err != nil {
+		return nil, err
+	}
+	tmpl, err := templates.Parse(name, text)
+	if err != nil {
+		return nil, err
+	}
+	regs := m.Registers
+	data := templates.Data{
+		ReadClipboard: func() (string, error) { return regs.Read(register.Clipboard) },
+		MaxFileSize:   m.maxFileSize(),
+	}
+	data.Selection, _ = regs.Get(register.Unnamed)
+
+	questions, err := tmpl.Questions(data)
+	if err != nil {
+		return nil, err
+	}
+	m.expanding = &expansion{
+		name:      name,
+		tmpl:      tmpl,
+		data:      data,
+		questions: questions,
+		answers:   make(map[string]string),
+	}
+	return m.askTemplate()
+}
+
+// askTemplate asks the next unanswered question of the pending
+// expansion, or inserts the expanded template when there are none left.
+func (m *Model) askTemplate() (tea.Cmd, error) {
+	exp := m.expanding
+	if len(exp.answers) < len(exp.questions) {
+		return m.CmdLine.Ask(exp.questions[len(exp.answers)]), nil
+	}
+	m.expanding = nil
+
+	text, err := exp.tmpl.Execute(exp.data, exp.answers)
+	if err != nil {
+		return nil, err
+	}
+	text = strings.TrimRight(text, "\n")
+	if text == "" {
+		return nil, fmt.Errorf("template %s expanded to nothing", exp.name)
+	}
+	m.pasteText(text)
+	m.CmdLine.SetMessage("template: " + exp.name)
+	return nil, nil
+}
+
+// answerTemplate records the answer to the current question.
+func (m *Model) answerTemplate(answer string) (tea.Cmd, error) {
+	if m.expanding == nil {
+		return nil, nil
+	}
+	exp := m.expanding
+	exp.answers[exp.questions[len(exp.answers)]] = answer
+	return m.askTemplate()
+}
+
+// saveTemplate saves the prompt as a template (:template-save[!]).
+func (m *Model) saveTemplate(name string, overwrite bool) error {
+	text := m.Input.Value()
+	if strings.TrimSpace(text) == "" {
+		return errors.New("prompt is empty")
+	}
+	if err := m.Templates.Save(name, text+"\n", overwrite); err != nil {
+		if errors.Is(err, templates.ErrExists) {
+			return fmt.Errorf("%w (add ! to overwrite)", err)
+		}
+		return err
+	}
+	m.CmdLine.SetMessage("template saved: " + name)
+	return nil
+}
+
+// updatePicker passes a key to the open picker and runs its pick function
+// once an item is picked.
+func (m Model) updatePicker(key tea.KeyMsg) (tea.Model, tea.Cmd) {
+	choice, done := m.picker.HandleKey(key)
+	if !done {
+		return m, nil
+	}
+	pick := m.pick
+	m.picker, m.pick = nil, nil
+	if choice == "" {
+		return m, nil
+	}
+	return m, pick(&m, choice)
+}
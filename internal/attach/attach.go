@@ -0,0 +1,212 @@
+// Package attach reads files attached to prompts as context, either
+// mentioned as @path or added with :attach, and finds files for mention
+// completion.
+package attach
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrBinary is returned for files that do not look like text.
+var ErrBinary = errors.New("binary file")
+
+// File is an attached file.
+type File struct {
+	Path    string // Path as given by the user
+	Lang    string // Language identifier for the code fence
+	Content string
+}
+
+// Tokens estimates the number of tokens of the file content.
+func (f File) Tokens() int {
+	return EstimateTokens(f.Content)
+}
+
+// Lines returns the content split into lines, without a trailing empty line.
+func (f File) Lines() []string {
+	return strings.Split(strings.TrimRight(f.Content, "\n"), "\n")
+}
+
+// EstimateTokens roughly estimates the tokens of text, at about four bytes
+// per token.
+func EstimateTokens(text string) int {
+	return (len(text) + 3) / 4
+}
+
+// sniffLen is how much of a file is checked for binary content.
+const sniffLen = 8000
+
+// Read reads a text file of at most maxSize bytes (0 means no limit).
+func Read(path string, maxSize int64) (File, error) {
+	info, err := os.Stat(ExpandHome(path))
+	if err != nil {
+		return File{}, err
+	}
+	if info.IsDir() {
+		return File{}, fmt.Errorf("%s: is a directory", path)
+	}
+	if maxSize > 0 && info.Size() > maxSize {
+		return File{}, fmt.Errorf("%s: too large (%d KiB, limit %d KiB)", path, kib(info.Size()), kib(maxSize))
+	}
+
+	data, err := os.ReadFile(ExpandHome(path))
+	if err != nil {
+		return File{}, err
+	}
+	if IsBinary(data) {
+		return File{}, fmt.Errorf("%s: %w", path, ErrBinary)
+	}
+	return File{Path: path, Lang: Lang(path), Content: string(data)}, nil
+}
+
+// IsBinary reports whether data looks like a binary file: it contains a
+// NUL byte or is not valid UTF-8 near the start.
+func IsBinary(data []byte) bool {
+	head := data[:min(len(data), sniffLen)]
+	if bytes.IndexByte(head, 0) >= 0 {
+		return true
+	}
+	if len(head) < len(data) {
+		// The cut may split the last character
+		for i := 0; i < utf8.UTFMax-1 && !utf8.Valid(head); i++ {
+			head = head[:len(head)-1]
+		}
+	}
+	return !utf8.Valid(head)
+}
+
+// kib converts bytes to KiB, rounding up.
+func kib(n int64) int64 {
+	return (n + 1023) / 1024
+}
+
+// ExpandHome replaces a leading ~/ with the home directory, as paths given
+// to :attach or mentioned as @path may start with it.
+func ExpandHome(path string) string {
+	if strings.HasPrefix(path, "~"+string(filepath.Separator)) {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, path[2:])
+		}
+	}
+	return path
+}
+
+// langs maps file extensions to code fence languages where they differ.
+var langs = map[string]string{
+	"py":   "python",
+	"rb":   "ruby",
+	"rs":   "rust",
+	"sh":   "bash",
+	"zsh":  "bash",
+	"yml":  "yaml",
+	"md":   "markdown",
+	"js":   "javascript",
+	"ts":   "typescript",
+	"h":    "c",
+	"hpp":  "cpp",
+	"cc":   "cpp",
+	"kt":   "kotlin",
+	"tf":   "hcl",
+	"mk":   "make",
+	"txt":  "",
+	"log":  "",
+	"conf": "",
+}
+
+// Lang returns the code fence language for a file name.
+func Lang(path string) string {
+	base := filepath.Base(path)
+	switch base {
+	case "Makefile", "GNUmakefile":
+		return "make"
+	case "Dockerfile":
+		return "dockerfile"
+	}
+	ext := strings.TrimPrefix(filepath.Ext(base), ".")
+	if lang, ok := langs[strings.ToLower(ext)]; ok {
+		return lang
+	}
+	return strings.ToLower(ext)
+}
+
+// Mentions returns the @path words of text. A mention starts a word; a
+// trailing punctuation mark is not part of it.
+func Mentions(text string) []string {
+	var out []string
+	for _, word := range strings.Fields(text) {
+		if len(word) < 2 || word[0] != '@' {
+			continue
+		}
+		out = append(out, strings.TrimRight(word[1:], ",.;:!?)\"'"))
+	}
+	return out
+}
+
+// Glob returns the files matching pattern. Besides the filepath.Match
+// syntax, a "**" path element matches any number of directories.
+func Glob(pattern string) ([]string, error) {
+	pattern = ExpandHome(pattern)
+	if !strings.Contains(pattern, "**") {
+		matches, err := filepath.Glob(pattern)
+		return filesOnly(matches), err
+	}
+
+	root, _, _ := strings.Cut(pattern, "**")
+	root = filepath.Clean(strings.TrimSuffix(root, string(filepath.Separator)))
+	if root == "" {
+		root = "."
+	}
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+	want := strings.Split(filepath.ToSlash(filepath.Clean(pattern)), "/")
+
+	var out []string
+	err := walk(root, func(path string) bool {
+		if matchSegments(want, strings.Split(filepath.ToSlash(path), "/")) {
+			out = append(out, path)
+		}
+		return true
+	})
+	return out, err
+}
+
+// filesOnly drops directories from paths.
+func filesOnly(paths []string) []string {
+	out := paths[:0]
+	for _, path := range paths {
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			out = append(out, path)
+		}
+	}
+	return out
+}
+
+// matchSegments matches path elements against pattern elements, where
+// "**" matches zero or more elements.
+func matchSegments(pattern, path []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(path); i++ {
+				if matchSegments(pattern[1:], path[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(path) == 0 {
+			return false
+		}
+		if ok, _ := filepath.Match(pattern[0], path[0]); !ok {
+			return false
+		}
+		pattern, path = pattern[1:], path[1:]
+	}
+	return len(path) == 0
+}
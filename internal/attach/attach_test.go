@@ -0,0 +1,180 @@
+package attach
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestMentions(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"explain @main.go please", []string{"main.go"}},
+		{"compare @a.go, @b.go.", []string{"a.go", "b.go"}},
+		{"(see @docs/x.md)", []string{"docs/x.md"}},
+		{"mail me at me@example.com", nil},
+		{"a lone @ sign", nil},
+		{"@first\n@second", []string{"first", "second"}},
+	}
+	for _, tt := range tests {
+		if got := Mentions(tt.text); !slices.Equal(got, tt.want) {
+			t.Errorf("Mentions(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestLang(t *testing.T) {
+	tests := []struct {
+		path, want string
+	}{
+		{"main.go", "go"},
+		{"script.PY", "python"},
+		{"dir/run.sh", "bash"},
+		{"notes.txt", ""},
+		{"Makefile", "make"},
+		{"build/Dockerfile", "dockerfile"},
+		{"README", ""},
+	}
+	for _, tt := range tests {
+		if got := Lang(tt.path); got != tt.want {
+			t.Errorf("Lang(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestIsBinary(t *testing.T) {
+	long := strings.Repeat("a", sniffLen-1) + "é" // split by the sniff limit
+	tests := []struct {
+		name string
+		data []byte
+		want bool
+	}{
+		{"text", []byte("hello\n"), false},
+		{"utf-8", []byte("héllo wörld"), false},
+		{"empty", nil, false},
+		{"nul byte", []byte("a\x00b"), true},
+		{"invalid utf-8", []byte{0xff, 0xfe, 'a'}, true},
+		{"character cut at the sniff limit", []byte(long), false},
+	}
+	for _, tt := range tests {
+		if got := IsBinary(tt.data); got != tt.want {
+			t.Errorf("IsBinary(%s) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRead(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, data []byte) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	text := write("main.go", []byte("package main\n"))
+	large := write("large.txt", []byte(strings.Repeat("x", 2048)))
+	binary := write("image.png", []byte("\x89PNG\x00\x00"))
+
+	f, err := Read(text, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Content != "package main\n" || f.Lang != "go" || f.Path != text {
+		t.Errorf("Read() = %+v", f)
+	}
+	if got := f.Lines(); !slices.Equal(got, []string{"package main"}) {
+		t.Errorf("Lines() = %q", got)
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		maxSize int64
+		want    string
+	}{
+		{"too large", large, 1024, "too large (2 KiB, limit 1 KiB)"},
+		{"directory", dir, 0, "is a directory"},
+		{"binary", binary, 0, "binary file"},
+		{"missing", filepath.Join(dir, "none"), 0, "no such file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Read(tt.path, tt.maxSize); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Read() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+	if _, err := Read(binary, 0); !errors.Is(err, ErrBinary) {
+		t.Errorf("Read() of a binary file = %v, want ErrBinary", err)
+	}
+	if _, err := Read(large, 0); err != nil {
+		t.Errorf("Read() without a limit: %v", err)
+	}
+}
+
+func TestGlob(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.go", "b.md", "sub/c.go", "sub/deep/d.go", ".git/e.go", "node_modules/f.go"} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{"*.go", []string{"a.go"}},
+		{"*", []string{"a.go", "b.md"}},
+		{"**/*.go", []string{"a.go", "sub/c.go", "sub/deep/d.go"}},
+		{"sub/**/*.go", []string{"sub/c.go", "sub/deep/d.go"}},
+		{"**/deep/*", []string{"sub/deep/d.go"}},
+		{"*.rs", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			got, err := Glob(filepath.Join(dir, tt.pattern))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var rel []string
+			for _, path := range got {
+				r, _ := filepath.Rel(dir, path)
+				rel = append(rel, filepath.ToSlash(r))
+			}
+			slices.Sort(rel)
+			if !slices.Equal(rel, tt.want) {
+				t.Errorf("Glob(%q) = %q, want %q", tt.pattern, rel, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpandHome(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"~/notes.md", filepath.Join(home, "notes.md")},
+		{"~", "~"},
+		{"~other/notes.md", "~other/notes.md"},
+		{"notes/~/x.md", "notes/~/x.md"},
+	}
+	for _, tt := range tests {
+		if got := ExpandHome(tt.path); got != tt.want {
+			t.Errorf("ExpandHome(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
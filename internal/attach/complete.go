@@ -0,0 +1,96 @@
+package attach
+
+import (
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/fingergohappy/vai/internal/fuzzy"
+)
+
+// maxWalk caps the number of files visited when completing, so a mention
+// typed in a huge tree stays responsive.
+const maxWalk = 20000
+
+// skipDirs are directories never searched for completion.
+var skipDirs = map[string]bool{
+	"node_modules": true,
+	"vendor":       true,
+	"__pycache__":  true,
+}
+
+// walk calls fn for the regular files below root, skipping hidden and
+// dependency directories. fn returns false to stop.
+func walk(root string, fn func(path string) bool) error {
+	count := 0
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil // unreadable entries are skipped
+		}
+		name := d.Name()
+		if d.IsDir() {
+			if path != root && (strings.HasPrefix(name, ".") || skipDirs[name]) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		count++
+		if count > maxWalk || !fn(path) {
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	return err
+}
+
+// Complete returns up to limit files below the working directory whose
+// path fuzzily matches query, best matches first. The characters of query
+// must appear in order; consecutive characters and matches at the start of
+// path elements and words rank higher.
+func Complete(query string, limit int) []string {
+	type scored struct {
+		path  string
+		score int
+	}
+	var found []scored
+	walk(".", func(path string) bool {
+		if s, ok := fuzzyScore(query, filepath.ToSlash(path)); ok {
+			found = append(found, scored{path, s})
+		}
+		return true
+	})
+
+	sort.Slice(found, func(i, j int) bool {
+		a, b := found[i], found[j]
+		if a.score != b.score {
+			return a.score > b.score
+		}
+		if len(a.path) != len(b.path) {
+			return len(a.path) < len(b.path)
+		}
+		return a.path < b.path
+	})
+
+	out := make([]string, 0, min(limit, len(found)))
+	for i := 0; i < len(found) && i < limit; i++ {
+		out = append(out, found[i].path)
+	}
+	return out
+}
+
+// fuzzyScore matches query against path like fuzzy.Score, preferring
+// matches in the file name over the directories.
+func fuzzyScore(query, path string) (int, bool) {
+	score, ok := fuzzy.Score(query, path)
+	if !ok || query == "" {
+		return score, ok
+	}
+	if strings.Contains(strings.ToLower(filepath.Base(path)), strings.ToLower(query)) {
+		score += 10
+	}
+	return score, true
+}
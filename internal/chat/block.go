@@ -3,6 +3,8 @@ package chat
 
 import (
 	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/charmbracelet/lipgloss"
 )
@@ -16,6 +18,9 @@ const (
 
 	// TypeCode is a code block with syntax.
 	TypeCode
+
+	// TypeContext is an attached file or other context.
+	TypeContext
 )
 
 // Block is the interface for all content block types.
@@ -36,89 +41,134 @@ func (b *TextBlock) Kind() BlockType {
 
 // Render renders the text block with word wrapping.
 func (b *TextBlock) Render(width int) string {
+	return b.render(width, nil, highlightStyles{})
+}
+
+// render renders the text block with word wrapping and search highlights.
+func (b *TextBlock) render(width int, hls []Highlight, st highlightStyles) string {
 	if width <= 0 {
 		return ""
 	}
 
-	var out strings.Builder
-	lines := strings.Split(b.Text, "\n")
+	lines := wrapSpans(b.Text, width)
+	out := make([]string, len(lines))
 	for i, line := range lines {
-		if i > 0 {
-			out.WriteString("\n")
-		}
-		out.WriteString(wrapPlainText(line, width))
+		out[i] = renderSpans(b.Text, line, hls, st)
 	}
-	return out.String()
+	return strings.Join(out, "\n")
 }
 
-func wrapPlainText(s string, width int) string {
-	words := strings.Fields(s)
-	if len(words) == 0 {
-		return ""
+// span is a byte range [start, end) of a block's source text.
+type span struct {
+	start, end int
+}
+
+// wrapSpans word-wraps text to width and returns, for every output line,
+// the source ranges of the words on it. Whitespace between words collapses
+// to a single space; hard newlines always start a new line. Keeping source
+// offsets lets search matches be mapped onto wrapped lines at any width.
+func wrapSpans(text string, width int) [][]span {
+	var lines [][]span
+	offset := 0
+	for _, para := range strings.Split(text, "\n") {
+		lines = append(lines, wrapParagraph(para, offset, width)...)
+		offset += len(para) + 1
 	}
+	return lines
+}
 
-	var out strings.Builder
+// wrapParagraph wraps a single line of text starting at offset in the source.
+func wrapParagraph(para string, offset, width int) [][]span {
+	var lines [][]span
+	var cur []span
 	lineW := 0
 
-	writeWord := func(w string) {
-		if lineW > 0 {
-			out.WriteByte(' ')
-			lineW++
-		}
-		out.WriteString(w)
-		lineW += lipgloss.Width(w)
-	}
-
-	newline := func() {
-		out.WriteByte('\n')
+	flush := func() {
+		lines = append(lines, cur)
+		cur = nil
 		lineW = 0
 	}
 
-	for _, w := range words {
-		wW := lipgloss.Width(w)
+	for _, w := range words(para, offset) {
+		word := para[w.start-offset : w.end-offset]
+		wW := lipgloss.Width(word)
+
 		if wW > width {
 			if lineW != 0 {
-				newline()
+				flush()
 			}
-			r := []rune(w)
-			start := 0
-			for start < len(r) {
-				end := start
-				segW := 0
-				for end < len(r) {
-					cw := lipgloss.Width(string(r[end]))
-					if segW+cw > width {
+			// Split the long word into chunks that fit the width
+			pos := w.start
+			for pos < w.end {
+				end, segW := pos, 0
+				for end < w.end {
+					_, size := utf8.DecodeRuneInString(para[end-offset:])
+					cw := lipgloss.Width(para[end-offset : end-offset+size])
+					if segW+cw > width && end > pos {
 						break
 					}
 					segW += cw
-					end += 1
-				}
-				if end == start {
-					end = start + 1
+					end += size
 				}
-				out.WriteString(string(r[start:end]))
-				start = end
-				if start < len(r) {
-					newline()
+				cur = append(cur, span{pos, end})
+				lineW = segW
+				pos = end
+				if pos < w.end {
+					flush()
 				}
 			}
 			continue
 		}
 
-		if lineW == 0 {
-			writeWord(w)
-			continue
+		if lineW != 0 && lineW+1+wW > width {
+			flush()
+		}
+		if lineW != 0 {
+			lineW++
 		}
+		cur = append(cur, w)
+		lineW += wW
+	}
 
-		if lineW+1+wW <= width {
-			writeWord(w)
+	flush()
+	return lines
+}
+
+// words returns the source ranges of whitespace-separated words in s.
+func words(s string, offset int) []span {
+	var out []span
+	start := -1
+	for i, r := range s {
+		if unicode.IsSpace(r) {
+			if start >= 0 {
+				out = append(out, span{offset + start, offset + i})
+				start = -1
+			}
 			continue
 		}
-
-		newline()
-		writeWord(w)
+		if start < 0 {
+			start = i
+		}
+	}
+	if start >= 0 {
+		out = append(out, span{offset + start, offset + len(s)})
 	}
+	return out
+}
 
+// renderSpans renders one wrapped line, joining words with single spaces.
+func renderSpans(src string, line []span, hls []Highlight, st highlightStyles) string {
+	var out strings.Builder
+	for i, sp := range line {
+		if i > 0 {
+			gap := " "
+			if h, ok := covering(hls, line[i-1].end, sp.start); ok {
+				gap = st.style(h).Render(gap)
+			}
+			out.WriteString(gap)
+		}
+		out.WriteString(highlightRange(src, sp.start, sp.end, hls, st))
+	}
 	return out.String()
 }
 
@@ -136,6 +186,11 @@ func (b *CodeBlock) Kind() BlockType {
 
 // Render renders the code block with syntax highlighting placeholder.
 func (b *CodeBlock) Render(width int) string {
+	return b.render(width, nil, highlightStyles{})
+}
+
+// render renders the code block with search highlights.
+func (b *CodeBlock) render(width int, hls []Highlight, st highlightStyles) string {
 	// TODO: Implement proper code block rendering
 	// TODO: Add syntax highlighting
 	var sb strings.Builder
@@ -151,13 +206,24 @@ func (b *CodeBlock) Render(width int) string {
 		sb.WriteString(b.Lang)
 		sb.WriteString("\n")
 	}
+	content := b.Content()
+	offset := 0
 	for _, line := range b.Lines {
-		sb.WriteString(line)
+		sb.WriteString(highlightRange(content, offset, offset+len(line), hls, st))
 		sb.WriteString("\n")
+		offset += len(line) + 1
 	}
 	return sb.String()
 }
 
+// headerLines returns the number of rendered lines before the first code line.
+func (b *CodeBlock) headerLines() int {
+	if b.Lang != "" {
+		return 1
+	}
+	return 0
+}
+
 // Content returns the full content of the code block.
 func (b *CodeBlock) Content() string {
 	return strings.Join(b.Lines, "\n")
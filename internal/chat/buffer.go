@@ -5,6 +5,10 @@ import (
 	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/fingergohappy/vai/internal/theme"
 )
 
 // Model is the chat buffer Bubble Tea Model.
@@ -19,6 +23,9 @@ type Model struct {
 	// CursorLine is the current cursor line position.
 	CursorLine int
 
+	// CursorCol is the cursor column on the cursor line, in characters.
+	CursorCol int
+
 	// Selection holds VISUAL mode selection state.
 	Selection Selection
 
@@ -31,6 +38,17 @@ type Model struct {
 	// messageRenderer handles rendering of individual messages.
 	messageRenderer *ChatMessage
 
+	// search holds the last search pattern and its matches.
+	search Search
+
+	// focused shows the cursor line while the buffer has focus.
+	focused bool
+
+	// system is the system prompt pinned above the messages, shown in
+	// full when systemExpanded is set.
+	system         string
+	systemExpanded bool
+
 	// Ready indicates if the model is initialized.
 	ready bool
 }
@@ -42,24 +60,14 @@ type Selection struct {
 	End    int // End line
 }
 
-// NewModel creates a new chat buffer model with mock data for visual testing.
+// NewModel creates an empty chat buffer model.
 func NewModel() Model {
 	return Model{
-		Messages: []Message{
-			NewMessage(RoleAssistant, []Block{
-				NewTextBlock("Hello! How can I help you today?"),
-			}),
-			NewMessage(RoleUser, []Block{
-				NewTextBlock("What is the weather like?"),
-			}),
-			NewMessage(RoleAssistant, []Block{
-				NewTextBlock("I don't have access to real-time weather data, but I can help you with many other tasks!"),
-			}),
-		},
 		ViewportOffset:  0,
 		CursorLine:      0,
 		Selection:       Selection{Active: false},
-		messageRenderer: NewChatMessage(),
+		messageRenderer: NewChatMessage(theme.Default()),
+		search:          Search{Current: -1},
 	}
 }
 
@@ -85,23 +93,101 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
-// View renders the chat buffer with styled messages.
+// View renders the visible part of the chat buffer with styled messages.
+// The system prompt header stays pinned at the top.
 func (m Model) View() string {
+	header := m.systemLines()
+
 	// Handle empty state
 	if len(m.Messages) == 0 {
-		return "  [Chat Buffer]\n\n" +
-			"  Welcome to vai!\n" +
-			"  Start a conversation..."
+		return strings.Join(append(header,
+			"  [Chat Buffer]",
+			"",
+			"  Welcome to vai!",
+			"  Start a conversation..."), "\n")
+	}
+
+	lines := m.renderLines()
+	if m.focused && m.CursorLine < len(lines) {
+		line := lines[m.CursorLine]
+		col := m.Column()
+		if plain := []rune(ansi.Strip(line)); col < len(plain) {
+			line = m.messageRenderer.renderCursor(line, ansi.StringWidth(string(plain[:col])))
+		}
+		lines[m.CursorLine] = m.messageRenderer.renderCursorLine(line)
+	}
+	start := min(m.ViewportOffset, len(lines))
+	end := len(lines)
+	if height := m.viewHeight(); height > 0 {
+		end = min(start+height, len(lines))
 	}
+	return strings.Join(append(header, lines[start:end]...), "\n")
+}
+
+// SetTheme restyles messages, search matches and the cursor line.
+func (m *Model) SetTheme(t theme.Theme) {
+	m.messageRenderer = NewChatMessage(t)
+}
+
+// Focus shows the cursor line.
+func (m *Model) Focus() {
+	m.focused = true
+}
 
-	// Render each message
-	var renderedMessages []string
-	for _, msg := range m.Messages {
-		renderedMessages = append(renderedMessages, m.messageRenderer.Render(msg, m.Width))
+// Blur hides the cursor line.
+func (m *Model) Blur() {
+	m.focused = false
+}
+
+// renderMessages renders every message at the current width.
+func (m Model) renderMessages() []string {
+	rendered := make([]string, len(m.Messages))
+	for i, msg := range m.Messages {
+		rendered[i] = m.messageRenderer.RenderHighlighted(msg, m.Width, func(block int) []Highlight {
+			return m.highlights(i, block)
+		})
 	}
+	return rendered
+}
 
+// renderLines renders the whole buffer and splits it into display lines.
+func (m Model) renderLines() []string {
 	// Join messages with single newline (messages have MarginTop/Bottom)
-	return strings.Join(renderedMessages, "\n")
+	return strings.Split(strings.Join(m.renderMessages(), "\n"), "\n")
+}
+
+// messageStartLines returns the first display line of every message.
+func (m Model) messageStartLines() []int {
+	starts := make([]int, len(m.Messages))
+	line := 0
+	for i, msg := range m.Messages {
+		starts[i] = line
+		line += lipgloss.Height(m.messageRenderer.Render(msg, m.Width))
+	}
+	return starts
+}
+
+// LineCount returns the number of display lines in the buffer.
+func (m Model) LineCount() int {
+	if len(m.Messages) == 0 {
+		return 0
+	}
+	starts := m.messageStartLines()
+	last := len(m.Messages) - 1
+	return starts[last] + lipgloss.Height(m.messageRenderer.Render(m.Messages[last], m.Width))
+}
+
+// scrollToCursor scrolls the viewport so the cursor line is visible,
+// centering it when it was off screen.
+func (m *Model) scrollToCursor() {
+	height := m.viewHeight()
+	if height <= 0 {
+		return
+	}
+	if m.CursorLine >= m.ViewportOffset && m.CursorLine < m.ViewportOffset+height {
+		return
+	}
+	m.ViewportOffset = max(m.CursorLine-height/2, 0)
 }
 
 // SetWidth sets the available width for rendering.
@@ -109,11 +195,13 @@ func (m *Model) SetWidth(width int) {
 	m.Width = width
 }
 
+// SetSize sets the available size for rendering. Search matches are kept
+// in source offsets, so the current match stays in view after re-wrapping.
 func (m *Model) SetSize(width, height int) {
-
 	m.Width = width
 	m.Height = height
 	m.ready = true
+	m.gotoCurrentMatch()
 }
 
 // AddMessage adds a new message to the chat buffer.
@@ -121,9 +209,45 @@ func (m *Model) AddMessage(msg Message) {
 	m.Messages = append(m.Messages, msg)
 }
 
+// SetMessages replaces the conversation and resets the cursor and search.
+func (m *Model) SetMessages(messages []Message) {
+	m.Messages = messages
+	m.ViewportOffset = 0
+	m.CursorLine = 0
+	m.CursorCol = 0
+	m.Selection = Selection{}
+	m.ClearSearch()
+}
+
+// Markdown returns the conversation as a markdown transcript, starting
+// with the system prompt if there is one.
+func (m Model) Markdown() string {
+	var sb strings.Builder
+	if m.system != "" {
+		sb.WriteString("## System\n\n" + m.system)
+		if len(m.Messages) > 0 {
+			sb.WriteString("\n\n")
+		}
+	}
+	for i, msg := range m.Messages {
+		if i > 0 {
+			sb.WriteString("\n\n")
+		}
+		switch msg.Role {
+		case RoleUser:
+			sb.WriteString("## You\n\n")
+		default:
+			sb.WriteString("## AI\n\n")
+		}
+		sb.WriteString(msg.Text())
+	}
+	sb.WriteString("\n")
+	return sb.String()
+}
+
 // ScrollDown scrolls the buffer down by one line.
 func (m *Model) ScrollDown() {
-	if m.ViewportOffset < len(m.Messages)-1 {
+	if m.ViewportOffset < m.LineCount()-1 {
 		m.ViewportOffset++
 	}
 }
@@ -0,0 +1,193 @@
+package chat
+
+import (
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// The cursor column counts characters of the displayed line, message
+// borders included, but motions keep it on the text between them. Words
+// are runs of letters, digits and underscores, the characters a search
+// pattern's \b sees as word characters.
+
+// plainLines returns the display lines without styles, as runes.
+func (m Model) plainLines() [][]rune {
+	lines := m.renderLines()
+	out := make([][]rune, len(lines))
+	for i, line := range lines {
+		out[i] = []rune(ansi.Strip(line))
+	}
+	return out
+}
+
+// cursorText returns the cursor line without styles, or nil.
+func (m Model) cursorText() []rune {
+	lines := m.plainLines()
+	if m.CursorLine < 0 || m.CursorLine >= len(lines) {
+		return nil
+	}
+	return lines[m.CursorLine]
+}
+
+// isFrame reports whether r is padding or part of a message border.
+func isFrame(r rune) bool {
+	return unicode.IsSpace(r) || (r >= 0x2500 && r <= 0x257f) // box drawing
+}
+
+// isWordChar reports whether r is part of a word.
+func isWordChar(r rune) bool {
+	return r == '_' || r < utf8.RuneSelf && (unicode.IsLetter(r) || unicode.IsDigit(r))
+}
+
+// isBorderLine reports whether line is the top or bottom border of a
+// message, whose title is not text.
+func isBorderLine(line []rune) bool {
+	for _, r := range line {
+		if !unicode.IsSpace(r) {
+			return r == '┌' || r == '└'
+		}
+	}
+	return false
+}
+
+// textBounds returns the first and last columns of the text on a display
+// line; ok is false for a line with only borders and padding.
+func textBounds(line []rune) (first, last int, ok bool) {
+	if isBorderLine(line) {
+		return 0, 0, false
+	}
+	first, last = 0, len(line)-1
+	for first <= last && isFrame(line[first]) {
+		first++
+	}
+	for last >= first && isFrame(line[last]) {
+		last--
+	}
+	return first, last, first <= last
+}
+
+// clampColumn keeps col on the text of line.
+func clampColumn(line []rune, col int) int {
+	first, last, ok := textBounds(line)
+	if !ok {
+		return 0
+	}
+	return max(min(col, last), first)
+}
+
+// Column returns the cursor column on the cursor line. The column is kept
+// when moving to a shorter line, as in Vim, and clamped here.
+func (m Model) Column() int {
+	return clampColumn(m.cursorText(), m.CursorCol)
+}
+
+// MoveColumn moves the cursor n characters right, or left if n is
+// negative, staying on the text of the cursor line (l, h).
+func (m *Model) MoveColumn(n int) {
+	line := m.cursorText()
+	m.CursorCol = clampColumn(line, clampColumn(line, m.CursorCol)+n)
+}
+
+// ColumnStart moves the cursor to the first character of the text on the
+// cursor line (0).
+func (m *Model) ColumnStart() {
+	m.CursorCol, _, _ = textBounds(m.cursorText())
+}
+
+// ColumnEnd moves the cursor to the last character of the text on the
+// cursor line ($).
+func (m *Model) ColumnEnd() {
+	_, last, _ := textBounds(m.cursorText())
+	m.CursorCol = max(last, 0)
+}
+
+// WordForward moves the cursor to the start of the [count]th next word
+// (w), continuing on the lines below.
+func (m *Model) WordForward(count int) {
+	m.wordMotion(count, 1, func(line []rune, i int) bool {
+		return isWordChar(line[i]) && (i == 0 || !isWordChar(line[i-1]))
+	})
+}
+
+// WordBackward moves the cursor to the start of the [count]th previous
+// word (b), continuing on the lines above.
+func (m *Model) WordBackward(count int) {
+	m.wordMotion(count, -1, func(line []rune, i int) bool {
+		return isWordChar(line[i]) && (i == 0 || !isWordChar(line[i-1]))
+	})
+}
+
+// WordEnd moves the cursor to the end of the [count]th word (e).
+func (m *Model) WordEnd(count int) {
+	m.wordMotion(count, 1, func(line []rune, i int) bool {
+		return isWordChar(line[i]) && (i == len(line)-1 || !isWordChar(line[i+1]))
+	})
+}
+
+// wordMotion moves the cursor count times to the next position in
+// direction dir (1 or -1) where at is true. It stops at the last such
+// position if there are fewer than count.
+func (m *Model) wordMotion(count, dir int, at func(line []rune, i int) bool) {
+	lines := m.plainLines()
+	if m.CursorLine < 0 || m.CursorLine >= len(lines) {
+		return
+	}
+	row, col := m.CursorLine, clampColumn(lines[m.CursorLine], m.CursorCol)
+	for i, line := range lines {
+		if isBorderLine(line) {
+			lines[i] = nil
+		}
+	}
+	for range max(count, 1) {
+		r, c, ok := scanLines(lines, row, col, dir, at)
+		if !ok {
+			break
+		}
+		row, col = r, c
+	}
+	m.CursorCol = col
+	m.setCursor(row)
+}
+
+// scanLines returns the first position after (row, col) in direction dir
+// where at is true.
+func scanLines(lines [][]rune, row, col, dir int, at func(line []rune, i int) bool) (int, int, bool) {
+	col += dir
+	for row >= 0 && row < len(lines) {
+		for ; col >= 0 && col < len(lines[row]); col += dir {
+			if at(lines[row], col) {
+				return row, col, true
+			}
+		}
+		row += dir
+		if row >= 0 && row < len(lines) {
+			col = 0
+			if dir < 0 {
+				col = len(lines[row]) - 1
+			}
+		}
+	}
+	return 0, 0, false
+}
+
+// wordAt returns the word at col in line or, if there is none, the next
+// word after col, as Vim's * picks it.
+func wordAt(line []rune, col int) string {
+	i := max(col, 0)
+	for i < len(line) && !isWordChar(line[i]) {
+		i++
+	}
+	if i >= len(line) {
+		return ""
+	}
+	start, end := i, i
+	for start > 0 && isWordChar(line[start-1]) {
+		start--
+	}
+	for end < len(line) && isWordChar(line[end]) {
+		end++
+	}
+	return string(line[start:end])
+}
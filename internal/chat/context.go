@@ -0,0 +1,88 @@
+package chat
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ContextBlock is a file or other text attached to a user message as
+// context. It is shown collapsed to a single line until expanded and is
+// sent to the provider as fenced code labelled with its path.
+type ContextBlock struct {
+	Path     string   // File path or label (e.g. "stdin")
+	Lang     string   // Language identifier for the fence
+	Lines    []string // Attached content split by lines
+	Expanded bool     // Show the content instead of the summary line
+}
+
+// NewContextBlock creates a collapsed context block.
+func NewContextBlock(path, lang string, lines []string) *ContextBlock {
+	return &ContextBlock{Path: path, Lang: lang, Lines: lines}
+}
+
+// Kind returns the block type.
+func (b *ContextBlock) Kind() BlockType {
+	return TypeContext
+}
+
+// Render renders the summary line, followed by the content when expanded.
+func (b *ContextBlock) Render(width int) string {
+	return b.render(lipgloss.NewStyle().Faint(true))
+}
+
+// render renders the block with the summary line in the given style.
+func (b *ContextBlock) render(style lipgloss.Style) string {
+	marker := "▸"
+	if b.Expanded {
+		marker = "▾"
+	}
+	lines := "lines"
+	if len(b.Lines) == 1 {
+		lines = "line"
+	}
+	summary := fmt.Sprintf("%s @%s (%d %s)", marker, b.Path, len(b.Lines), lines)
+	if !b.Expanded {
+		return style.Render(summary)
+	}
+	return style.Render(summary) + "\n" + b.Content()
+}
+
+// Content returns the attached text.
+func (b *ContextBlock) Content() string {
+	return strings.Join(b.Lines, "\n")
+}
+
+// Fenced returns the block as sent to the provider: the path followed by
+// the content in a fenced code block.
+func (b *ContextBlock) Fenced() string {
+	fence := "```"
+	for strings.Contains(b.Content(), fence) {
+		fence += "`"
+	}
+	return b.Path + ":\n" + fence + b.Lang + "\n" + b.Content() + "\n" + fence
+}
+
+// ToggleContext expands or collapses the context block under the cursor.
+// It returns false if the cursor is not on a context block.
+func (m *Model) ToggleContext() bool {
+	starts := m.messageStartLines()
+	width := innerMaxWidth(m.Width)
+	for mi, msg := range m.Messages {
+		for bi, block := range msg.Blocks {
+			ctx, ok := block.(*ContextBlock)
+			if !ok {
+				continue
+			}
+			start := starts[mi] + m.messageRenderer.blockLine(msg, m.Width, bi, 0)
+			end := start + lipgloss.Height(ctx.Render(width)) - 1
+			if m.CursorLine >= start && m.CursorLine <= end {
+				ctx.Expanded = !ctx.Expanded
+				m.setCursor(start)
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -1,7 +1,10 @@
 // Package chat provides the chat buffer component for displaying messages.
 package chat
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 // Role represents the role of a message sender.
 type Role string
@@ -32,6 +35,33 @@ func NewMessage(role Role, blocks []Block) Message {
 	}
 }
 
+// Text returns the message content as plain text, with code blocks fenced.
+func (m Message) Text() string {
+	parts := make([]string, 0, len(m.Blocks))
+	for _, block := range m.Blocks {
+		switch b := block.(type) {
+		case *TextBlock:
+			parts = append(parts, b.Text)
+		case *CodeBlock:
+			parts = append(parts, "```"+b.Lang+"\n"+b.Content()+"\n```")
+		case *ContextBlock:
+			parts = append(parts, b.Fenced())
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// CodeBlocks returns the code blocks of the message in order.
+func (m Message) CodeBlocks() []*CodeBlock {
+	var blocks []*CodeBlock
+	for _, block := range m.Blocks {
+		if b, ok := block.(*CodeBlock); ok {
+			blocks = append(blocks, b)
+		}
+	}
+	return blocks
+}
+
 // generateID generates a unique ID for a message.
 // TODO: Implement proper ID generation (UUID or similar).
 func generateID() string {
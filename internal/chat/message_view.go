@@ -5,6 +5,9 @@ import (
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/fingergohappy/vai/internal/theme"
 )
 
 // ChatMessage handles rendering of individual chat messages with role-based styling.
@@ -20,18 +23,26 @@ type ChatMessage struct {
 	aiLabel     lipgloss.Style
 	aiContent   lipgloss.Style
 	aiContainer lipgloss.Style
+
+	// Styles for search matches
+	highlight highlightStyles
+
+	// Styles for attachment summaries, the cursor line and the cursor
+	context    lipgloss.Style
+	cursorLine lipgloss.Style
+	cursor     lipgloss.Style
 }
 
-// NewChatMessage creates a new ChatMessage renderer with predefined styles.
-func NewChatMessage() *ChatMessage {
-	userBorderStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("142"))
+// NewChatMessage creates a new ChatMessage renderer with the colors of a theme.
+func NewChatMessage(t theme.Theme) *ChatMessage {
+	userBorderStyle := lipgloss.NewStyle().Foreground(t.Color(theme.User))
 
 	userLabelStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("142")).
+		Foreground(t.Color(theme.User)).
 		Bold(true)
 
 	userContentStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("244"))
+		Foreground(t.Color(theme.UserText))
 
 	userContainerStyle := lipgloss.NewStyle().
 		Width(0). // Will be set dynamically
@@ -39,14 +50,14 @@ func NewChatMessage() *ChatMessage {
 		MarginTop(1).   // Add top margin
 		MarginBottom(1) // Add bottom margin
 
-	aiBorderStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("33"))
+	aiBorderStyle := lipgloss.NewStyle().Foreground(t.Color(theme.Assistant))
 
 	aiLabelStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("33")).
+		Foreground(t.Color(theme.Assistant)).
 		Bold(true)
 
 	aiContentStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("252"))
+		Foreground(t.Color(theme.AssistText))
 
 	aiContainerStyle := lipgloss.NewStyle().
 		Width(0). // Will be set dynamically
@@ -63,24 +74,130 @@ func NewChatMessage() *ChatMessage {
 		aiLabel:       aiLabelStyle,
 		aiContent:     aiContentStyle,
 		aiContainer:   aiContainerStyle,
+		highlight: highlightStyles{
+			match: lipgloss.NewStyle().
+				Foreground(t.Color(theme.SearchFg)).
+				Background(t.Color(theme.SearchBg)),
+			current: lipgloss.NewStyle().
+				Foreground(t.Color(theme.SearchFg)).
+				Background(t.Color(theme.SearchCurrentBg)).
+				Bold(true),
+		},
+		context:    lipgloss.NewStyle().Foreground(t.Color(theme.Muted)),
+		cursorLine: lipgloss.NewStyle().Background(t.Color(theme.CursorLine)),
+		cursor:     lipgloss.NewStyle().Reverse(true),
 	}
 }
 
 // Render renders a message with appropriate styling based on its role.
 func (cm *ChatMessage) Render(msg Message, maxWidth int) string {
+	return cm.RenderHighlighted(msg, maxWidth, nil)
+}
+
+// RenderHighlighted renders a message, painting the search highlights
+// returned by hl for each block index. hl may be nil.
+func (cm *ChatMessage) RenderHighlighted(msg Message, maxWidth int, hl func(block int) []Highlight) string {
+	blocks := cm.renderBlocks(msg, innerMaxWidth(maxWidth), hl)
 	switch msg.Role {
 	case RoleUser:
-		return cm.renderUserMessage(msg, maxWidth)
+		return cm.renderUserMessage(blocks, maxWidth)
 	case RoleAssistant:
-		return cm.renderAssistantMessage(msg, maxWidth)
+		return cm.renderAssistantMessage(blocks, maxWidth)
 	default:
-		return cm.renderAssistantMessage(msg, maxWidth)
+		return cm.renderAssistantMessage(blocks, maxWidth)
+	}
+}
+
+// renderBlocks renders each block of a message at the given content width.
+func (cm *ChatMessage) renderBlocks(msg Message, width int, hl func(block int) []Highlight) []string {
+	blocks := make([]string, 0, len(msg.Blocks))
+	for i, block := range msg.Blocks {
+		var hls []Highlight
+		if hl != nil {
+			hls = hl(i)
+		}
+		switch b := block.(type) {
+		case *TextBlock:
+			blocks = append(blocks, b.render(width, hls, cm.highlight))
+		case *CodeBlock:
+			blocks = append(blocks, b.render(width, hls, cm.highlight))
+		case *ContextBlock:
+			blocks = append(blocks, b.render(cm.context))
+		default:
+			blocks = append(blocks, block.Render(width))
+		}
+	}
+	return blocks
+}
+
+// blockLine returns the line, relative to the top of the rendered message,
+// on which the given source offset of a block is displayed. Heights are
+// measured as the bubble wraps them, so long code lines count every row.
+func (cm *ChatMessage) blockLine(msg Message, maxWidth, blockIdx, offset int) int {
+	width := innerMaxWidth(maxWidth)
+
+	// Top margin and top border
+	line := 2
+	for i := 0; i < blockIdx; i++ {
+		line += wrappedHeight(msg.Blocks[i].Render(width), width)
+	}
+
+	switch b := msg.Blocks[blockIdx].(type) {
+	case *TextBlock:
+		for i, spans := range wrapSpans(b.Text, width) {
+			if len(spans) > 0 && spans[len(spans)-1].end > offset {
+				return line + i
+			}
+		}
+	case *CodeBlock:
+		line += b.headerLines()
+		pos := 0
+		for _, l := range b.Lines {
+			if offset <= pos+len(l) {
+				// Wrap up to the end of the word so it lands on the same row
+				end := offset - pos
+				for end < len(l) && l[end] != ' ' {
+					end++
+				}
+				return line + wrappedHeight(l[:end], width) - 1
+			}
+			line += wrappedHeight(l, width)
+			pos += len(l) + 1
+		}
 	}
+	return line
 }
 
-// renderUserMessage renders a user message with green border, right-aligned.
-func (cm *ChatMessage) renderUserMessage(msg Message, maxWidth int) string {
-	boxed := boxedMessage("You", msg, maxWidth, lipgloss.Color("142"))
+// wrappedHeight returns the number of lines s takes inside a message bubble,
+// which wraps anything wider than width.
+func wrappedHeight(s string, width int) int {
+	return lipgloss.Height(lipgloss.NewStyle().Width(width).Render(s))
+}
+
+// renderCursorLine paints the cursor line background under a rendered line.
+// The background is restored after every reset, so search highlights on the
+// line stay visible.
+func (cm *ChatMessage) renderCursorLine(line string) string {
+	const reset = "\x1b[0m"
+	on, off, ok := strings.Cut(cm.cursorLine.Render(" "), " ")
+	if !ok || on == "" {
+		return line
+	}
+	return on + strings.ReplaceAll(line, reset, reset+on) + off
+}
+
+// renderCursor paints the cursor on the cell at col of a rendered line.
+func (cm *ChatMessage) renderCursor(line string, col int) string {
+	cell := ansi.Strip(ansi.Cut(line, col, col+1))
+	if cell == "" {
+		return line
+	}
+	return ansi.Truncate(line, col, "") + cm.cursor.Render(cell) + ansi.TruncateLeft(line, col+1, "")
+}
+
+// renderUserMessage renders a user message with the user border, right-aligned.
+func (cm *ChatMessage) renderUserMessage(blocks []string, maxWidth int) string {
+	boxed := boxedMessage("You", blocks, maxWidth, cm.userBorder.GetForeground())
 	return cm.userContainer.Width(maxWidth).Render(boxed)
 }
 
@@ -89,28 +206,42 @@ func bubbleMaxWidth(paneWidth int) int {
 	if w < 30 {
 		w = 30
 	}
+	// The border is drawn outside the bubble width; never overflow the pane,
+	// or the container re-wraps the box and breaks line mapping.
+	if w > paneWidth-bubbleFrameX {
+		w = paneWidth - bubbleFrameX
+	}
 	return w
 }
 
-func boxedMessage(title string, msg Message, maxPaneWidth int, borderColor lipgloss.Color) string {
+// innerMaxWidth returns the widest content a message bubble can hold.
+func innerMaxWidth(maxPaneWidth int) int {
 	maxBubbleWidth := bubbleMaxWidth(maxPaneWidth)
 	if maxBubbleWidth < 10 {
 		maxBubbleWidth = 10
 	}
 
-	padX := 1
-	frameX := 2
-
-	innerMaxWidth := maxBubbleWidth - frameX - padX*2
-	if innerMaxWidth < 1 {
-		innerMaxWidth = 1
+	w := maxBubbleWidth - bubbleFrameX - bubblePadX*2
+	if w < 1 {
+		w = 1
 	}
+	return w
+}
+
+const (
+	bubblePadX   = 1
+	bubbleFrameX = 2
+)
 
-	var blocks []string
-	for _, block := range msg.Blocks {
-		blocks = append(blocks, block.Render(innerMaxWidth))
+func boxedMessage(title string, blocks []string, maxPaneWidth int, borderColor lipgloss.TerminalColor) string {
+	maxBubbleWidth := bubbleMaxWidth(maxPaneWidth)
+	if maxBubbleWidth < 10 {
+		maxBubbleWidth = 10
 	}
 
+	padX := bubblePadX
+	frameX := bubbleFrameX
+
 	content := strings.Join(blocks, "\n")
 
 	contentWidth, _ := lipgloss.Size(content)
@@ -164,8 +295,8 @@ func boxedMessage(title string, msg Message, maxPaneWidth int, borderColor lipgl
 	return border.Render(inner)
 }
 
-// renderAssistantMessage renders an AI message with blue border, left-aligned.
-func (cm *ChatMessage) renderAssistantMessage(msg Message, maxWidth int) string {
-	boxed := boxedMessage("AI", msg, maxWidth, lipgloss.Color("33"))
+// renderAssistantMessage renders an AI message with the assistant border, left-aligned.
+func (cm *ChatMessage) renderAssistantMessage(blocks []string, maxWidth int) string {
+	boxed := boxedMessage("AI", blocks, maxWidth, cm.aiBorder.GetForeground())
 	return cm.aiContainer.Render(boxed)
 }
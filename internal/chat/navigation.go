@@ -0,0 +1,150 @@
+package chat
+
+import (
+	"sort"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// blockPos is the display position of a code block.
+type blockPos struct {
+	message int // Index into Model.Messages
+	block   int // Index into Message.Blocks
+	start   int // First display line (the [n] header)
+	end     int // Last display line
+}
+
+// codeBlockPositions returns the display lines of every code block, in order.
+func (m Model) codeBlockPositions() []blockPos {
+	var out []blockPos
+	starts := m.messageStartLines()
+	width := innerMaxWidth(m.Width)
+	for mi, msg := range m.Messages {
+		for bi, block := range msg.Blocks {
+			code, ok := block.(*CodeBlock)
+			if !ok {
+				continue
+			}
+			start := starts[mi] + m.messageRenderer.blockLine(msg, m.Width, bi, 0) - code.headerLines()
+			height := lipgloss.Height(code.Render(width))
+			out = append(out, blockPos{message: mi, block: bi, start: start, end: start + height - 1})
+		}
+	}
+	return out
+}
+
+// MoveCursor moves the cursor by n lines (negative moves up), scrolling
+// the viewport to keep it visible.
+func (m *Model) MoveCursor(n int) {
+	m.setCursor(m.CursorLine + n)
+}
+
+// setCursor moves the cursor to line, clamped to the buffer.
+func (m *Model) setCursor(line int) {
+	last := m.LineCount() - 1
+	m.CursorLine = max(min(line, last), 0)
+	m.scrollToCursor()
+}
+
+// GotoTop moves the cursor to the first line (gg), or to line n if n > 0.
+func (m *Model) GotoTop(n int) {
+	m.setCursor(max(n-1, 0))
+}
+
+// GotoBottom moves the cursor to the last line (G), or to line n if n > 0.
+func (m *Model) GotoBottom(n int) {
+	if n > 0 {
+		m.setCursor(n - 1)
+		return
+	}
+	m.setCursor(m.LineCount() - 1)
+}
+
+// ScrollLines scrolls the viewport by n lines (Ctrl-e, Ctrl-y), keeping the
+// cursor on screen.
+func (m *Model) ScrollLines(n int) {
+	height := m.viewHeight()
+	maxOffset := max(m.LineCount()-height, 0)
+	m.ViewportOffset = max(min(m.ViewportOffset+n, maxOffset), 0)
+	if m.CursorLine < m.ViewportOffset {
+		m.CursorLine = m.ViewportOffset
+	}
+	if height > 0 && m.CursorLine >= m.ViewportOffset+height {
+		m.CursorLine = m.ViewportOffset + height - 1
+	}
+}
+
+// ScrollPages scrolls by a fraction of the viewport height and moves the
+// cursor along: 1 is a full page (Ctrl-f), 0.5 half a page (Ctrl-d).
+func (m *Model) ScrollPages(pages float64) {
+	n := int(float64(max(m.viewHeight(), 1)) * pages)
+	if n == 0 {
+		n = 1
+		if pages < 0 {
+			n = -1
+		}
+	}
+	m.ScrollLines(n)
+	m.setCursor(m.CursorLine + n)
+}
+
+// NextCodeBlock moves the cursor to the header of the n-th next code block (]c).
+func (m *Model) NextCodeBlock(n int) bool {
+	blocks := m.codeBlockPositions()
+	i := sort.Search(len(blocks), func(i int) bool { return blocks[i].start > m.CursorLine })
+	i += max(n, 1) - 1
+	if i >= len(blocks) {
+		return false
+	}
+	m.setCursor(blocks[i].start)
+	return true
+}
+
+// PrevCodeBlock moves the cursor to the header of the n-th previous code block ([c).
+func (m *Model) PrevCodeBlock(n int) bool {
+	blocks := m.codeBlockPositions()
+	i := sort.Search(len(blocks), func(i int) bool { return blocks[i].start >= m.CursorLine }) - 1
+	i -= max(n, 1) - 1
+	if i < 0 {
+		return false
+	}
+	m.setCursor(blocks[i].start)
+	return true
+}
+
+// CurrentMessage returns the message under the cursor.
+func (m *Model) CurrentMessage() *Message {
+	if len(m.Messages) == 0 {
+		return nil
+	}
+	starts := m.messageStartLines()
+	i := sort.Search(len(starts), func(i int) bool { return starts[i] > m.CursorLine }) - 1
+	return &m.Messages[max(i, 0)]
+}
+
+// CurrentCodeBlock returns the code block under the cursor, or else the
+// nearest one above it, or else the first one below it.
+func (m *Model) CurrentCodeBlock() *CodeBlock {
+	blocks := m.codeBlockPositions()
+	if len(blocks) == 0 {
+		return nil
+	}
+	i := sort.Search(len(blocks), func(i int) bool { return blocks[i].start > m.CursorLine }) - 1
+	pos := blocks[max(i, 0)]
+	return m.Messages[pos.message].Blocks[pos.block].(*CodeBlock)
+}
+
+// CodeBlock returns the code block numbered n in the message under the
+// cursor, or nil if there is none.
+func (m *Model) CodeBlock(n int) *CodeBlock {
+	msg := m.CurrentMessage()
+	if msg == nil {
+		return nil
+	}
+	for _, code := range msg.CodeBlocks() {
+		if code.Number == n {
+			return code
+		}
+	}
+	return nil
+}
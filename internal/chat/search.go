@@ -0,0 +1,270 @@
+// Package chat provides the chat buffer component for displaying messages.
+package chat
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Match is a search match in the source text of a block.
+type Match struct {
+	Message int // Index into Model.Messages
+	Block   int // Index into Message.Blocks
+	Start   int // Byte offset of the match start in the block source
+	End     int // Byte offset of the match end in the block source
+}
+
+// Search holds the state of the last buffer search.
+type Search struct {
+	Pattern  string         // Pattern as typed by the user
+	Backward bool           // True for ?pattern, reverses n and N
+	Matches  []Match        // All matches in buffer order
+	Current  int            // Index of the current match, -1 if none
+	re       *regexp.Regexp // Compiled pattern
+}
+
+// Highlight marks a source range of a block to be highlighted.
+type Highlight struct {
+	Start, End int
+	Current    bool
+}
+
+// highlightStyles holds the styles used to paint search matches.
+type highlightStyles struct {
+	match   lipgloss.Style
+	current lipgloss.Style
+}
+
+// style returns the style for a highlight.
+func (s highlightStyles) style(h Highlight) lipgloss.Style {
+	if h.Current {
+		return s.current
+	}
+	return s.match
+}
+
+// blockSource returns the searchable source text of a block.
+func blockSource(b Block) string {
+	switch b := b.(type) {
+	case *TextBlock:
+		return b.Text
+	case *CodeBlock:
+		return b.Content()
+	}
+	return ""
+}
+
+// Search compiles pattern and finds all matches in the buffer. The cursor
+// jumps to the first match after (or, if backward, before) the cursor line.
+func (m *Model) Search(pattern string, backward bool) error {
+	if pattern == "" {
+		if m.search.Pattern == "" {
+			return fmt.Errorf("no previous search pattern")
+		}
+		pattern = m.search.Pattern
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return fmt.Errorf("invalid pattern: %w", err)
+	}
+
+	m.search = Search{Pattern: pattern, Backward: backward, Current: -1, re: re}
+	m.findMatches()
+	if len(m.search.Matches) == 0 {
+		return fmt.Errorf("pattern not found: %s", pattern)
+	}
+
+	m.jumpFromCursor(backward)
+	return nil
+}
+
+// SearchWord searches forward for the word under the cursor, or the next
+// word on the cursor line (*).
+func (m *Model) SearchWord() error {
+	word := wordAt(m.cursorText(), m.Column())
+	if word == "" {
+		return fmt.Errorf("no word under the cursor")
+	}
+	return m.Search(`\b`+regexp.QuoteMeta(word)+`\b`, false)
+}
+
+// NextMatch moves to the next match in the search direction (n).
+func (m *Model) NextMatch() error {
+	return m.stepMatch(m.search.Backward)
+}
+
+// PrevMatch moves to the next match against the search direction (N).
+func (m *Model) PrevMatch() error {
+	return m.stepMatch(!m.search.Backward)
+}
+
+// stepMatch moves the current match one step, wrapping around the buffer.
+func (m *Model) stepMatch(backward bool) error {
+	n := len(m.search.Matches)
+	if m.search.re == nil {
+		return fmt.Errorf("no previous search pattern")
+	}
+	if n == 0 {
+		return fmt.Errorf("pattern not found: %s", m.search.Pattern)
+	}
+
+	if backward {
+		m.search.Current = (m.search.Current - 1 + n) % n
+	} else {
+		m.search.Current = (m.search.Current + 1) % n
+	}
+	m.gotoCurrentMatch()
+	return nil
+}
+
+// ClearSearch removes search highlighting.
+func (m *Model) ClearSearch() {
+	m.search = Search{Current: -1}
+}
+
+// SearchStatus returns the match counter (e.g. "3/17"), or "" with no search.
+func (m Model) SearchStatus() string {
+	if m.search.re == nil || m.search.Current < 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d/%d", m.search.Current+1, len(m.search.Matches))
+}
+
+// findMatches runs the compiled pattern over every block's source text.
+func (m *Model) findMatches() {
+	m.search.Matches = nil
+	for mi, msg := range m.Messages {
+		for bi, block := range msg.Blocks {
+			src := blockSource(block)
+			for _, loc := range m.search.re.FindAllStringIndex(src, -1) {
+				if loc[0] == loc[1] {
+					continue // skip empty matches such as ^ or a*
+				}
+				m.search.Matches = append(m.search.Matches, Match{
+					Message: mi, Block: bi, Start: loc[0], End: loc[1],
+				})
+			}
+		}
+	}
+}
+
+// jumpFromCursor selects the first match after (or before) the cursor line.
+func (m *Model) jumpFromCursor(backward bool) {
+	lines := m.matchLines()
+	n := len(lines)
+
+	if backward {
+		m.search.Current = n - 1
+		for i := n - 1; i >= 0; i-- {
+			if lines[i] < m.CursorLine {
+				m.search.Current = i
+				break
+			}
+		}
+	} else {
+		m.search.Current = sort.Search(n, func(i int) bool { return lines[i] > m.CursorLine })
+		if m.search.Current == n {
+			m.search.Current = 0
+		}
+	}
+	m.gotoCurrentMatch()
+}
+
+// gotoCurrentMatch moves the cursor to the current match and scrolls to it.
+func (m *Model) gotoCurrentMatch() {
+	lines := m.matchLines()
+	if m.search.Current < 0 || m.search.Current >= len(lines) {
+		return
+	}
+	m.CursorLine = lines[m.search.Current]
+	m.CursorCol = m.matchColumn(lines)
+	m.scrollToCursor()
+}
+
+// matchColumn returns the column of the current match on the cursor line,
+// given the line of every match. It finds the match by its order among
+// the matches on that line, and keeps the cursor column if the line does
+// not hold the whole match.
+func (m Model) matchColumn(lines []int) int {
+	nth := 0
+	for i := range m.search.Current {
+		if lines[i] == m.CursorLine {
+			nth++
+		}
+	}
+	text := string(m.cursorText())
+	for _, loc := range m.search.re.FindAllStringIndex(text, -1) {
+		if loc[0] == loc[1] {
+			continue
+		}
+		if nth == 0 {
+			return utf8.RuneCountInString(text[:loc[0]])
+		}
+		nth--
+	}
+	return m.CursorCol
+}
+
+// highlights returns the highlights for a block.
+func (m Model) highlights(msgIdx, blockIdx int) []Highlight {
+	var hls []Highlight
+	for i, match := range m.search.Matches {
+		if match.Message == msgIdx && match.Block == blockIdx {
+			hls = append(hls, Highlight{
+				Start:   match.Start,
+				End:     match.End,
+				Current: i == m.search.Current,
+			})
+		}
+	}
+	return hls
+}
+
+// matchLines returns the rendered buffer line of every match, in match order.
+// Lines are derived from the current width, so they stay correct after resize.
+func (m Model) matchLines() []int {
+	lines := make([]int, len(m.search.Matches))
+	starts := m.messageStartLines()
+	for i, match := range m.search.Matches {
+		msg := m.Messages[match.Message]
+		lines[i] = starts[match.Message] + m.messageRenderer.blockLine(msg, m.Width, match.Block, match.Start)
+	}
+	return lines
+}
+
+// covering returns the first highlight that covers the whole range [start, end).
+func covering(hls []Highlight, start, end int) (Highlight, bool) {
+	for _, h := range hls {
+		if h.Start <= start && h.End >= end {
+			return h, true
+		}
+	}
+	return Highlight{}, false
+}
+
+// highlightRange renders src[start:end], painting the parts covered by hls.
+func highlightRange(src string, start, end int, hls []Highlight, st highlightStyles) string {
+	if len(hls) == 0 {
+		return src[start:end]
+	}
+
+	var out strings.Builder
+	pos := start
+	for _, h := range hls {
+		hs, he := max(h.Start, pos), min(h.End, end)
+		if hs >= he {
+			continue
+		}
+		out.WriteString(src[pos:hs])
+		out.WriteString(st.style(h).Render(src[hs:he]))
+		pos = he
+	}
+	out.WriteString(src[pos:end])
+	return out.String()
+}
@@ -0,0 +1,152 @@
+package chat
+
+import (
+	"strings"
+	"testing"
+)
+
+// testBuffer returns a buffer of the given width holding one assistant
+// message per text.
+func testBuffer(width int, texts ...string) *Model {
+	m := NewModel()
+	var msgs []Message
+	for _, text := range texts {
+		msgs = append(msgs, NewMessage(RoleAssistant, []Block{NewTextBlock(text)}))
+	}
+	m.SetMessages(msgs)
+	m.SetSize(width, 100)
+	return &m
+}
+
+// cursorOn puts the cursor on the first display line containing text, at
+// its start.
+func cursorOn(t *testing.T, m *Model, text string) {
+	t.Helper()
+	for i, line := range m.plainLines() {
+		if col := strings.Index(string(line), text); col >= 0 {
+			m.CursorLine = i
+			m.CursorCol = len([]rune(string(line)[:col]))
+			return
+		}
+	}
+	t.Fatalf("%q is not displayed", text)
+}
+
+// underCursor returns the displayed text from the cursor to the line end.
+func underCursor(m *Model) string {
+	line := m.cursorText()
+	col := m.Column()
+	if col >= len(line) {
+		return ""
+	}
+	return string(line[col:])
+}
+
+func TestWordAt(t *testing.T) {
+	tests := []struct {
+		line string
+		col  int
+		want string
+	}{
+		{"│ foo bar │", 2, "foo"},
+		{"│ foo bar │", 4, "foo"},
+		{"│ foo bar │", 5, "bar"},
+		{"│ foo bar │", 0, "foo"},
+		{"│ x.y_z │", 4, "y_z"},
+		{"│ foo │", 6, ""},
+		{"", 0, ""},
+	}
+	for _, tt := range tests {
+		if got := wordAt([]rune(tt.line), tt.col); got != tt.want {
+			t.Errorf("wordAt(%q, %d) = %q, want %q", tt.line, tt.col, got, tt.want)
+		}
+	}
+}
+
+func TestSearchWord(t *testing.T) {
+	m := testBuffer(80, "first alpha beta", "then beta gamma")
+
+	cursorOn(t, m, "beta")
+	if err := m.SearchWord(); err != nil {
+		t.Fatal(err)
+	}
+	if m.search.Pattern != `\bbeta\b` {
+		t.Errorf("pattern = %q, want the word under the cursor", m.search.Pattern)
+	}
+	if got := underCursor(m); !strings.HasPrefix(got, "beta gamma") {
+		t.Errorf("cursor on %q, want the next beta", got)
+	}
+
+	// Between words, * takes the next word on the line
+	cursorOn(t, m, " alpha")
+	if err := m.SearchWord(); err != nil || m.search.Pattern != `\balpha\b` {
+		t.Errorf("SearchWord() between words = %q, %v, want alpha", m.search.Pattern, err)
+	}
+
+	cursorOn(t, m, "beta")
+	m.ColumnEnd()
+	m.MoveColumn(5)
+	if err := m.SearchWord(); err != nil || m.search.Pattern != `\bbeta\b` {
+		t.Errorf("SearchWord() on the last character = %q, %v, want beta", m.search.Pattern, err)
+	}
+}
+
+func TestMatchesAfterRewrap(t *testing.T) {
+	text := strings.Repeat("filler words to wrap ", 6) + "needle one " +
+		strings.Repeat("more filler text ", 6) + "needle two " + strings.Repeat("tail ", 10)
+	m := testBuffer(100, text)
+	if err := m.Search("needle", false); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.NextMatch(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, width := range []int{100, 60, 40, 100} {
+		m.SetSize(width, 100)
+		// The second needle is the last one displayed
+		row, col := -1, -1
+		for i, line := range m.plainLines() {
+			if j := strings.LastIndex(string(line), "needle"); j >= 0 {
+				row, col = i, len([]rune(string(line)[:j]))
+			}
+		}
+		if m.CursorLine != row || m.Column() != col {
+			t.Errorf("width %d: cursor at %d:%d, want the second needle at %d:%d", width, m.CursorLine, m.Column(), row, col)
+		}
+		if status := m.SearchStatus(); status != "2/2" {
+			t.Errorf("width %d: status %q, want 2/2", width, status)
+		}
+	}
+}
+
+func TestWordMotions(t *testing.T) {
+	tests := []struct {
+		name  string
+		start string
+		move  func(m *Model)
+		want  string
+	}{
+		{"w", "alpha", func(m *Model) { m.WordForward(1) }, "beta."},
+		{"2w", "alpha", func(m *Model) { m.WordForward(2) }, "gamma"},
+		{"w skips punctuation", "beta", func(m *Model) { m.WordForward(1) }, "gamma"},
+		{"w to the next message", "gamma", func(m *Model) { m.WordForward(1) }, "delta"},
+		{"b", "gamma", func(m *Model) { m.WordBackward(1) }, "beta."},
+		{"b to the previous message", "delta", func(m *Model) { m.WordBackward(1) }, "gamma"},
+		{"e", "alpha", func(m *Model) { m.WordEnd(1) }, "a beta."},
+		{"0", "gamma", func(m *Model) { m.ColumnStart() }, "alpha"},
+		{"$", "alpha", func(m *Model) { m.ColumnEnd() }, "a"},
+		{"h at the text start", "alpha", func(m *Model) { m.MoveColumn(-3) }, "alpha"},
+		{"l", "alpha", func(m *Model) { m.MoveColumn(2) }, "pha"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := testBuffer(80, "alpha beta. gamma", "delta")
+			cursorOn(t, m, tt.start)
+			tt.move(m)
+			if got := strings.TrimRight(underCursor(m), " │"); !strings.HasPrefix(got, tt.want) {
+				t.Errorf("cursor on %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
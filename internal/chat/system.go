@@ -0,0 +1,78 @@
+package chat
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// SetSystem sets the system prompt pinned above the conversation. An empty
+// prompt hides the header. The header starts collapsed to one line.
+func (m *Model) SetSystem(text string) {
+	text = strings.TrimSpace(text)
+	if text != m.system {
+		m.systemExpanded = false
+	}
+	m.system = text
+	m.scrollToCursor()
+}
+
+// ToggleSystem expands or collapses the system prompt header. It returns
+// false if there is no system prompt.
+func (m *Model) ToggleSystem() bool {
+	if m.system == "" {
+		return false
+	}
+	m.systemExpanded = !m.systemExpanded
+	m.scrollToCursor()
+	return true
+}
+
+// systemLines renders the system prompt header: a summary line, followed
+// when expanded by the prompt wrapped to the width, at most half the
+// buffer height.
+func (m Model) systemLines() []string {
+	if m.system == "" {
+		return nil
+	}
+	lines := strings.Split(m.system, "\n")
+	marker := "▸"
+	if m.systemExpanded {
+		marker = "▾"
+	}
+	count := "lines"
+	if len(lines) == 1 {
+		count = "line"
+	}
+	summary := fmt.Sprintf("%s System (%d %s): %s", marker, len(lines), count, lines[0])
+	if m.Width > 0 {
+		summary = ansi.Truncate(summary, m.Width, "…")
+	}
+	out := []string{m.messageRenderer.context.Render(summary)}
+	if !m.systemExpanded {
+		return out
+	}
+
+	body := m.system
+	if m.Width > 2 {
+		body = ansi.Wrap(body, m.Width-2, "")
+	}
+	bodyLines := strings.Split(body, "\n")
+	if limit := m.Height / 2; m.Height > 0 && len(bodyLines) > limit {
+		bodyLines = append(bodyLines[:max(limit-1, 0)], "…")
+	}
+	for _, line := range bodyLines {
+		out = append(out, "  "+line)
+	}
+	return out
+}
+
+// viewHeight returns the number of lines left for messages below the
+// system prompt header, or 0 if the height is not known.
+func (m Model) viewHeight() int {
+	if m.Height <= 0 {
+		return 0
+	}
+	return max(m.Height-len(m.systemLines()), 1)
+}
@@ -1,19 +1,106 @@
 // Package clipboard provides cross-platform clipboard operations.
 package clipboard
 
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+)
+
+// Target selects the clipboard to copy to or paste from.
+type Target int
+
+const (
+	// TargetClipboard is the system clipboard ("+ register).
+	TargetClipboard Target = iota
+
+	// TargetPrimary is the X11/Wayland primary selection ("* register).
+	// Backends without a primary selection use the clipboard instead.
+	TargetPrimary
+)
+
 // Clipboard is the interface for clipboard operations.
 type Clipboard interface {
-	// Copy copies text to the clipboard.
-	Copy(text string) error
+	// Copy copies text to the target clipboard.
+	Copy(target Target, text string) error
+
+	// Paste returns the content of the target clipboard.
+	Paste(target Target) (string, error)
 
 	// Available returns true if the clipboard is available.
 	Available() bool
 }
 
-// New returns the appropriate clipboard implementation for the current platform.
-// Platform detection will be done in build files.
-func New() Clipboard {
-	// Try to detect platform and return appropriate implementation
-	// For now, return dummy
+// Backend names accepted by New and the clipboard.backend config option.
+const (
+	BackendAuto    = "auto"
+	BackendPbcopy  = "pbcopy"
+	BackendWayland = "wl-copy"
+	BackendXclip   = "xclip"
+	BackendXsel    = "xsel"
+	BackendOSC52   = "osc52"
+	BackendTmux    = "tmux"
+	BackendNone    = "none"
+)
+
+// Backends lists the accepted backend names. Auto never picks tmux.
+var Backends = []string{
+	BackendAuto, BackendPbcopy, BackendWayland, BackendXclip, BackendXsel, BackendOSC52, BackendTmux, BackendNone,
+}
+
+// New returns the clipboard backend with the given name. An empty name or
+// "auto" detects the backend with Detect.
+func New(backend string) (Clipboard, error) {
+	switch backend {
+	case "", BackendAuto:
+		return Detect(), nil
+	case BackendPbcopy:
+		return NewMacOS(), nil
+	case BackendWayland:
+		return NewWayland(), nil
+	case BackendXclip:
+		return NewXclip(), nil
+	case BackendXsel:
+		return NewXsel(), nil
+	case BackendOSC52:
+		return NewOSC52(), nil
+	case BackendTmux:
+		return NewTmux(), nil
+	case BackendNone:
+		return NewDummy(), nil
+	}
+	return nil, fmt.Errorf("unknown clipboard backend %q", backend)
+}
+
+// Detect returns the first usable backend for the current session:
+//   - pbcopy on macOS
+//   - wl-copy when WAYLAND_DISPLAY is set
+//   - xclip or xsel when DISPLAY is set
+//   - OSC 52 over SSH, inside tmux or screen, or in any other terminal
+//
+// Without a display server the commands cannot reach a clipboard, so
+// remote sessions fall through to OSC 52, which the local terminal
+// handles.
+func Detect() Clipboard {
+	if runtime.GOOS == "darwin" && os.Getenv("SSH_TTY") == "" {
+		if cb := NewMacOS(); cb.Available() {
+			return cb
+		}
+	}
+	for _, cb := range []Clipboard{NewWayland(), NewXclip(), NewXsel()} {
+		if cb.Available() {
+			return cb
+		}
+	}
+	if cb := NewOSC52(); cb.Available() {
+		return cb
+	}
 	return NewDummy()
 }
+
+// hasCommand returns true if name is found in PATH.
+func hasCommand(name string) bool {
+	_, err := exec.LookPath(name)
+	return err == nil
+}
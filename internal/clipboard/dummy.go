@@ -1,7 +1,7 @@
 // Package clipboard provides cross-platform clipboard operations.
 package clipboard
 
-import "fmt"
+import "errors"
 
 // Dummy is a fallback clipboard implementation for systems without clipboard support.
 type Dummy struct{}
@@ -12,10 +12,18 @@ func NewDummy() *Dummy {
 }
 
 // Copy returns an error indicating clipboard is not available.
-func (d *Dummy) Copy(text string) error {
-	return fmt.Errorf("clipboard not available. Please install pbcopy (macOS) or xclip/wl-copy (Linux)")
+func (d *Dummy) Copy(target Target, text string) error {
+	return errNotAvailable
 }
 
+// Paste returns an error indicating clipboard is not available.
+func (d *Dummy) Paste(target Target) (string, error) {
+	return "", errNotAvailable
+}
+
+// errNotAvailable is returned by every Dummy operation.
+var errNotAvailable = errors.New("clipboard not available. Please install pbcopy (macOS) or wl-copy/xclip/xsel (Linux), or set clipboard.backend to osc52")
+
 // Available returns false for the dummy clipboard.
 func (d *Dummy) Available() bool {
 	return false
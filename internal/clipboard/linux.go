@@ -8,53 +8,104 @@ import (
 	"strings"
 )
 
-// Linux is the clipboard implementation for Linux.
+// Linux is the clipboard implementation for Linux and other Unix systems
+// running Wayland or X11. It runs wl-copy/wl-paste, xclip or xsel.
 type Linux struct {
-	wayland bool
+	name    string // command name, for messages and detection
+	display string // environment variable naming the display server
+
+	// args returns the command line copying to (paste false) or pasting
+	// from a target
+	args func(target Target, paste bool) []string
 }
 
-// NewLinux creates a new Linux clipboard instance.
-func NewLinux() *Linux {
-	// Detect Wayland
-	_, err := exec.LookPath("wl-copy")
+// NewWayland creates a clipboard that uses wl-copy and wl-paste.
+func NewWayland() *Linux {
 	return &Linux{
-		wayland: err == nil,
+		name:    "wl-copy",
+		display: "WAYLAND_DISPLAY",
+		args: func(target Target, paste bool) []string {
+			cmd := []string{"wl-copy"}
+			if paste {
+				cmd = []string{"wl-paste", "--no-newline"}
+			}
+			if target == TargetPrimary {
+				cmd = append(cmd, "--primary")
+			}
+			return cmd
+		},
 	}
 }
 
-// Copy copies text to the Linux clipboard.
-func (l *Linux) Copy(text string) error {
-	if !l.Available() {
-		return fmt.Errorf("no clipboard command available")
+// NewXclip creates a clipboard that uses xclip.
+func NewXclip() *Linux {
+	return &Linux{
+		name:    "xclip",
+		display: "DISPLAY",
+		args: func(target Target, paste bool) []string {
+			cmd := []string{"xclip", "-selection", "clipboard"}
+			if target == TargetPrimary {
+				cmd[2] = "primary"
+			}
+			if paste {
+				cmd = append(cmd, "-o")
+			}
+			return cmd
+		},
+	}
+}
+
+// NewXsel creates a clipboard that uses xsel.
+func NewXsel() *Linux {
+	return &Linux{
+		name:    "xsel",
+		display: "DISPLAY",
+		args: func(target Target, paste bool) []string {
+			cmd := []string{"xsel", "--clipboard", "--input"}
+			if target == TargetPrimary {
+				cmd[1] = "--primary"
+			}
+			if paste {
+				cmd[2] = "--output"
+			}
+			return cmd
+		},
 	}
+}
 
-	var cmd *exec.Cmd
-	if l.wayland {
-		cmd = exec.Command("wl-copy")
-	} else {
-		cmd = exec.Command("xclip", "-selection", "clipboard")
+// Copy copies text to the target clipboard.
+func (l *Linux) Copy(target Target, text string) error {
+	if !l.Available() {
+		return fmt.Errorf("%s not available (is $%s set?)", l.name, l.display)
 	}
 
+	args := l.args(target, false)
+	cmd := exec.Command(args[0], args[1:]...)
 	cmd.Stdin = strings.NewReader(text)
 	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("clipboard command failed: %w", err)
+		return fmt.Errorf("%s failed: %w", args[0], err)
 	}
 
 	return nil
 }
 
-// Available returns true if a clipboard command is available.
-func (l *Linux) Available() bool {
-	if l.wayland {
-		return true
+// Paste returns the content of the target clipboard.
+func (l *Linux) Paste(target Target) (string, error) {
+	if !l.Available() {
+		return "", fmt.Errorf("%s not available (is $%s set?)", l.name, l.display)
 	}
 
-	// Check for xclip
-	_, err := exec.LookPath("xclip")
-	return err == nil
+	args := l.args(target, true)
+	out, err := exec.Command(args[0], args[1:]...).Output()
+	if err != nil {
+		return "", fmt.Errorf("%s failed: %w", args[0], err)
+	}
+
+	return string(out), nil
 }
 
-// Detect Wayland session.
-func isWayland() bool {
-	return os.Getenv("WAYLAND_DISPLAY") != ""
+// Available returns true if the command is installed and its display
+// server is reachable.
+func (l *Linux) Available() bool {
+	return os.Getenv(l.display) != "" && hasCommand(l.name)
 }
@@ -7,7 +7,8 @@ import (
 	"strings"
 )
 
-// MacOS is the clipboard implementation for macOS.
+// MacOS is the clipboard implementation for macOS. It has no primary
+// selection, so both targets use the general pasteboard.
 type MacOS struct{}
 
 // NewMacOS creates a new macOS clipboard instance.
@@ -16,7 +17,7 @@ func NewMacOS() *MacOS {
 }
 
 // Copy copies text to the macOS clipboard using pbcopy.
-func (m *MacOS) Copy(text string) error {
+func (m *MacOS) Copy(target Target, text string) error {
 	if !m.Available() {
 		return fmt.Errorf("pbcopy not available")
 	}
@@ -30,8 +31,21 @@ func (m *MacOS) Copy(text string) error {
 	return nil
 }
 
+// Paste returns the content of the macOS clipboard using pbpaste.
+func (m *MacOS) Paste(target Target) (string, error) {
+	if !hasCommand("pbpaste") {
+		return "", fmt.Errorf("pbpaste not available")
+	}
+
+	out, err := exec.Command("pbpaste").Output()
+	if err != nil {
+		return "", fmt.Errorf("pbpaste failed: %w", err)
+	}
+
+	return string(out), nil
+}
+
 // Available returns true if pbcopy is available.
 func (m *MacOS) Available() bool {
-	_, err := exec.LookPath("pbcopy")
-	return err == nil
+	return hasCommand("pbcopy")
 }
@@ -0,0 +1,80 @@
+// Package clipboard provides cross-platform clipboard operations.
+package clipboard
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/aymanbagabas/go-osc52/v2"
+)
+
+// OSC52 copies text by writing an OSC 52 escape sequence to the terminal,
+// which sets the clipboard of the machine the terminal runs on. It works
+// over SSH; inside tmux or screen the sequence is wrapped so that it
+// passes through to the outer terminal.
+type OSC52 struct {
+	tmux   bool
+	screen bool
+}
+
+// NewOSC52 creates an OSC 52 clipboard for the current terminal.
+func NewOSC52() *OSC52 {
+	return &OSC52{
+		tmux:   os.Getenv("TMUX") != "",
+		screen: strings.HasPrefix(os.Getenv("TERM"), "screen") && os.Getenv("STY") != "",
+	}
+}
+
+// ErrPasteUnsupported is returned by OSC52.Paste. Reading the clipboard
+// needs a reply from the terminal, which most terminals refuse; pasting
+// with the terminal's own shortcut works instead.
+var ErrPasteUnsupported = errors.New("osc52: paste is not supported, use the terminal's paste")
+
+// Copy writes the OSC 52 sequence for text to the terminal.
+func (o *OSC52) Copy(target Target, text string) error {
+	out, err := openTerminal()
+	if err != nil {
+		return fmt.Errorf("osc52: %w", err)
+	}
+	defer out.Close()
+
+	seq := osc52.New(text)
+	if target == TargetPrimary {
+		seq = seq.Primary()
+	}
+	switch {
+	case o.tmux:
+		// Needs "set -g allow-passthrough on" in tmux 3.3 and later
+		seq = seq.Tmux()
+	case o.screen:
+		seq = seq.Screen()
+	}
+	if _, err := seq.WriteTo(out); err != nil {
+		return fmt.Errorf("osc52: %w", err)
+	}
+	return nil
+}
+
+// Paste returns ErrPasteUnsupported.
+func (o *OSC52) Paste(target Target) (string, error) {
+	return "", ErrPasteUnsupported
+}
+
+// Available returns true if there is a terminal to write to.
+func (o *OSC52) Available() bool {
+	out, err := openTerminal()
+	if err != nil {
+		return false
+	}
+	out.Close()
+	return true
+}
+
+// openTerminal opens the controlling terminal for writing. The sequence
+// goes to the terminal rather than stdout, which may be redirected.
+func openTerminal() (io.WriteCloser, error) {
+	return os.OpenFile("/dev/tty", os.O_WRONLY, 0)
+}
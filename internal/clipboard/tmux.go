@@ -0,0 +1,101 @@
+// Package clipboard provides cross-platform clipboard operations.
+package clipboard
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Tmux copies to and pastes from the tmux paste buffer of the server vai
+// runs in. Both targets use the same buffer.
+type Tmux struct{}
+
+// NewTmux creates a new tmux clipboard instance.
+func NewTmux() *Tmux {
+	return &Tmux{}
+}
+
+// sendBuffer is the named buffer used to hand text to another pane.
+const sendBuffer = "vai-send"
+
+// Copy loads text into the top tmux paste buffer.
+func (t *Tmux) Copy(target Target, text string) error {
+	return t.load(text)
+}
+
+// Paste returns the content of the top tmux paste buffer.
+func (t *Tmux) Paste(target Target) (string, error) {
+	if !t.Available() {
+		return "", fmt.Errorf("tmux not available (not inside tmux)")
+	}
+	out, err := exec.Command("tmux", "save-buffer", "-").Output()
+	if err != nil {
+		return "", fmt.Errorf("tmux save-buffer failed: %w", err)
+	}
+	return string(out), nil
+}
+
+// Available returns true inside a tmux session.
+func (t *Tmux) Available() bool {
+	return os.Getenv("TMUX") != "" && hasCommand("tmux")
+}
+
+// Send pastes text into the tmux pane target, e.g. "{right}" or "dev:1.2",
+// and presses Enter afterwards if enter is set. Shells that request it
+// receive the text as a bracketed paste, so a multi-line snippet only
+// runs on Enter.
+func (t *Tmux) Send(target, text string, enter bool) error {
+	if err := t.load(text, "-b", sendBuffer); err != nil {
+		return err
+	}
+	if err := t.run("paste-buffer", "-d", "-p", "-b", sendBuffer, "-t", target); err != nil {
+		return err
+	}
+	if enter {
+		return t.run("send-keys", "-t", target, "Enter")
+	}
+	return nil
+}
+
+// Panes returns the targets of all panes, for completion.
+func (t *Tmux) Panes() []string {
+	if !t.Available() {
+		return nil
+	}
+	out, err := exec.Command("tmux", "list-panes", "-a", "-F", "#{session_name}:#{window_index}.#{pane_index}").Output()
+	if err != nil {
+		return nil
+	}
+	return strings.Fields(string(out))
+}
+
+// load runs tmux load-buffer with text on stdin.
+func (t *Tmux) load(text string, args ...string) error {
+	if !t.Available() {
+		return fmt.Errorf("tmux not available (not inside tmux)")
+	}
+	cmd := exec.Command("tmux", append(append([]string{"load-buffer"}, args...), "-")...)
+	cmd.Stdin = strings.NewReader(text)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("tmux load-buffer failed: %s", tmuxError(out, err))
+	}
+	return nil
+}
+
+// run runs a tmux command.
+func (t *Tmux) run(args ...string) error {
+	if out, err := exec.Command("tmux", args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("tmux %s failed: %s", args[0], tmuxError(out, err))
+	}
+	return nil
+}
+
+// tmuxError prefers the message tmux printed over the exit status.
+func tmuxError(out []byte, err error) string {
+	if msg := strings.TrimSpace(string(out)); msg != "" {
+		return msg
+	}
+	return err.Error()
+}
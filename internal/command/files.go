@@ -0,0 +1,45 @@
+// Package command provides the ex-style command registry and command line.
+package command
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// CompleteFiles returns file and directory paths that start with partial.
+// Directories get a trailing separator so completion can continue into them.
+// Hidden entries are only offered when partial names a dot-prefix.
+func CompleteFiles(partial string) []string {
+	dir, base := filepath.Split(partial)
+	readDir := dir
+	if readDir == "" {
+		readDir = "."
+	}
+	if strings.HasPrefix(readDir, "~"+string(filepath.Separator)) {
+		if home, err := os.UserHomeDir(); err == nil {
+			readDir = filepath.Join(home, readDir[2:])
+		}
+	}
+
+	entries, err := os.ReadDir(readDir)
+	if err != nil {
+		return nil
+	}
+
+	var out []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, base) {
+			continue
+		}
+		if strings.HasPrefix(name, ".") && !strings.HasPrefix(base, ".") {
+			continue
+		}
+		if entry.IsDir() {
+			name += string(filepath.Separator)
+		}
+		out = append(out, dir+name)
+	}
+	return out
+}
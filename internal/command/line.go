@@ -0,0 +1,350 @@
+// Package command provides the ex-style command registry and command line.
+package command
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// SubmitMsg is sent when the user presses Enter on the command line.
+type SubmitMsg struct {
+	Prompt rune   // ':' for commands, '/' or '?' for searches, '@' for answers
+	Value  string // Text typed after the prompt
+}
+
+// CancelMsg is sent when the command line is closed without submitting.
+type CancelMsg struct {
+	Prompt rune
+}
+
+// InputPrompt is the prompt of answers read with Ask. It is never shown;
+// like Vim's input(), answers have their own history.
+const InputPrompt = '@'
+
+// maxHistory is the number of entries kept per prompt.
+const maxHistory = 100
+
+// Line is the bottom command line. It reads ':' commands and '/' or '?'
+// search patterns, keeps a separate history per prompt, completes command
+// lines from a Registry, and shows messages and errors when closed.
+type Line struct {
+	input    textinput.Model
+	registry *Registry
+	prompt   rune
+	active   bool
+
+	// history per prompt (':' and '/'; '?' shares the '/' history)
+	history map[rune][]string
+	histIdx int    // index into history while browsing, len(history) = draft
+	draft   string // text typed before browsing history
+
+	// completion state; candidates are full replacement lines
+	completions []string
+	compIdx     int
+
+	// message shown while the line is inactive
+	message string
+	isError bool
+
+	// showCmd holds pending NORMAL mode keys, shown at the right edge
+	showCmd string
+
+	styles LineStyles
+	width  int
+}
+
+// LineStyles holds the styles used by the command line.
+type LineStyles struct {
+	Text  lipgloss.Style
+	Info  lipgloss.Style
+	Error lipgloss.Style
+	Menu  lipgloss.Style
+}
+
+// NewLine creates a command line that completes against registry.
+func NewLine(registry *Registry, styles LineStyles) Line {
+	ti := textinput.New()
+	ti.Prompt = ""
+	return Line{
+		input:    ti,
+		registry: registry,
+		history:  make(map[rune][]string),
+		styles:   styles,
+	}
+}
+
+// Open activates the line with the given prompt character.
+func (l *Line) Open(prompt rune) tea.Cmd {
+	l.prompt = prompt
+	l.active = true
+	l.message = ""
+	l.input.Prompt = string(prompt)
+	l.input.Reset()
+	l.resetCompletion()
+	l.histIdx = len(l.history[historyKey(prompt)])
+	l.draft = ""
+	return l.input.Focus()
+}
+
+// OpenWith activates the line with initial text, e.g. ":send-pane ".
+func (l *Line) OpenWith(prompt rune, text string) tea.Cmd {
+	cmd := l.Open(prompt)
+	l.input.SetValue(text)
+	l.input.CursorEnd()
+	return cmd
+}
+
+// Ask activates the line to read an answer to question, which is shown
+// as the prompt. The answer is submitted with InputPrompt.
+func (l *Line) Ask(question string) tea.Cmd {
+	cmd := l.Open(InputPrompt)
+	l.input.Prompt = question + " "
+	return cmd
+}
+
+// close deactivates the line.
+func (l *Line) close() {
+	l.active = false
+	l.input.Blur()
+	l.resetCompletion()
+}
+
+// Active returns true while the line is reading input.
+func (l Line) Active() bool {
+	return l.active
+}
+
+// Prompt returns the prompt character of the current or last input.
+func (l Line) Prompt() rune {
+	return l.prompt
+}
+
+// SetWidth sets the rendering width.
+func (l *Line) SetWidth(width int) {
+	l.width = width
+	l.input.Width = width - 2
+}
+
+// SetStyles replaces the styles, e.g. after a theme change.
+func (l *Line) SetStyles(styles LineStyles) {
+	l.styles = styles
+}
+
+// SetMessage shows an informational message while the line is inactive.
+func (l *Line) SetMessage(text string) {
+	l.message = text
+	l.isError = false
+}
+
+// SetError shows an error message while the line is inactive.
+func (l *Line) SetError(err error) {
+	if err == nil {
+		l.message = ""
+		return
+	}
+	l.message = err.Error()
+	l.isError = true
+}
+
+// ClearMessage removes the message.
+func (l *Line) ClearMessage() {
+	l.message = ""
+}
+
+// SetShowCmd shows a partially typed key sequence at the right edge of the
+// line, like Vim's 'showcmd'. An empty string hides it.
+func (l *Line) SetShowCmd(keys string) {
+	l.showCmd = keys
+}
+
+// History returns the history for a prompt, oldest first.
+func (l Line) History(prompt rune) []string {
+	return l.history[historyKey(prompt)]
+}
+
+// SetHistory replaces the history for a prompt, e.g. when restoring it.
+func (l *Line) SetHistory(prompt rune, entries []string) {
+	l.history[historyKey(prompt)] = entries
+}
+
+// historyKey maps '?' onto the '/' history, as Vim does.
+func historyKey(prompt rune) rune {
+	if prompt == '?' {
+		return '/'
+	}
+	return prompt
+}
+
+// Update handles key input while the line is active.
+func (l Line) Update(msg tea.Msg) (Line, tea.Cmd) {
+	if !l.active {
+		return l, nil
+	}
+
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		var cmd tea.Cmd
+		l.input, cmd = l.input.Update(msg)
+		return l, cmd
+	}
+
+	switch key.Type {
+	case tea.KeyEsc, tea.KeyCtrlC:
+		l.close()
+		prompt := l.prompt
+		return l, func() tea.Msg { return CancelMsg{Prompt: prompt} }
+
+	case tea.KeyEnter:
+		value := l.input.Value()
+		l.close()
+		l.addHistory(value)
+		prompt := l.prompt
+		return l, func() tea.Msg { return SubmitMsg{Prompt: prompt, Value: value} }
+
+	case tea.KeyBackspace:
+		// Backspace on an empty line cancels, as in Vim
+		if l.input.Value() == "" {
+			l.close()
+			prompt := l.prompt
+			return l, func() tea.Msg { return CancelMsg{Prompt: prompt} }
+		}
+
+	case tea.KeyUp, tea.KeyCtrlP:
+		l.browseHistory(-1)
+		return l, nil
+
+	case tea.KeyDown, tea.KeyCtrlN:
+		l.browseHistory(1)
+		return l, nil
+
+	case tea.KeyTab:
+		l.complete(1)
+		return l, nil
+
+	case tea.KeyShiftTab:
+		l.complete(-1)
+		return l, nil
+	}
+
+	l.resetCompletion()
+	var cmd tea.Cmd
+	l.input, cmd = l.input.Update(msg)
+	return l, cmd
+}
+
+// addHistory appends an entry, dropping an older duplicate.
+func (l *Line) addHistory(value string) {
+	if strings.TrimSpace(value) == "" {
+		return
+	}
+	key := historyKey(l.prompt)
+	hist := l.history[key]
+	for i, entry := range hist {
+		if entry == value {
+			hist = append(hist[:i], hist[i+1:]...)
+			break
+		}
+	}
+	hist = append(hist, value)
+	if len(hist) > maxHistory {
+		hist = hist[len(hist)-maxHistory:]
+	}
+	l.history[key] = hist
+}
+
+// browseHistory moves through history entries that start with the text
+// typed before browsing began, as Vim's cmdline does.
+func (l *Line) browseHistory(dir int) {
+	hist := l.history[historyKey(l.prompt)]
+	if l.histIdx == len(hist) {
+		l.draft = l.input.Value()
+	}
+
+	for i := l.histIdx + dir; i >= 0 && i <= len(hist); i += dir {
+		if i == len(hist) {
+			l.histIdx = i
+			l.setValue(l.draft)
+			return
+		}
+		if strings.HasPrefix(hist[i], l.draft) {
+			l.histIdx = i
+			l.setValue(hist[i])
+			return
+		}
+	}
+}
+
+// complete cycles through completion candidates for the current text.
+func (l *Line) complete(dir int) {
+	if l.prompt != ':' || l.registry == nil {
+		return
+	}
+
+	if l.completions == nil {
+		l.completions = l.registry.Complete(l.input.Value())
+		if len(l.completions) == 0 {
+			return
+		}
+		// Keep the typed text as the last entry so cycling can return to it
+		l.completions = append(l.completions, l.input.Value())
+		l.compIdx = len(l.completions) - 1
+	}
+
+	n := len(l.completions)
+	l.compIdx = (l.compIdx + dir + n) % n
+	l.input.SetValue(l.completions[l.compIdx])
+	l.input.CursorEnd()
+}
+
+// resetCompletion discards the completion menu.
+func (l *Line) resetCompletion() {
+	l.completions = nil
+	l.compIdx = 0
+}
+
+// setValue replaces the text and moves the cursor to the end.
+func (l *Line) setValue(value string) {
+	l.input.SetValue(value)
+	l.input.CursorEnd()
+	l.resetCompletion()
+}
+
+// View renders the command line.
+func (l Line) View() string {
+	var line string
+	switch {
+	case l.active && len(l.completions) > 1:
+		line = l.input.View() + "  " + l.menuView()
+	case l.active:
+		line = l.input.View()
+	case l.message != "" && l.isError:
+		line = l.styles.Error.Render(l.message)
+	case l.message != "":
+		line = l.styles.Info.Render(l.message)
+	}
+	if !l.active && l.showCmd != "" {
+		pad := l.width - lipgloss.Width(line) - lipgloss.Width(l.showCmd) - 1
+		line += strings.Repeat(" ", max(pad, 1)) + l.showCmd
+	}
+	return l.styles.Text.Width(l.width).MaxHeight(1).Render(line)
+}
+
+// menuView renders the completion candidates, marking the selected one.
+func (l Line) menuView() string {
+	var parts []string
+	for i, cand := range l.completions[:len(l.completions)-1] {
+		fields := strings.Fields(cand)
+		label := cand
+		if len(fields) > 0 {
+			label = fields[len(fields)-1]
+		}
+		if i == l.compIdx {
+			label = l.styles.Menu.Render(label)
+		}
+		parts = append(parts, label)
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,238 @@
+// Package command provides the ex-style command registry and command line.
+package command
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Context holds a parsed command invocation.
+type Context struct {
+	Name string   // Command name as typed (may be an abbreviation)
+	Bang bool     // True if the name was followed by "!"
+	Args []string // Whitespace-separated arguments
+	Raw  string   // Everything after the command name, untrimmed of inner spaces
+}
+
+// Arg returns the i-th argument, or "" if absent.
+func (c Context) Arg(i int) string {
+	if i < 0 || i >= len(c.Args) {
+		return ""
+	}
+	return c.Args[i]
+}
+
+// RunFunc executes a command. It returns a command for the Bubble Tea runtime,
+// or an error that is reported inline in the command line.
+type RunFunc func(ctx Context) (tea.Cmd, error)
+
+// CompleteFunc returns completion candidates for the argument at index argIdx,
+// given the arguments typed so far. The last argument may be partial.
+type CompleteFunc func(args []string, argIdx int) []string
+
+// Command is an ex command such as :q or :e.
+type Command struct {
+	Name        string       // Full command name (e.g. "edit")
+	Aliases     []string     // Short names (e.g. "e")
+	Usage       string       // Argument synopsis (e.g. "{session}")
+	Description string       // One-line description for :help
+	Run         RunFunc      // Handler
+	Complete    CompleteFunc // Argument completion, may be nil
+	Slash       bool         // Can also be run as /name from the input area
+}
+
+// Registry is the central table of ex commands. Packages register their own
+// commands with Register; the app resolves and runs them with Execute.
+type Registry struct {
+	commands map[string]*Command
+	aliases  map[string]string
+}
+
+// NewRegistry creates an empty command registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		commands: make(map[string]*Command),
+		aliases:  make(map[string]string),
+	}
+}
+
+// Register adds a command. It fails if the name or an alias is taken.
+func (r *Registry) Register(cmd Command) error {
+	if cmd.Name == "" || cmd.Run == nil {
+		return fmt.Errorf("command needs a name and a handler")
+	}
+	for _, name := range append([]string{cmd.Name}, cmd.Aliases...) {
+		if _, ok := r.resolve(name); ok {
+			return fmt.Errorf("command already registered: %s", name)
+		}
+	}
+
+	c := cmd
+	r.commands[c.Name] = &c
+	for _, alias := range c.Aliases {
+		r.aliases[alias] = c.Name
+	}
+	return nil
+}
+
+// MustRegister is like Register but panics on error. It is meant for
+// built-in commands registered at startup.
+func (r *Registry) MustRegister(cmd Command) {
+	if err := r.Register(cmd); err != nil {
+		panic(err)
+	}
+}
+
+// resolve looks up a command by exact name or alias.
+func (r *Registry) resolve(name string) (*Command, bool) {
+	if c, ok := r.commands[name]; ok {
+		return c, true
+	}
+	if full, ok := r.aliases[name]; ok {
+		return r.commands[full], true
+	}
+	return nil, false
+}
+
+// Lookup finds a command by name, alias or unambiguous prefix of a name.
+func (r *Registry) Lookup(name string) (*Command, error) {
+	if c, ok := r.resolve(name); ok {
+		return c, nil
+	}
+
+	var matches []string
+	for full := range r.commands {
+		if strings.HasPrefix(full, name) {
+			matches = append(matches, full)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("not an editor command: %s", name)
+	case 1:
+		return r.commands[matches[0]], nil
+	}
+	sort.Strings(matches)
+	return nil, fmt.Errorf("ambiguous command: %s (%s)", name, strings.Join(matches, ", "))
+}
+
+// Commands returns all commands sorted by name.
+func (r *Registry) Commands() []*Command {
+	cmds := make([]*Command, 0, len(r.commands))
+	for _, c := range r.commands {
+		cmds = append(cmds, c)
+	}
+	sort.Slice(cmds, func(i, j int) bool { return cmds[i].Name < cmds[j].Name })
+	return cmds
+}
+
+// Execute parses a command line (without the leading ':') and runs it.
+func (r *Registry) Execute(line string) (tea.Cmd, error) {
+	ctx, err := Parse(line)
+	if err != nil {
+		return nil, err
+	}
+	cmd, err := r.Lookup(ctx.Name)
+	if err != nil {
+		return nil, err
+	}
+	return cmd.Run(ctx)
+}
+
+// Parse splits a command line into name, bang and arguments.
+func Parse(line string) (Context, error) {
+	line = strings.TrimLeft(line, ": \t")
+	if line == "" {
+		return Context{}, fmt.Errorf("empty command")
+	}
+
+	// The name is a run of letters, possibly joined by hyphens as in
+	// send-pane, or a single non-letter such as "!"
+	end := nameEnd(line)
+	if end == 0 {
+		end = 1
+	}
+
+	ctx := Context{Name: line[:end]}
+	rest := line[end:]
+	if strings.HasPrefix(rest, "!") {
+		ctx.Bang = true
+		rest = rest[1:]
+	}
+	ctx.Raw = strings.TrimSpace(rest)
+	ctx.Args = strings.Fields(rest)
+	return ctx, nil
+}
+
+// nameEnd returns the length of the command name at the start of line.
+func nameEnd(line string) int {
+	end := 0
+	for i, r := range line {
+		switch {
+		case unicode.IsLetter(r):
+			end = i + utf8.RuneLen(r)
+		case r == '-' && end == i && end > 0 && i+1 < len(line) && unicode.IsLetter(rune(line[i+1])):
+		default:
+			return end
+		}
+	}
+	return end
+}
+
+// Complete returns completion candidates for a partial command line.
+// The candidates are full replacement lines, so the caller can cycle
+// through them without re-parsing.
+func (r *Registry) Complete(line string) []string {
+	trimmed := strings.TrimLeft(line, ": \t")
+
+	// Still typing the command name
+	if !strings.ContainsAny(trimmed, " \t") {
+		var out []string
+		for _, c := range r.Commands() {
+			if strings.HasPrefix(c.Name, trimmed) {
+				out = append(out, c.Name)
+			}
+		}
+		return out
+	}
+
+	ctx, err := Parse(trimmed)
+	if err != nil {
+		return nil
+	}
+	cmd, err := r.Lookup(ctx.Name)
+	if err != nil {
+		return nil
+	}
+	return completeArgs(cmd, ctx, trimmed)
+}
+
+// completeArgs returns the candidates for the last, possibly partial,
+// argument of line, parsed as ctx, as full replacement lines.
+func completeArgs(cmd *Command, ctx Context, line string) []string {
+	if cmd.Complete == nil {
+		return nil
+	}
+
+	// A trailing space starts a new, empty argument
+	args := ctx.Args
+	if strings.HasSuffix(line, " ") || len(args) == 0 {
+		args = append(args, "")
+	}
+	argIdx := len(args) - 1
+	partial := args[argIdx]
+	prefix := strings.TrimSuffix(line, partial)
+
+	var out []string
+	for _, cand := range cmd.Complete(args, argIdx) {
+		if strings.HasPrefix(cand, partial) {
+			out = append(out, prefix+cand)
+		}
+	}
+	return out
+}
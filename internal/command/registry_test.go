@@ -0,0 +1,152 @@
+package command
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// testRegistry returns a registry with commands named like the built-in
+// ones, whose handlers record the context they ran with.
+func testRegistry(ran *Context) *Registry {
+	run := func(ctx Context) (tea.Cmd, error) {
+		*ran = ctx
+		return nil, nil
+	}
+	r := NewRegistry()
+	r.MustRegister(Command{Name: "set", Aliases: []string{"se"}, Run: run})
+	r.MustRegister(Command{Name: "source", Aliases: []string{"so"}, Run: run})
+	r.MustRegister(Command{Name: "system", Aliases: []string{"sy"}, Run: run})
+	r.MustRegister(Command{Name: "send-pane", Run: run})
+	r.MustRegister(Command{Name: "quit", Aliases: []string{"q"}, Run: run})
+	r.MustRegister(Command{
+		Name: "model", Run: run,
+		Complete: func(args []string, argIdx int) []string {
+			return []string{"gpt-4", "gpt-4o", "llama3"}
+		},
+	})
+	return r
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		line string
+		want Context
+	}{
+		{"q", Context{Name: "q"}},
+		{":q!", Context{Name: "q", Bang: true}},
+		{"model gpt-4o", Context{Name: "model", Args: []string{"gpt-4o"}, Raw: "gpt-4o"}},
+		{"system  be  brief ", Context{Name: "system", Args: []string{"be", "brief"}, Raw: "be  brief"}},
+		{"send-pane 2", Context{Name: "send-pane", Args: []string{"2"}, Raw: "2"}},
+		{"!ls", Context{Name: "!", Args: []string{"ls"}, Raw: "ls"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			got, err := Parse(tt.line)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Name != tt.want.Name || got.Bang != tt.want.Bang || got.Raw != tt.want.Raw || !slices.Equal(got.Args, tt.want.Args) {
+				t.Errorf("Parse(%q) = %+v, want %+v", tt.line, got, tt.want)
+			}
+		})
+	}
+	if _, err := Parse(" : "); err == nil {
+		t.Error("Parse of an empty line: no error")
+	}
+}
+
+func TestLookup(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    string
+		wantErr string
+	}{
+		{name: "model", want: "model"},
+		{name: "q", want: "quit"},
+		{name: "se", want: "set"},
+		{name: "so", want: "source"},
+		{name: "sy", want: "system"},
+		{name: "mo", want: "model"},
+		{name: "sen", want: "send-pane"},
+		{name: "s", wantErr: "ambiguous command: s (send-pane, set, source, system)"},
+		{name: "sys", want: "system"},
+		{name: "x", wantErr: "not an editor command: x"},
+	}
+	var ran Context
+	r := testRegistry(&ran)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, err := r.Lookup(tt.name)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("Lookup(%q) error = %v, want %q", tt.name, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cmd.Name != tt.want {
+				t.Errorf("Lookup(%q) = %s, want %s", tt.name, cmd.Name, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegisterTakenName(t *testing.T) {
+	var ran Context
+	r := testRegistry(&ran)
+	noop := func(Context) (tea.Cmd, error) { return nil, nil }
+	for _, cmd := range []Command{
+		{Name: "set", Run: noop},
+		{Name: "settings", Aliases: []string{"se"}, Run: noop},
+		{Name: "", Run: noop},
+		{Name: "nil"},
+	} {
+		if err := r.Register(cmd); err == nil {
+			t.Errorf("Register(%q): no error", cmd.Name)
+		}
+	}
+}
+
+func TestExecute(t *testing.T) {
+	var ran Context
+	r := testRegistry(&ran)
+	if _, err := r.Execute("mo gpt-4o"); err != nil {
+		t.Fatal(err)
+	}
+	if ran.Name != "mo" || ran.Raw != "gpt-4o" {
+		t.Errorf("ran with %+v", ran)
+	}
+	if _, err := r.Execute("s x"); err == nil || !strings.HasPrefix(err.Error(), "ambiguous") {
+		t.Errorf("Execute(s x) error = %v", err)
+	}
+}
+
+func TestComplete(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{"s", []string{"send-pane", "set", "source", "system"}},
+		{":so", []string{"source"}},
+		{"x", nil},
+		{"model ", []string{"model gpt-4", "model gpt-4o", "model llama3"}},
+		{"model gpt-4", []string{"model gpt-4", "model gpt-4o"}},
+		{"mo ll", []string{"mo llama3"}},
+		{"quit ", nil},
+		{"s x", nil},
+	}
+	var ran Context
+	r := testRegistry(&ran)
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			if got := r.Complete(tt.line); !slices.Equal(got, tt.want) {
+				t.Errorf("Complete(%q) = %q, want %q", tt.line, got, tt.want)
+			}
+		})
+	}
+}
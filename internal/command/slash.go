@@ -0,0 +1,119 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Slash commands are the registry commands marked Slash, typed as /name at
+// the start of the prompt in the input area. They run in place instead of
+// being sent, so they parse like ex commands but resolve only among
+// themselves; a prompt starting with "//" is text with one slash.
+
+// IsSlash returns true if text is a slash command line: a single line
+// made of a slash, a command name and optional arguments. Text such as
+// "/etc/hosts" or "//x" is not.
+func IsSlash(text string) bool {
+	if !strings.HasPrefix(text, "/") || strings.Contains(text, "\n") {
+		return false
+	}
+	line := text[1:]
+	end := nameEnd(line)
+	if end == 0 {
+		return false
+	}
+	rest := strings.TrimPrefix(line[end:], "!")
+	return rest == "" || rest[0] == ' ' || rest[0] == '\t'
+}
+
+// Unslash returns text with the leading slash of an escaped "//" removed.
+func Unslash(text string) string {
+	if strings.HasPrefix(text, "//") {
+		return text[1:]
+	}
+	return text
+}
+
+// SlashCommands returns the commands that run as /name, sorted by name.
+func (r *Registry) SlashCommands() []*Command {
+	var cmds []*Command
+	for _, c := range r.Commands() {
+		if c.Slash {
+			cmds = append(cmds, c)
+		}
+	}
+	return cmds
+}
+
+// LookupSlash finds a slash command by name or alias. Unlike ex
+// commands, prefixes are not resolved: a prompt such as "/s is the root
+// dir?" must not run /system.
+func (r *Registry) LookupSlash(name string) (*Command, error) {
+	if c, ok := r.resolve(name); ok && c.Slash {
+		return c, nil
+	}
+	return nil, fmt.Errorf("unknown command: /%s", name)
+}
+
+// ParseSlash parses a slash command line and finds its command. It
+// fails, before anything runs, for unknown commands and for a missing
+// required argument, which the usage shows in braces.
+func (r *Registry) ParseSlash(text string) (*Command, Context, error) {
+	if !IsSlash(text) {
+		return nil, Context{}, fmt.Errorf("not a command: %s", text)
+	}
+	ctx, err := Parse(text[1:])
+	if err != nil {
+		return nil, Context{}, err
+	}
+	cmd, err := r.LookupSlash(ctx.Name)
+	if err != nil {
+		return nil, Context{}, err
+	}
+	if strings.HasPrefix(cmd.Usage, "{") && len(ctx.Args) == 0 {
+		return nil, Context{}, fmt.Errorf("usage: /%s %s", cmd.Name, cmd.Usage)
+	}
+	return cmd, ctx, nil
+}
+
+// ExecuteSlash runs a slash command line such as "/model gpt-4o".
+func (r *Registry) ExecuteSlash(text string) (tea.Cmd, error) {
+	cmd, ctx, err := r.ParseSlash(text)
+	if err != nil {
+		return nil, err
+	}
+	return cmd.Run(ctx)
+}
+
+// CompleteSlash returns completion candidates for a partial slash command
+// line, as full replacement lines like Complete.
+func (r *Registry) CompleteSlash(text string) []string {
+	line := strings.TrimPrefix(text, "/")
+
+	// Still typing the command name
+	if !strings.ContainsAny(line, " \t") {
+		var out []string
+		for _, c := range r.SlashCommands() {
+			if strings.HasPrefix(c.Name, line) {
+				out = append(out, "/"+c.Name)
+			}
+		}
+		return out
+	}
+
+	ctx, err := Parse(line)
+	if err != nil {
+		return nil
+	}
+	cmd, err := r.LookupSlash(ctx.Name)
+	if err != nil {
+		return nil
+	}
+	out := completeArgs(cmd, ctx, line)
+	for i := range out {
+		out[i] = "/" + out[i]
+	}
+	return out
+}
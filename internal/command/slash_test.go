@@ -0,0 +1,107 @@
+package command
+
+import (
+	"slices"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// slashRegistry returns a registry where model and clear run as slash
+// commands and quit does not.
+func slashRegistry(ran *Context) *Registry {
+	run := func(ctx Context) (tea.Cmd, error) {
+		*ran = ctx
+		return nil, nil
+	}
+	r := NewRegistry()
+	r.MustRegister(Command{
+		Name: "model", Aliases: []string{"mo"}, Usage: "{name}", Run: run, Slash: true,
+		Complete: func(args []string, argIdx int) []string { return []string{"gpt-4", "gpt-4o"} },
+	})
+	r.MustRegister(Command{Name: "clear", Run: run, Slash: true})
+	r.MustRegister(Command{Name: "clone", Run: run, Slash: true})
+	r.MustRegister(Command{Name: "quit", Aliases: []string{"q"}, Run: run})
+	return r
+}
+
+func TestIsSlash(t *testing.T) {
+	tests := []struct {
+		text string
+		want bool
+	}{
+		{"/model gpt-4o", true},
+		{"/clear", true},
+		{"/q!", true},
+		{"/etc/hosts", false},
+		{"//model", false},
+		{"/", false},
+		{"/model\nmore", false},
+		{"model", false},
+	}
+	for _, tt := range tests {
+		if got := IsSlash(tt.text); got != tt.want {
+			t.Errorf("IsSlash(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestParseSlash(t *testing.T) {
+	tests := []struct {
+		text    string
+		want    string
+		wantErr string
+	}{
+		{text: "/model gpt-4o", want: "model"},
+		{text: "/mo gpt-4o", want: "model"},
+		{text: "/model", wantErr: "usage: /model {name}"},
+		{text: "/mod gpt-4o", wantErr: "unknown command: /mod"},
+		{text: "/clea", wantErr: "unknown command: /clea"},
+		{text: "/cl is it cleared?", wantErr: "unknown command: /cl"},
+		{text: "/quit", wantErr: "unknown command: /quit"},
+		{text: "/q", wantErr: "unknown command: /q"},
+		{text: "/etc/hosts", wantErr: "not a command: /etc/hosts"},
+	}
+	var ran Context
+	r := slashRegistry(&ran)
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			cmd, _, err := r.ParseSlash(tt.text)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("ParseSlash(%q) error = %v, want %q", tt.text, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cmd.Name != tt.want {
+				t.Errorf("ParseSlash(%q) = %s, want %s", tt.text, cmd.Name, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompleteSlash(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"/", []string{"/clear", "/clone", "/model"}},
+		{"/cl", []string{"/clear", "/clone"}},
+		{"/q", nil},
+		{"/model gpt-4", []string{"/model gpt-4", "/model gpt-4o"}},
+		{"/mo ", []string{"/mo gpt-4", "/mo gpt-4o"}},
+		{"/mod ", nil},
+	}
+	var ran Context
+	r := slashRegistry(&ran)
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			if got := r.CompleteSlash(tt.text); !slices.Equal(got, tt.want) {
+				t.Errorf("CompleteSlash(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
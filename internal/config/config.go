@@ -3,14 +3,69 @@ package config
 
 // Config holds application configuration.
 type Config struct {
+	// Path is the file the configuration was loaded from, if any.
+	Path string `yaml:"-"`
+
+	// Issues lists the problems found while loading the file.
+	Issues []Issue `yaml:"-"`
+
+	// Overlay is the project file (.vai.yaml) merged over Path, if any.
+	Overlay string `yaml:"-"`
+
+	// origins maps dotted keys to where they are set
+	origins map[string]Origin
+
+	// Profile names the profile applied over the settings below.
+	Profile string `yaml:"profile"`
+
+	// Profiles are named sets of provider, system prompt and theme
+	// settings, e.g. for a work gateway and a local model.
+	Profiles map[string]Profile `yaml:"profiles"`
+
+	// SystemPrompt is the default system prompt of new sessions.
+	SystemPrompt string `yaml:"system_prompt"`
+
 	// Editor settings
 	Editor EditorConfig `yaml:"editor"`
 
+	// Layout of the panes
+	Layout LayoutConfig `yaml:"layout"`
+
 	// Keybindings
 	Keybindings KeybindingsConfig `yaml:"keybindings"`
 
 	// Theme
 	Theme ThemeConfig `yaml:"theme"`
+
+	// Provider
+	Provider ProviderConfig `yaml:"provider"`
+
+	// Attachments
+	Attachments AttachmentsConfig `yaml:"attachments"`
+
+	// Clipboard
+	Clipboard ClipboardConfig `yaml:"clipboard"`
+}
+
+// Profile overrides the provider, system prompt and theme. Only the
+// settings a profile sets replace those of the config file:
+//
+//	profile: work
+//	profiles:
+//	  work:
+//	    provider:
+//	      base_url: https://llm.example.com/v1
+//	      model: gpt-4o
+//	  local:
+//	    provider:
+//	      name: ollama
+//	      model: llama3
+//	    theme:
+//	      name: light
+type Profile struct {
+	Provider     ProviderConfig `yaml:"provider"`
+	SystemPrompt string         `yaml:"system_prompt"`
+	Theme        ThemeConfig    `yaml:"theme"`
 }
 
 // EditorConfig contains editor-related settings.
@@ -18,29 +73,116 @@ type EditorConfig struct {
 	// TabWidth is the number of spaces per tab.
 	TabWidth int `yaml:"tab_width"`
 
-	// WordWrap enables word wrap.
-	WordWrap bool `yaml:"word_wrap"`
-
-	// LineNumbers enables line numbers in code blocks.
+	// WordWrap and LineNumbers are not used yet: the chat buffer always
+	// wraps and never numbers code lines. They are accepted so that files
+	// written by older versions of vai config init still load cleanly.
+	WordWrap    bool `yaml:"word_wrap"`
 	LineNumbers bool `yaml:"line_numbers"`
+
+	// SendOnSave sends the prompt when it is saved in the external editor.
+	SendOnSave bool `yaml:"send_on_save"`
 }
 
-// KeybindingsConfig contains custom keybindings.
+// KeybindingsConfig contains custom keybindings. Each mode maps a focus
+// area (any, history, buffer, input) to key sequences and the action or
+// ex command they run:
+//
+//	keybindings:
+//	  leader: "<Space>"
+//	  normal:
+//	    buffer:
+//	      "<leader>e": ":export chat.md"
+//	      "J": scroll-down
+//	      "<C-e>": "<Nop>"   # unbind a default
 type KeybindingsConfig struct {
-	// Custom keybindings override defaults.
-	// TODO: Define keybinding structure
-	Overrides map[string]string `yaml:"overrides"`
+	// Leader replaces <leader> in key sequences (default: backslash).
+	Leader string `yaml:"leader"`
+
+	Normal   ModeBindings `yaml:"normal"`
+	Insert   ModeBindings `yaml:"insert"`
+	Visual   ModeBindings `yaml:"visual"`
+	Operator ModeBindings `yaml:"operator"`
 }
 
 // ThemeConfig contains theme settings.
 type ThemeConfig struct {
-	// Name of the theme (default, dark, light).
+	// Name of the theme: auto (dark or light, following the terminal
+	// background), dark, light, high-contrast, or a file in the themes
+	// directory of the config dir without its .yaml extension.
 	Name string `yaml:"name"`
 
-	// Custom colors override theme colors.
+	// Colors override colors of the theme, as 256-color indexes or hex.
 	Colors map[string]string `yaml:"colors"`
 }
 
+// ProviderConfig contains AI provider settings.
+type ProviderConfig struct {
+	// Name of the provider (e.g. openai, anthropic, ollama).
+	Name string `yaml:"name"`
+
+	// Model is the default model for new sessions.
+	Model string `yaml:"model"`
+
+	// Models lists the model names :model accepts and completes; when
+	// empty, :model accepts any name.
+	Models []string `yaml:"models"`
+
+	// BaseURL overrides the API endpoint, e.g. for a gateway or a local
+	// OpenAI-compatible server.
+	BaseURL string `yaml:"base_url"`
+
+	// The API key comes from one of these sources, or else from the
+	// provider's usual environment variable (OPENAI_API_KEY,
+	// ANTHROPIC_API_KEY). APIKeyEnv names an environment variable,
+	// APIKeyCmd is a shell command printing the key (e.g. "pass show
+	// openai"), run once, and APIKeyFile is a file only its owner can
+	// read. Project files cannot set them.
+	APIKeyEnv  string `yaml:"api_key_env"`
+	APIKeyCmd  string `yaml:"api_key_cmd"`
+	APIKeyFile string `yaml:"api_key_file"`
+}
+
+// LayoutConfig arranges the panes. The :set options of the same names
+// change it while vai runs.
+type LayoutConfig struct {
+	// History shows the session list pane.
+	History bool `yaml:"history"`
+
+	// HistoryWidth is the width of the session list in columns; 0 uses a
+	// fifth of the terminal, at least 20 columns.
+	HistoryWidth int `yaml:"history_width"`
+
+	// InputHeight is the number of text rows of the input area, which
+	// grows with its content up to InputMaxHeight rows.
+	InputHeight    int `yaml:"input_height"`
+	InputMaxHeight int `yaml:"input_max_height"`
+
+	// VerticalBelow is the terminal width under which the panes are
+	// stacked instead of side by side; 0 never stacks them.
+	VerticalBelow int `yaml:"vertical_below"`
+}
+
+// AttachmentsConfig limits files attached with @path or :attach.
+type AttachmentsConfig struct {
+	// MaxFileSize is the largest file that can be attached, in KiB.
+	MaxFileSize int `yaml:"max_file_size"`
+
+	// MaxTotalSize limits all attachments of one message, in KiB.
+	MaxTotalSize int `yaml:"max_total_size"`
+
+	// WarnTokens is the estimated token count above which a warning is
+	// shown before sending.
+	WarnTokens int `yaml:"warn_tokens"`
+}
+
+// ClipboardConfig selects how "+ and "* reach the system clipboard.
+type ClipboardConfig struct {
+	// Backend is auto, pbcopy, wl-copy, xclip, xsel, osc52 or none. Auto
+	// picks a command for the local display server and falls back to
+	// OSC 52 escape sequences, e.g. over SSH.
+	Backend string `yaml:"backend"`
+}
+
 // DefaultConfig returns the default configuration.
 func DefaultConfig() Config {
 	return Config{
@@ -49,12 +191,28 @@ func DefaultConfig() Config {
 			WordWrap:    true,
 			LineNumbers: true,
 		},
+		Layout: LayoutConfig{
+			History:        true,
+			InputHeight:    3,
+			InputMaxHeight: 10,
+			VerticalBelow:  80,
+		},
 		Keybindings: KeybindingsConfig{
-			Overrides: make(map[string]string),
+			Leader: "\\",
+		},
+		Theme: ThemeDefaults(),
+		Provider: ProviderConfig{
+			Name:   "openai",
+			Model:  "gpt-4",
+			Models: []string{"gpt-4", "gpt-4o", "gpt-4o-mini"},
+		},
+		Attachments: AttachmentsConfig{
+			MaxFileSize:  256,
+			MaxTotalSize: 1024,
+			WarnTokens:   8000,
 		},
-		Theme: ThemeConfig{
-			Name:   "default",
-			Colors: make(map[string]string),
+		Clipboard: ClipboardConfig{
+			Backend: "auto",
 		},
 	}
 }
@@ -18,20 +18,31 @@ func EditorDefaults() EditorConfig {
 	}
 }
 
-// ThemeDefaults returns default theme configuration.
+// ThemeDefaults returns default theme configuration: the built-in theme
+// matching the terminal background, without color overrides. The color
+// keys are listed in the theme package.
 func ThemeDefaults() ThemeConfig {
 	return ThemeConfig{
-		Name: "default",
-		Colors: map[string]string{
-			"normal_mode": "252",
-			"insert_mode": "142",
-			"visual_mode": "33",
-			"user_text":   "244",
-			"assist_text": "252",
-		},
+		Name:   "auto",
+		Colors: make(map[string]string),
 	}
 }
 
+// GetConfigDir returns the platform-specific config directory.
+func GetConfigDir() string {
+	// Check for XDG_CONFIG_HOME
+	if configDir := os.Getenv("XDG_CONFIG_HOME"); configDir != "" {
+		return filepath.Join(configDir, "vai")
+	}
+
+	// Default to ~/.config/vai
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".config", "vai")
+}
+
 // GetDataDir returns the platform-specific data directory.
 func GetDataDir() string {
 	// Check for XDG_DATA_HOME
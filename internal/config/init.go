@@ -0,0 +1,120 @@
+// Package config provides application configuration.
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/template"
+)
+
+// defaultFile is the commented config file written by Loader.Init. Values
+// come from DefaultConfig so the two cannot drift apart.
+var defaultFile = template.Must(template.New("config").Parse(`# vai configuration. Every setting below is the default; delete what
+# you do not change. Check this file with: vai config check
+# A .vai.yaml in a project directory or its parents is merged over this
+# file for that project.
+
+editor:
+  # Spaces per tab in the prompt editor (1-16)
+  tab_width: {{.Editor.TabWidth}}
+  # Send the prompt when it is saved in $EDITOR
+  send_on_save: {{.Editor.SendOnSave}}
+
+layout:
+  # Show the session list (Ctrl-w o toggles it)
+  history: {{.Layout.History}}
+  # Session list width in columns; 0 is a fifth of the terminal
+  # (Ctrl-w < and > resize it)
+  history_width: {{.Layout.HistoryWidth}}
+  # Text rows of the input area, which grows with its content up to
+  # input_max_height
+  input_height: {{.Layout.InputHeight}}
+  input_max_height: {{.Layout.InputMaxHeight}}
+  # Stack the panes when the terminal is narrower than this; 0 never
+  vertical_below: {{.Layout.VerticalBelow}}
+
+keybindings:
+  # Replaces <leader> in key sequences
+  leader: '{{.Keybindings.Leader}}'
+  # Per mode (normal, insert, visual, operator) and focus area (any,
+  # history, buffer, input), keys map to an action, an ex command or <Nop>:
+  # normal:
+  #   buffer:
+  #     "<leader>e": ":w ~/chat.md"
+  #     "J": scroll-down
+  #     "<C-e>": "<Nop>"
+
+theme:
+  # auto, dark, light, high-contrast or a file in the themes directory
+  name: {{.Theme.Name}}
+  # Color overrides as 256-color indexes or hex, e.g. assistant: "#89b4fa"
+  colors: {}
+
+provider:
+  # openai, anthropic or ollama
+  name: {{.Provider.Name}}
+  model: {{.Provider.Model}}
+  # Models :model accepts and completes (any name when empty)
+  models:{{range .Provider.Models}}
+    - {{.}}{{end}}
+  # Optional gateway or local endpoint
+  base_url: ""
+  # The API key is read from OPENAI_API_KEY or ANTHROPIC_API_KEY, or from
+  # one of these sources (not allowed in project .vai.yaml files):
+  # api_key_env: WORK_OPENAI_KEY       # another environment variable
+  # api_key_cmd: pass show openai      # first line of output, run once
+  # api_key_file: ~/.config/vai/key    # first line, mode 0600
+
+# Default system prompt of new sessions
+system_prompt: ""
+
+# Profiles override provider, system_prompt and theme settings. Select one
+# here, with vai --profile or with :profile:
+# profile: work
+# profiles:
+#   work:
+#     provider:
+#       base_url: https://llm.example.com/v1
+#       model: gpt-4o
+#   local:
+#     provider:
+#       name: ollama
+#       model: llama3
+
+attachments:
+  # Largest attached file and all attachments of a message, in KiB
+  max_file_size: {{.Attachments.MaxFileSize}}
+  max_total_size: {{.Attachments.MaxTotalSize}}
+  # Warn when attachments exceed this many estimated tokens
+  warn_tokens: {{.Attachments.WarnTokens}}
+
+clipboard:
+  # auto, pbcopy, wl-copy, xclip, xsel, osc52, tmux or none
+  backend: {{.Clipboard.Backend}}
+`))
+
+// DefaultFile returns the commented default config file.
+func DefaultFile() string {
+	var buf bytes.Buffer
+	if err := defaultFile.Execute(&buf, DefaultConfig()); err != nil {
+		panic(err)
+	}
+	return buf.String()
+}
+
+// Init writes the commented default config file. An existing file is only
+// replaced if force is set.
+func (l *Loader) Init(force bool) error {
+	if l.configPath == "" {
+		return fmt.Errorf("cannot determine the config directory")
+	}
+	if _, err := os.Stat(l.configPath); err == nil && !force {
+		return fmt.Errorf("%s already exists (use --force to overwrite)", l.configPath)
+	}
+	if err := os.MkdirAll(filepath.Dir(l.configPath), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(l.configPath, []byte(DefaultFile()), 0644)
+}
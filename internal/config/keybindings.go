@@ -0,0 +1,82 @@
+// Package config provides application configuration.
+package config
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Unbind is the action that removes a default binding, as in Vim's <Nop>.
+const Unbind = "<Nop>"
+
+// KeyBinding maps a key sequence to an action in one focus area.
+type KeyBinding struct {
+	Focus  string // any, history, buffer or input
+	Keys   string // Vim notation, e.g. "<leader>e" or "<C-w>h"
+	Action string // Action name, ":command args", or Unbind
+	Path   string // Config file, for error messages
+	Line   int    // Line in the config file, for error messages
+}
+
+// ModeBindings holds the bindings of one mode in file order. It is written
+// in YAML as a map of focus areas to maps of keys to actions.
+type ModeBindings []KeyBinding
+
+// UnmarshalYAML decodes the focus → keys → action maps, keeping the line
+// of every binding. Malformed entries are skipped and reported as a
+// yaml.TypeError, so the rest of the file is still decoded.
+func (b *ModeBindings) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return &yaml.TypeError{Errors: []string{fmt.Sprintf("line %d: expected a map of focus areas", node.Line)}}
+	}
+	var errs []string
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		focus, keys := node.Content[i], node.Content[i+1]
+		if keys.Kind != yaml.MappingNode {
+			errs = append(errs, fmt.Sprintf("line %d: expected a map of keys to actions under %q", keys.Line, focus.Value))
+			continue
+		}
+		for j := 0; j+1 < len(keys.Content); j += 2 {
+			key, action := keys.Content[j], keys.Content[j+1]
+			if action.Kind != yaml.ScalarNode {
+				errs = append(errs, fmt.Sprintf("line %d: action for %q must be a string", action.Line, key.Value))
+				continue
+			}
+			value := action.Value
+			if action.Tag == "!!null" {
+				value = Unbind
+			}
+			*b = append(*b, KeyBinding{
+				Focus:  focus.Value,
+				Keys:   key.Value,
+				Action: value,
+				Line:   key.Line,
+			})
+		}
+	}
+	if len(errs) > 0 {
+		return &yaml.TypeError{Errors: errs}
+	}
+	return nil
+}
+
+// MarshalYAML encodes the bindings as focus → keys → action maps.
+func (b ModeBindings) MarshalYAML() (interface{}, error) {
+	if len(b) == 0 {
+		return nil, nil
+	}
+	out := make(map[string]map[string]string)
+	for _, kb := range b {
+		if out[kb.Focus] == nil {
+			out[kb.Focus] = make(map[string]string)
+		}
+		out[kb.Focus][kb.Keys] = kb.Action
+	}
+	return out, nil
+}
+
+// modes returns the bindings of every mode.
+func (k *KeybindingsConfig) modes() []*ModeBindings {
+	return []*ModeBindings{&k.Normal, &k.Insert, &k.Visual, &k.Operator}
+}
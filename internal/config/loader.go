@@ -2,47 +2,98 @@
 package config
 
 import (
+	"bytes"
+	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 )
 
+// ProjectFile is the name of the project config file, merged over the user
+// config when it is found in the working directory or one of its parents.
+const ProjectFile = ".vai.yaml"
+
 // Loader loads configuration from file.
 type Loader struct {
 	configPath string
+
+	// dir is where the search for a project file starts
+	dir string
+
+	// profile overrides the profile named in the config files
+	profile string
 }
 
 // NewLoader creates a new configuration loader.
 func NewLoader() *Loader {
+	dir, _ := os.Getwd()
 	return &Loader{
 		configPath: getConfigPath(),
+		dir:        dir,
 	}
 }
 
-// Load loads the configuration from file.
-// If the file doesn't exist, returns default config.
+// Load loads the user config file and the nearest project file over the
+// defaults and applies the profile. Missing files are skipped. Problems in
+// the files are reported in Config.Issues; only a file that cannot be read
+// or an unknown profile passed to SetProfile is an error.
 func (l *Loader) Load() (Config, error) {
-	cfg := DefaultConfig()
-
-	// Check if config file exists
-	if _, err := os.Stat(l.configPath); os.IsNotExist(err) {
-		// Return default config
-		return cfg, nil
+	var files []source
+	data, err := os.ReadFile(l.configPath)
+	if err == nil {
+		files = append(files, source{path: l.configPath, data: data})
+	} else if !os.IsNotExist(err) {
+		return DefaultConfig(), err
 	}
 
-	// Read config file
-	data, err := os.ReadFile(l.configPath)
-	if err != nil {
-		return cfg, err
+	if path := l.ProjectPath(); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return DefaultConfig(), err
+		}
+		files = append(files, source{path: path, data: data, overlay: true})
 	}
 
-	// Parse YAML
-	if err := yaml.Unmarshal(data, &cfg); err != nil {
-		return cfg, err
+	return parse(files, l.profile)
+}
+
+// Path returns the config file path.
+func (l *Loader) Path() string {
+	return l.configPath
+}
+
+// ProjectPath returns the project file nearest to the working directory,
+// or "" if there is none.
+func (l *Loader) ProjectPath() string {
+	if l.dir == "" {
+		return ""
+	}
+	dir := l.dir
+	for {
+		path := filepath.Join(dir, ProjectFile)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
 	}
+}
 
-	return cfg, nil
+// Profile returns the profile set with SetProfile.
+func (l *Loader) Profile() string {
+	return l.profile
+}
+
+// SetProfile selects the profile to apply instead of the one named in the
+// config files; "" restores that one.
+func (l *Loader) SetProfile(name string) {
+	l.profile = name
 }
 
 // Save saves the configuration to file.
@@ -53,27 +104,124 @@ func (l *Loader) Save(cfg Config) error {
 		return err
 	}
 
-	// Marshal to YAML
+	// Marshal to YAML. Config only holds where API keys come from, never
+	// the keys, but a key pasted into another setting is caught here.
 	data, err := yaml.Marshal(cfg)
 	if err != nil {
 		return err
 	}
+	if containsSecret(data) {
+		return fmt.Errorf("not saving %s: it would contain an API key", l.configPath)
+	}
 
 	// Write to file
 	return os.WriteFile(l.configPath, data, 0644)
 }
 
-// getConfigPath returns the platform-specific config path.
-func getConfigPath() string {
-	// Check for XDG_CONFIG_HOME
-	if configDir := os.Getenv("XDG_CONFIG_HOME"); configDir != "" {
-		return filepath.Join(configDir, "vai", "config.yaml")
+// SaveValue sets the setting with the dotted key (e.g. layout.history) in
+// the user config file. A value already in the file is replaced where it
+// is, and a missing top-level section is appended, so the rest of the file
+// keeps its formatting and comments. Only the user file is written:
+// project files and profiles are not touched.
+func (l *Loader) SaveValue(key string, value any) error {
+	if l.configPath == "" {
+		return fmt.Errorf("cannot determine the config directory")
 	}
-
-	// Default to ~/.config/vai/config.yaml
-	home, err := os.UserHomeDir()
+	data, err := os.ReadFile(l.configPath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	data, err = setValue(data, key, value)
 	if err != nil {
+		return fmt.Errorf("not saving %s: %w", l.configPath, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(l.configPath), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(l.configPath, data, 0644)
+}
+
+// setValue returns the YAML document data with the dotted key set to value.
+func setValue(data []byte, key string, value any) ([]byte, error) {
+	var scalar yaml.Node
+	if err := scalar.Encode(value); err != nil {
+		return nil, err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if doc.Kind == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+	if doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("the file is not a mapping")
+	}
+
+	node := doc.Content[0]
+	parts := strings.Split(key, ".")
+	for i, part := range parts {
+		next := mapValue(node, part)
+		switch {
+		case next == nil && node == doc.Content[0]:
+			// Append the whole section, e.g. "layout:\n  history: false"
+			var text bytes.Buffer
+			for j, p := range parts[i:] {
+				text.WriteString(strings.Repeat("  ", j) + p + ":")
+				if j < len(parts[i:])-1 {
+					text.WriteString("\n")
+				}
+			}
+			text.WriteString(" " + scalar.Value + "\n")
+			if len(data) > 0 && !bytes.HasSuffix(data, []byte("\n")) {
+				data = append(data, '\n')
+			}
+			return append(data, text.Bytes()...), nil
+		case next == nil:
+			// A new key in an existing section: rewrite the file
+			return addValue(&doc, node, parts[i:], &scalar)
+		case i < len(parts)-1 && next.Kind != yaml.MappingNode:
+			return nil, fmt.Errorf("%s is not a mapping", strings.Join(parts[:i+1], "."))
+		case i == len(parts)-1:
+			if next.Kind != yaml.ScalarNode || next.Style != 0 {
+				return nil, fmt.Errorf("%s is not a plain value", key)
+			}
+			lines := bytes.SplitAfter(data, []byte("\n"))
+			line := lines[next.Line-1]
+			col := next.Column - 1
+			lines[next.Line-1] = slices.Concat(line[:col], []byte(scalar.Value), line[col+len(next.Value):])
+			return bytes.Join(lines, nil), nil
+		}
+		node = next
+	}
+	return data, nil
+}
+
+// addValue adds the keys below the mapping node and encodes the document
+// again, which keeps comments but not blank lines.
+func addValue(doc, node *yaml.Node, keys []string, scalar *yaml.Node) ([]byte, error) {
+	for i, key := range keys {
+		value := scalar
+		if i < len(keys)-1 {
+			value = &yaml.Node{Kind: yaml.MappingNode}
+		}
+		node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, value)
+		node = value
+	}
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// getConfigPath returns the platform-specific config path.
+func getConfigPath() string {
+	dir := GetConfigDir()
+	if dir == "" {
 		return ""
 	}
-	return filepath.Join(home, ".config", "vai", "config.yaml")
+	return filepath.Join(dir, "config.yaml")
 }
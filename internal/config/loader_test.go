@@ -0,0 +1,121 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSetValue(t *testing.T) {
+	tests := []struct {
+		name  string
+		data  string
+		key   string
+		value any
+		want  string
+	}{
+		{
+			name:  "replace in place",
+			data:  "# vai\n\nlayout:\n  # width\n  history_width: 0   # auto\n\nclipboard:\n  backend: auto\n",
+			key:   "layout.history_width",
+			value: 32,
+			want:  "# vai\n\nlayout:\n  # width\n  history_width: 32   # auto\n\nclipboard:\n  backend: auto\n",
+		},
+		{
+			name:  "replace a bool",
+			data:  "layout: {history: true}\n",
+			key:   "layout.history",
+			value: false,
+			want:  "layout: {history: false}\n",
+		},
+		{
+			name:  "append a section",
+			data:  "editor:\n  tab_width: 2",
+			key:   "layout.history",
+			value: false,
+			want:  "editor:\n  tab_width: 2\nlayout:\n  history: false\n",
+		},
+		{
+			name:  "empty file",
+			data:  "",
+			key:   "layout.input_height",
+			value: 3,
+			want:  "layout:\n  input_height: 3\n",
+		},
+		{
+			name:  "add a key to a section",
+			data:  "layout:\n  # shown\n  history: true\n",
+			key:   "layout.input_height",
+			value: 3,
+			want:  "layout:\n  # shown\n  history: true\n  input_height: 3\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := setValue([]byte(tt.data), tt.key, tt.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("setValue() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetValueErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		key  string
+	}{
+		{"syntax error", "layout: [\n", "layout.history"},
+		{"not a mapping", "- a\n- b\n", "layout.history"},
+		{"section is a value", "layout: big\n", "layout.history"},
+		{"quoted value", "layout:\n  history: \"true\"\n", "layout.history"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := setValue([]byte(tt.data), tt.key, true); err == nil {
+				t.Errorf("setValue(%q): no error", tt.data)
+			}
+		})
+	}
+}
+
+func TestSaveValueOnlyWritesTheUserFile(t *testing.T) {
+	dir := t.TempDir()
+	project := filepath.Join(dir, ProjectFile)
+	if err := os.WriteFile(project, []byte("layout:\n  input_height: 8\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	l := &Loader{configPath: filepath.Join(dir, "vai", "config.yaml"), dir: dir}
+
+	cfg, err := l.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := l.SaveValue("layout.history", false); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.SaveValue("layout.history_width", 30); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(project)
+	if err != nil || string(data) != "layout:\n  input_height: 8\n" {
+		t.Errorf("project file changed: %q, %v", data, err)
+	}
+	saved, err := l.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := cfg.Layout
+	want.History, want.HistoryWidth = false, 30
+	if saved.Layout != want {
+		t.Errorf("layout after saving = %+v, want %+v", saved.Layout, want)
+	}
+	if origin := saved.Origin("layout.history"); origin.Path != l.configPath {
+		t.Errorf("layout.history set in %s, want %s", origin, l.configPath)
+	}
+}
@@ -0,0 +1,49 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Origin is where a setting comes from.
+type Origin struct {
+	Path    string // file the setting is in, "" for a default
+	Line    int    // line in the file, 0 if unknown
+	Profile string // profile that set it, if any
+}
+
+// String formats the origin as path:line, "profile name (path:line)" or
+// "default". Paths in the home directory start with ~.
+func (o Origin) String() string {
+	if o.Path == "" {
+		return "default"
+	}
+	s := homeRelative(o.Path)
+	if o.Line > 0 {
+		s += ":" + strconv.Itoa(o.Line)
+	}
+	if o.Profile != "" {
+		s = "profile " + o.Profile + " (" + s + ")"
+	}
+	return s
+}
+
+// Origin returns where the setting with the dotted key (e.g.
+// editor.tab_width) was set. Settings no file sets have the default origin.
+func (c Config) Origin(key string) Origin {
+	return c.origins[key]
+}
+
+// homeRelative replaces the home directory at the start of path with ~.
+func homeRelative(path string) string {
+	home, err := os.UserHomeDir()
+	if err != nil || home == "" {
+		return path
+	}
+	if rel, ok := strings.CutPrefix(path, home+string(filepath.Separator)); ok {
+		return filepath.Join("~", rel)
+	}
+	return path
+}
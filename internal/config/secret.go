@@ -0,0 +1,276 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// redacted replaces secrets in text.
+const redacted = "[redacted]"
+
+// minRedactLen is the shortest secret Redact replaces; shorter values
+// would garble unrelated text and are not real keys anyway.
+const minRedactLen = 8
+
+// Secret is a resolved credential such as an API key. It formats as
+// [redacted] with every fmt verb, so it cannot leak through logs or error
+// messages; Reveal returns the value for the request that needs it.
+type Secret struct {
+	value string
+}
+
+// Reveal returns the secret value.
+func (s Secret) Reveal() string {
+	return s.value
+}
+
+// IsZero returns true if there is no secret.
+func (s Secret) IsZero() bool {
+	return s.value == ""
+}
+
+// Format writes [redacted] in place of the value.
+func (s Secret) Format(f fmt.State, verb rune) {
+	fmt.Fprint(f, redacted)
+}
+
+// secrets holds every secret resolved by this process, for Redact, and the
+// cached output of api_key_cmd commands.
+var secrets struct {
+	sync.Mutex
+	known map[string]bool
+	cmds  map[string]Secret
+}
+
+// newSecret registers value as a secret and returns it.
+func newSecret(value string) Secret {
+	secrets.Lock()
+	defer secrets.Unlock()
+	if secrets.known == nil {
+		secrets.known = make(map[string]bool)
+	}
+	if len(value) >= minRedactLen {
+		secrets.known[value] = true
+	}
+	return Secret{value: value}
+}
+
+// Redact replaces every secret resolved by this process in s.
+func Redact(s string) string {
+	secrets.Lock()
+	defer secrets.Unlock()
+	for value := range secrets.known {
+		s = strings.ReplaceAll(s, value, redacted)
+	}
+	return s
+}
+
+// RedactError returns err with the secrets in its message replaced. Errors
+// without secrets are returned unchanged.
+func RedactError(err error) error {
+	if err == nil {
+		return nil
+	}
+	msg := err.Error()
+	if clean := Redact(msg); clean != msg {
+		return &redactedError{msg: clean, err: err}
+	}
+	return err
+}
+
+// redactedError is an error whose message had secrets removed. The
+// original stays reachable through errors.Is and errors.As.
+type redactedError struct {
+	msg string
+	err error
+}
+
+func (e *redactedError) Error() string { return e.msg }
+func (e *redactedError) Unwrap() error { return e.err }
+
+// containsSecret returns true if data contains a resolved secret.
+func containsSecret(data []byte) bool {
+	secrets.Lock()
+	defer secrets.Unlock()
+	for value := range secrets.known {
+		if bytes.Contains(data, []byte(value)) {
+			return true
+		}
+	}
+	return false
+}
+
+// APIKey resolves the API key from api_key_env, api_key_cmd or
+// api_key_file, or else from the environment variable defaultEnv. A
+// command runs once per process; its first line of output is cached. No
+// key and no error means the provider is used without one.
+func (p ProviderConfig) APIKey(defaultEnv string) (Secret, error) {
+	switch {
+	case p.APIKeyEnv != "":
+		value, ok := os.LookupEnv(p.APIKeyEnv)
+		if !ok || value == "" {
+			return Secret{}, fmt.Errorf("api_key_env: %s is not set", p.APIKeyEnv)
+		}
+		return newSecret(value), nil
+	case p.APIKeyCmd != "":
+		return keyFromCommand(p.APIKeyCmd)
+	case p.APIKeyFile != "":
+		return keyFromFile(p.APIKeyFile)
+	case defaultEnv != "":
+		if value := os.Getenv(defaultEnv); value != "" {
+			return newSecret(value), nil
+		}
+	}
+	return Secret{}, nil
+}
+
+// keyFromCommand runs cmd with the shell, or returns its cached key.
+func keyFromCommand(cmd string) (Secret, error) {
+	secrets.Lock()
+	key, ok := secrets.cmds[cmd]
+	secrets.Unlock()
+	if ok {
+		return key, nil
+	}
+
+	var stderr bytes.Buffer
+	c := exec.Command("sh", "-c", cmd)
+	c.Stderr = &stderr
+	out, err := c.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			err = fmt.Errorf("%w: %s", err, firstLine(msg))
+		}
+		return Secret{}, RedactError(fmt.Errorf("api_key_cmd: %w", err))
+	}
+	value := firstLine(string(out))
+	if value == "" {
+		return Secret{}, fmt.Errorf("api_key_cmd: printed no key")
+	}
+
+	key = newSecret(value)
+	secrets.Lock()
+	if secrets.cmds == nil {
+		secrets.cmds = make(map[string]Secret)
+	}
+	secrets.cmds[cmd] = key
+	secrets.Unlock()
+	return key, nil
+}
+
+// keyFromFile reads the first line of path, which must not be accessible
+// by other users.
+func keyFromFile(path string) (Secret, error) {
+	path = expandHome(path)
+	if err := checkKeyFile(path); err != nil {
+		return Secret{}, fmt.Errorf("api_key_file: %w", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Secret{}, fmt.Errorf("api_key_file: %w", err)
+	}
+	value := firstLine(string(data))
+	if value == "" {
+		return Secret{}, fmt.Errorf("api_key_file: %s is empty", path)
+	}
+	return newSecret(value), nil
+}
+
+// checkKeyFile checks that a key file exists and that only its owner can
+// access it, as ssh does for private keys.
+func checkKeyFile(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if perm := info.Mode().Perm(); perm&0o077 != 0 {
+		return fmt.Errorf("%s is accessible by other users (mode %04o, want 0600)", path, perm)
+	}
+	return nil
+}
+
+// secretKeys are the key sources a project file cannot set, since a cloned
+// repository could use them to run commands or send other credentials.
+var secretKeys = []string{"api_key_env", "api_key_cmd", "api_key_file"}
+
+// removeSecretKeys removes the key sources from the provider settings of a
+// project file and of the profiles it defines.
+func removeSecretKeys(doc *yaml.Node, path string) []Issue {
+	var issues []Issue
+	strip := func(provider *yaml.Node, prefix string) {
+		if provider == nil || provider.Kind != yaml.MappingNode {
+			return
+		}
+		var kept []*yaml.Node
+		for i := 0; i+1 < len(provider.Content); i += 2 {
+			key := provider.Content[i]
+			if slices.Contains(secretKeys, key.Value) {
+				issues = append(issues, Issue{Path: path, Line: key.Line, Key: prefix + key.Value, Err: fmt.Errorf("not allowed in a project file")})
+				continue
+			}
+			kept = append(kept, provider.Content[i], provider.Content[i+1])
+		}
+		provider.Content = kept
+	}
+
+	strip(mapValue(doc, "provider"), "provider.")
+	if profiles := mapValue(doc, "profiles"); profiles != nil && profiles.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(profiles.Content); i += 2 {
+			name := profiles.Content[i].Value
+			strip(mapValue(profiles.Content[i+1], "provider"), "profiles."+name+".provider.")
+		}
+	}
+	return issues
+}
+
+// setsKeySource returns true if the provider settings in node set an API
+// key source.
+func setsKeySource(node *yaml.Node) bool {
+	provider := mapValue(node, "provider")
+	if provider == nil {
+		return false
+	}
+	for _, key := range secretKeys {
+		if mapValue(provider, key) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// mapValue returns the value of key in a mapping node, or nil.
+func mapValue(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// firstLine returns the first line of s without surrounding spaces.
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(strings.TrimLeft(s, "\r\n"), "\n")
+	return strings.TrimSpace(line)
+}
+
+// expandHome replaces a leading ~/ with the home directory.
+func expandHome(path string) string {
+	if strings.HasPrefix(path, "~"+string(filepath.Separator)) {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, path[2:])
+		}
+	}
+	return path
+}
@@ -0,0 +1,190 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSecretFormat(t *testing.T) {
+	s := Secret{value: "sk-test-0123456789"}
+	for _, verb := range []string{"%v", "%+v", "%#v", "%s", "%q", "%x", "%d"} {
+		if got := fmt.Sprintf(verb, s); got != redacted {
+			t.Errorf("Sprintf(%q) = %q, want %q", verb, got, redacted)
+		}
+	}
+	if got := fmt.Sprint(struct{ Key Secret }{s}); strings.Contains(got, s.value) {
+		t.Errorf("secret in a struct formats as %q", got)
+	}
+	if s.Reveal() != "sk-test-0123456789" {
+		t.Errorf("Reveal() = %q", s.Reveal())
+	}
+}
+
+// writeKeyFile writes a key file with the given mode and returns its path.
+func writeKeyFile(t *testing.T, content string, mode os.FileMode) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "key")
+	if err := os.WriteFile(path, []byte(content), mode); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestAPIKey(t *testing.T) {
+	t.Setenv("VAI_TEST_KEY", "sk-env-0123456789")
+	t.Setenv("VAI_TEST_DEFAULT", "sk-default-0123456789")
+	t.Setenv("VAI_TEST_EMPTY", "")
+
+	tests := []struct {
+		name     string
+		provider ProviderConfig
+		want     string
+		wantErr  string
+	}{
+		{name: "default variable", want: "sk-default-0123456789"},
+		{name: "api_key_env", provider: ProviderConfig{APIKeyEnv: "VAI_TEST_KEY"}, want: "sk-env-0123456789"},
+		{name: "unset api_key_env", provider: ProviderConfig{APIKeyEnv: "VAI_TEST_EMPTY"}, wantErr: "api_key_env: VAI_TEST_EMPTY is not set"},
+		{
+			name:     "api_key_cmd first line",
+			provider: ProviderConfig{APIKeyCmd: `printf '\n  sk-cmd-0123456789  \nsecond\n'`},
+			want:     "sk-cmd-0123456789",
+		},
+		{name: "api_key_cmd without output", provider: ProviderConfig{APIKeyCmd: "true"}, wantErr: "api_key_cmd: printed no key"},
+		{name: "failing api_key_cmd", provider: ProviderConfig{APIKeyCmd: "echo no key >&2; exit 3"}, wantErr: "api_key_cmd: exit status 3: no key"},
+		{
+			name:     "api_key_file",
+			provider: ProviderConfig{APIKeyFile: writeKeyFile(t, "sk-file-0123456789\n", 0600)},
+			want:     "sk-file-0123456789",
+		},
+		{name: "empty api_key_file", provider: ProviderConfig{APIKeyFile: writeKeyFile(t, "\n", 0600)}, wantErr: "is empty"},
+		{name: "shared api_key_file", provider: ProviderConfig{APIKeyFile: writeKeyFile(t, "sk-x\n", 0644)}, wantErr: "accessible by other users"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := tt.provider.APIKey("VAI_TEST_DEFAULT")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("APIKey() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if key.Reveal() != tt.want {
+				t.Errorf("APIKey() = %q, want %q", key.Reveal(), tt.want)
+			}
+		})
+	}
+}
+
+func TestRedact(t *testing.T) {
+	t.Setenv("VAI_TEST_REDACT", "sk-redact-0123456789")
+	t.Setenv("VAI_TEST_SHORT", "abc")
+	for _, env := range []string{"VAI_TEST_REDACT", "VAI_TEST_SHORT"} {
+		if _, err := (ProviderConfig{APIKeyEnv: env}).APIKey(""); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		in, want string
+	}{
+		{"401: bad key sk-redact-0123456789", "401: bad key [redacted]"},
+		{"Bearer sk-redact-0123456789, sk-redact-0123456789", "Bearer [redacted], [redacted]"},
+		{"abc is too short to redact", "abc is too short to redact"},
+		{"nothing secret", "nothing secret"},
+	}
+	for _, tt := range tests {
+		if got := Redact(tt.in); got != tt.want {
+			t.Errorf("Redact(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+
+	base := fmt.Errorf("open sk-redact-0123456789: %w", fs.ErrNotExist)
+	err := RedactError(base)
+	if err.Error() != "open [redacted]: file does not exist" {
+		t.Errorf("RedactError() = %q", err)
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Error("RedactError() lost the wrapped error")
+	}
+	if clean := errors.New("clean"); RedactError(clean) != clean {
+		t.Error("RedactError() replaced an error without secrets")
+	}
+}
+
+func TestKeySources(t *testing.T) {
+	tests := []struct {
+		name   string
+		user   string
+		proj   string
+		issues []string
+		check  func(p ProviderConfig) bool
+	}{
+		{
+			name:   "one key source",
+			user:   "provider:\n  api_key_env: A\n  api_key_cmd: b\n",
+			issues: []string{"u.yaml:1: provider: set only one of api_key_env, api_key_cmd and api_key_file"},
+		},
+		{
+			name:   "not from a project file",
+			user:   "provider:\n  api_key_env: MINE\n",
+			proj:   "provider:\n  model: gpt-4o\n  api_key_cmd: curl evil\n",
+			issues: []string{".vai.yaml:3: provider.api_key_cmd: not allowed in a project file"},
+			check: func(p ProviderConfig) bool {
+				return p.APIKeyCmd == "" && p.APIKeyEnv == "MINE" && p.Model == "gpt-4o"
+			},
+		},
+		{
+			name:   "not from a project profile",
+			proj:   "profiles:\n  work:\n    provider:\n      api_key_file: /tmp/k\n",
+			issues: []string{".vai.yaml:4: profiles.work.provider.api_key_file: not allowed in a project file"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := []source{{path: "u.yaml", data: []byte(tt.user)}}
+			if tt.proj != "" {
+				files = append(files, source{path: ".vai.yaml", data: []byte(tt.proj), overlay: true})
+			}
+			cfg, err := parse(files, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, issue := range cfg.Issues {
+				got = append(got, issue.Error())
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.issues, "\n") {
+				t.Errorf("issues = %q, want %q", got, tt.issues)
+			}
+			if tt.check != nil && !tt.check(cfg.Provider) {
+				t.Errorf("provider = %+v", cfg.Provider)
+			}
+		})
+	}
+}
+
+func TestAPIKeyFileCheck(t *testing.T) {
+	path := writeKeyFile(t, "sk-0123456789\n", 0644)
+	cfg := Parse([]byte("provider:\n  api_key_file: "+path+"\n"), "c.yaml")
+	if len(cfg.Issues) != 1 || cfg.Issues[0].Key != "provider.api_key_file" {
+		t.Errorf("issues = %v, want one for provider.api_key_file", cfg.Issues)
+	}
+
+	if err := os.Chmod(path, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if cfg := Parse([]byte("provider:\n  api_key_file: "+path+"\n"), "c.yaml"); len(cfg.Issues) > 0 {
+		t.Errorf("issues for a 0600 key file: %v", cfg.Issues)
+	}
+}
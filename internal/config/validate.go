@@ -0,0 +1,420 @@
+// Package config provides application configuration.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Issue is a problem found in the configuration. Issues are warnings: the
+// rest of the file still applies and the bad setting keeps its default.
+type Issue struct {
+	Path string // config file
+	Line int    // line in the file, 0 if unknown
+	Key  string // dotted key, e.g. editor.tab_width
+	Err  error
+
+	// Fatal is set when the file could not be parsed, so none of it
+	// applies.
+	Fatal bool
+}
+
+// Error formats the issue as path:line: key: message.
+func (i Issue) Error() string {
+	var sb strings.Builder
+	path := i.Path
+	if path == "" {
+		path = "config"
+	}
+	sb.WriteString(path)
+	if i.Line > 0 {
+		sb.WriteString(":" + strconv.Itoa(i.Line))
+	}
+	sb.WriteString(": ")
+	if i.Key != "" {
+		sb.WriteString(i.Key + ": ")
+	}
+	sb.WriteString(i.Err.Error())
+	return sb.String()
+}
+
+// Unwrap returns the underlying error.
+func (i Issue) Unwrap() error {
+	return i.Err
+}
+
+// IssueAt returns an issue for key, located at the file and line the key
+// was set on. Packages that check their own settings (themes, keybindings)
+// use it to report problems in the same form as the loader.
+func (c Config) IssueAt(key string, err error) Issue {
+	for k := key; k != ""; k = parentKey(k) {
+		if o, ok := c.origins[k]; ok {
+			return Issue{Path: o.Path, Line: o.Line, Key: key, Err: err}
+		}
+	}
+	return Issue{Path: c.Path, Key: key, Err: err}
+}
+
+// parentKey returns the key one level up, e.g. theme for theme.colors.
+func parentKey(key string) string {
+	i := strings.LastIndex(key, ".")
+	if i < 0 {
+		return ""
+	}
+	return key[:i]
+}
+
+// source is a config file to merge.
+type source struct {
+	path    string
+	data    []byte
+	overlay bool // a project file rather than the user config
+}
+
+// profileNode is the definition of a profile in one file.
+type profileNode struct {
+	name string
+	path string
+	node *yaml.Node
+}
+
+// Parse decodes a config file over the defaults. Unknown keys, values of
+// the wrong type and invalid settings are recorded in Config.Issues and
+// leave the defaults in place; a syntax error ignores the whole file.
+func Parse(data []byte, path string) Config {
+	cfg, _ := parse([]source{{path: path, data: data}}, "")
+	return cfg
+}
+
+// parse decodes files over the defaults in order, so each file overrides
+// the settings it sets: maps are merged key by key, values and lists are
+// replaced. The profile, or the one named by the files, is applied last.
+// Only an unknown profile passed by name is an error.
+func parse(files []source, profile string) (Config, error) {
+	cfg := DefaultConfig()
+	cfg.origins = make(map[string]Origin)
+
+	var profiles []profileNode
+	for _, f := range files {
+		if f.overlay {
+			cfg.Overlay = f.path
+		} else {
+			cfg.Path = f.path
+		}
+		profiles = append(profiles, cfg.decode(f)...)
+	}
+
+	// Definitions of a profile in several files are merged like the files
+	cfg.Profiles = make(map[string]Profile)
+	for _, p := range profiles {
+		prof := cfg.Profiles[p.name]
+		_ = p.node.Decode(&prof) // type errors were reported with the file
+		cfg.Profiles[p.name] = prof
+	}
+
+	name := profile
+	if name == "" {
+		name = cfg.Profile
+	}
+	if _, ok := cfg.Profiles[name]; name != "" && !ok {
+		err := fmt.Errorf("unknown profile %q", name)
+		if profile != "" {
+			return cfg, err
+		}
+		cfg.Issues = append(cfg.Issues, cfg.IssueAt("profile", err))
+		name = ""
+	}
+	cfg.Profile = name
+	for _, p := range profiles {
+		if p.name != name {
+			continue
+		}
+		checkKeys(p.node, reflect.TypeOf(Profile{}), "", Origin{Path: p.path, Profile: name}, cfg.origins)
+		if setsKeySource(p.node) {
+			// A key source of the profile replaces that of the file
+			cfg.Provider.APIKeyEnv, cfg.Provider.APIKeyCmd, cfg.Provider.APIKeyFile = "", "", ""
+		}
+		_ = p.node.Decode(&cfg)
+	}
+
+	cfg.Issues = append(cfg.Issues, cfg.validate()...)
+	order := make(map[string]int)
+	for i, f := range files {
+		order[f.path] = i
+	}
+	sort.SliceStable(cfg.Issues, func(i, j int) bool {
+		a, b := cfg.Issues[i], cfg.Issues[j]
+		if order[a.Path] != order[b.Path] {
+			return order[a.Path] < order[b.Path]
+		}
+		return a.Line < b.Line
+	})
+	return cfg, nil
+}
+
+// decode decodes one file over c, recording its issues and the origin of
+// every key it sets, and returns the profiles it defines.
+func (c *Config) decode(f source) []profileNode {
+	var root yaml.Node
+	if err := yaml.Unmarshal(f.data, &root); err != nil {
+		line, msg := yamlErrorLine(err.Error())
+		c.Issues = append(c.Issues, Issue{Path: f.path, Line: line, Err: errors.New(msg), Fatal: true})
+		return nil
+	}
+	if len(root.Content) == 0 {
+		return nil // empty file
+	}
+	doc := root.Content[0]
+	if f.overlay {
+		c.Issues = append(c.Issues, removeSecretKeys(doc, f.path)...)
+	}
+
+	c.Issues = append(c.Issues, checkKeys(doc, reflect.TypeOf(*c), "", Origin{Path: f.path}, c.origins)...)
+
+	bindings := c.Keybindings.modes()
+	counts := make([]int, len(bindings))
+	for i, b := range bindings {
+		counts[i] = len(*b)
+	}
+	if err := doc.Decode(c); err != nil {
+		var typeErr *yaml.TypeError
+		if !errors.As(err, &typeErr) {
+			line, msg := yamlErrorLine(err.Error())
+			c.Issues = append(c.Issues, Issue{Path: f.path, Line: line, Err: errors.New(msg)})
+		} else {
+			for _, e := range typeErr.Errors {
+				line, msg := yamlErrorLine(e)
+				c.Issues = append(c.Issues, Issue{Path: f.path, Line: line, Key: c.keyAt(f.path, line), Err: errors.New(msg)})
+			}
+		}
+	}
+	// Bindings of this file come after those of the files before it
+	for i, b := range bindings {
+		for j := counts[i]; j < len(*b); j++ {
+			(*b)[j].Path = f.path
+		}
+	}
+
+	return findProfiles(doc, f.path)
+}
+
+// findProfiles returns the profiles defined under the profiles key of doc.
+func findProfiles(doc *yaml.Node, path string) []profileNode {
+	var profiles []profileNode
+	for i := 0; i+1 < len(doc.Content); i += 2 {
+		key, value := doc.Content[i], doc.Content[i+1]
+		if key.Value != "profiles" || value.Kind != yaml.MappingNode {
+			continue
+		}
+		for j := 0; j+1 < len(value.Content); j += 2 {
+			if value.Content[j+1].Kind == yaml.MappingNode {
+				profiles = append(profiles, profileNode{name: value.Content[j].Value, path: path, node: value.Content[j+1]})
+			}
+		}
+	}
+	return profiles
+}
+
+// yamlLine matches the line number in yaml.v3 error messages.
+var yamlLine = regexp.MustCompile(`^(?:yaml: )?line (\d+): `)
+
+// yamlErrorLine splits a yaml.v3 error message into line and message.
+func yamlErrorLine(msg string) (int, string) {
+	m := yamlLine.FindStringSubmatch(msg)
+	if m == nil {
+		return 0, strings.TrimPrefix(msg, "yaml: ")
+	}
+	line, _ := strconv.Atoi(m[1])
+	return line, msg[len(m[0]):]
+}
+
+// keyAt returns the deepest key set on line of the file path, or "".
+func (c Config) keyAt(path string, line int) string {
+	best := ""
+	for key, o := range c.origins {
+		if o.Path == path && o.Line == line && len(key) > len(best) {
+			best = key
+		}
+	}
+	return best
+}
+
+// unmarshalerType is implemented by types that decode themselves, such as
+// ModeBindings; their keys are checked when they are decoded.
+var unmarshalerType = reflect.TypeOf((*yaml.Unmarshaler)(nil)).Elem()
+
+// checkKeys reports keys of node that have no field in typ, recording the
+// origin of every key it visits.
+func checkKeys(node *yaml.Node, typ reflect.Type, prefix string, origin Origin, origins map[string]Origin) []Issue {
+	if node.Kind != yaml.MappingNode || reflect.PointerTo(typ).Implements(unmarshalerType) {
+		return nil
+	}
+
+	var issues []Issue
+	fields := yamlFields(typ)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		name := prefix + key.Value
+		origin.Line = key.Line
+		origins[name] = origin
+
+		switch typ.Kind() {
+		case reflect.Struct:
+			field, ok := fields[key.Value]
+			if !ok {
+				err := fmt.Errorf("unknown key")
+				if s := suggest(key.Value, fields); s != "" {
+					err = fmt.Errorf("unknown key (did you mean %s?)", s)
+				}
+				issues = append(issues, Issue{Path: origin.Path, Line: key.Line, Key: name, Err: err})
+				continue
+			}
+			issues = append(issues, checkKeys(value, field, name+".", origin, origins)...)
+		case reflect.Map:
+			issues = append(issues, checkKeys(value, typ.Elem(), name+".", origin, origins)...)
+		}
+	}
+	return issues
+}
+
+// yamlFields maps the YAML names of a struct's fields to their types.
+func yamlFields(typ reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	if typ.Kind() != reflect.Struct {
+		return fields
+	}
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		if name == "" || name == "-" || !f.IsExported() {
+			continue
+		}
+		fields[name] = f.Type
+	}
+	return fields
+}
+
+// suggest returns the field name closest to a misspelled key, if any is
+// within two edits.
+func suggest(key string, fields map[string]reflect.Type) string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	best, bestDist := "", 3
+	for _, name := range names {
+		if d := editDistance(key, name); d < bestDist {
+			best, bestDist = name, d
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// validate checks the values of the settings owned by this package and
+// resets invalid ones to their defaults. Themes, keybindings, the
+// clipboard backend and the provider name are checked where they are used.
+func (c *Config) validate() []Issue {
+	def := DefaultConfig()
+	var issues []Issue
+	fail := func(key string, err error) {
+		issues = append(issues, c.IssueAt(key, err))
+	}
+
+	if c.Editor.TabWidth < 1 || c.Editor.TabWidth > 16 {
+		fail("editor.tab_width", fmt.Errorf("must be between 1 and 16, got %d", c.Editor.TabWidth))
+		c.Editor.TabWidth = def.Editor.TabWidth
+	}
+
+	if c.Layout.HistoryWidth != 0 && c.Layout.HistoryWidth < 10 {
+		fail("layout.history_width", fmt.Errorf("must be 0 (auto) or at least 10, got %d", c.Layout.HistoryWidth))
+		c.Layout.HistoryWidth = def.Layout.HistoryWidth
+	}
+	if c.Layout.InputHeight < 1 {
+		fail("layout.input_height", fmt.Errorf("must be at least 1, got %d", c.Layout.InputHeight))
+		c.Layout.InputHeight = def.Layout.InputHeight
+	}
+	if c.Layout.InputMaxHeight < c.Layout.InputHeight {
+		fail("layout.input_max_height", fmt.Errorf("is smaller than input_height (%d < %d)", c.Layout.InputMaxHeight, c.Layout.InputHeight))
+		c.Layout.InputMaxHeight = c.Layout.InputHeight
+	}
+	if c.Layout.VerticalBelow < 0 {
+		fail("layout.vertical_below", fmt.Errorf("must not be negative, got %d", c.Layout.VerticalBelow))
+		c.Layout.VerticalBelow = def.Layout.VerticalBelow
+	}
+
+	if c.Provider.Model == "" {
+		fail("provider.model", fmt.Errorf("must not be empty"))
+		c.Provider.Model = def.Provider.Model
+	}
+	if c.Provider.BaseURL != "" {
+		u, err := url.Parse(c.Provider.BaseURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			fail("provider.base_url", fmt.Errorf("must be an http or https URL, got %q", c.Provider.BaseURL))
+			c.Provider.BaseURL = ""
+		}
+	}
+
+	sources := 0
+	for _, s := range []string{c.Provider.APIKeyEnv, c.Provider.APIKeyCmd, c.Provider.APIKeyFile} {
+		if s != "" {
+			sources++
+		}
+	}
+	if sources > 1 {
+		fail("provider", fmt.Errorf("set only one of api_key_env, api_key_cmd and api_key_file"))
+	}
+	if c.Provider.APIKeyFile != "" {
+		if err := checkKeyFile(expandHome(c.Provider.APIKeyFile)); err != nil {
+			fail("provider.api_key_file", err)
+		}
+	}
+
+	if c.Attachments.MaxFileSize < 1 {
+		fail("attachments.max_file_size", fmt.Errorf("must be at least 1 (KiB), got %d", c.Attachments.MaxFileSize))
+		c.Attachments.MaxFileSize = def.Attachments.MaxFileSize
+	}
+	if c.Attachments.MaxTotalSize < 1 {
+		fail("attachments.max_total_size", fmt.Errorf("must be at least 1 (KiB), got %d", c.Attachments.MaxTotalSize))
+		c.Attachments.MaxTotalSize = def.Attachments.MaxTotalSize
+	}
+	if c.Attachments.MaxTotalSize < c.Attachments.MaxFileSize {
+		fail("attachments.max_total_size", fmt.Errorf("is smaller than max_file_size (%d < %d)", c.Attachments.MaxTotalSize, c.Attachments.MaxFileSize))
+	}
+	if c.Attachments.WarnTokens < 0 {
+		fail("attachments.warn_tokens", fmt.Errorf("must not be negative, got %d", c.Attachments.WarnTokens))
+		c.Attachments.WarnTokens = def.Attachments.WarnTokens
+	}
+
+	return issues
+}
@@ -0,0 +1,107 @@
+package config
+
+import "testing"
+
+func TestParseIssues(t *testing.T) {
+	tests := []struct {
+		name  string
+		yaml  string
+		issue string // the only issue expected, "" for none
+		fatal bool
+		check func(cfg Config) bool // the config after the issue
+	}{
+		{
+			name: "valid",
+			yaml: "editor:\n  tab_width: 2\nlayout:\n  history: false\n",
+			check: func(cfg Config) bool {
+				return cfg.Editor.TabWidth == 2 && !cfg.Layout.History
+			},
+		},
+		{
+			name:  "out of range resets to the default",
+			yaml:  "editor:\n  tab_width: 0\n",
+			issue: "c.yaml:2: editor.tab_width: must be between 1 and 16, got 0",
+			check: func(cfg Config) bool { return cfg.Editor.TabWidth == DefaultConfig().Editor.TabWidth },
+		},
+		{
+			name:  "unknown key suggests a known one",
+			yaml:  "editr:\n  tab_width: 2\n",
+			issue: "c.yaml:1: editr: unknown key (did you mean editor?)",
+		},
+		{
+			name:  "wrong type",
+			yaml:  "editor:\n  tab_width: x\n",
+			issue: "c.yaml:2: editor.tab_width: cannot unmarshal !!str `x` into int",
+		},
+		{
+			name:  "syntax error ignores the file",
+			yaml:  "editor:\n  tab_width: 2\nlayout: [\n",
+			issue: "c.yaml:3: did not find expected node content",
+			fatal: true,
+			check: func(cfg Config) bool { return cfg.Editor.TabWidth == DefaultConfig().Editor.TabWidth },
+		},
+		{
+			name:  "input_max_height below input_height",
+			yaml:  "layout:\n  input_height: 5\n  input_max_height: 2\n",
+			issue: "c.yaml:3: layout.input_max_height: is smaller than input_height (2 < 5)",
+			check: func(cfg Config) bool { return cfg.Layout.InputMaxHeight == 5 },
+		},
+		{
+			name:  "history_width too small",
+			yaml:  "layout:\n  history_width: 5\n",
+			issue: "c.yaml:2: layout.history_width: must be 0 (auto) or at least 10, got 5",
+			check: func(cfg Config) bool { return cfg.Layout.HistoryWidth == 0 },
+		},
+		{
+			name:  "empty model",
+			yaml:  "provider:\n  model: \"\"\n",
+			issue: "c.yaml:2: provider.model: must not be empty",
+			check: func(cfg Config) bool { return cfg.Provider.Model == DefaultConfig().Provider.Model },
+		},
+		{
+			name:  "base_url must be http",
+			yaml:  "provider:\n  base_url: ftp://example.com\n",
+			issue: `c.yaml:2: provider.base_url: must be an http or https URL, got "ftp://example.com"`,
+			check: func(cfg Config) bool { return cfg.Provider.BaseURL == "" },
+		},
+		{
+			name:  "attachment limits",
+			yaml:  "attachments:\n  max_file_size: 100\n  max_total_size: 50\n",
+			issue: "c.yaml:3: attachments.max_total_size: is smaller than max_file_size (50 < 100)",
+		},
+		{
+			name:  "negative warn_tokens",
+			yaml:  "attachments:\n  warn_tokens: -1\n",
+			issue: "c.yaml:2: attachments.warn_tokens: must not be negative, got -1",
+			check: func(cfg Config) bool { return cfg.Attachments.WarnTokens == DefaultConfig().Attachments.WarnTokens },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Parse([]byte(tt.yaml), "c.yaml")
+			switch {
+			case tt.issue == "" && len(cfg.Issues) > 0:
+				t.Fatalf("unexpected issues: %v", cfg.Issues)
+			case tt.issue != "" && len(cfg.Issues) != 1:
+				t.Fatalf("issues = %v, want %q", cfg.Issues, tt.issue)
+			case tt.issue != "":
+				if got := cfg.Issues[0]; got.Error() != tt.issue || got.Fatal != tt.fatal {
+					t.Errorf("issue = %q (fatal %v), want %q (fatal %v)", got.Error(), got.Fatal, tt.issue, tt.fatal)
+				}
+			}
+			if tt.check != nil && !tt.check(cfg) {
+				t.Errorf("config not as expected after %q", tt.yaml)
+			}
+		})
+	}
+}
+
+func TestDefaultFileIsValid(t *testing.T) {
+	cfg := Parse([]byte(DefaultFile()), "config.yaml")
+	if len(cfg.Issues) > 0 {
+		t.Fatalf("default file has issues: %v", cfg.Issues)
+	}
+	if cfg.Editor != DefaultConfig().Editor || cfg.Layout != DefaultConfig().Layout {
+		t.Error("default file does not match DefaultConfig")
+	}
+}
@@ -0,0 +1,63 @@
+package config
+
+import (
+	"os"
+	"sync"
+)
+
+// Watcher detects changes to files by polling their modification time and
+// size, so it works the same everywhere without file notification support.
+// A file that is created or removed counts as changed.
+type Watcher struct {
+	paths []string
+
+	mu     sync.Mutex
+	stamps map[string]stamp
+}
+
+// stamp is what Watcher compares between polls.
+type stamp struct {
+	modTime int64
+	size    int64
+	exists  bool
+}
+
+// NewWatcher creates a watcher for paths, taking their current state as
+// unchanged.
+func NewWatcher(paths ...string) *Watcher {
+	w := &Watcher{paths: paths, stamps: make(map[string]stamp)}
+	w.Changed()
+	return w
+}
+
+// Changed returns true if any of the files changed since the last call.
+func (w *Watcher) Changed() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	changed := false
+	for _, path := range w.paths {
+		s := statFile(path)
+		if s != w.stamps[path] {
+			w.stamps[path] = s
+			changed = true
+		}
+	}
+	return changed
+}
+
+// Skip takes the current state of path as unchanged, for a file the
+// program wrote itself.
+func (w *Watcher) Skip(path string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.stamps[path] = statFile(path)
+}
+
+// statFile returns the current stamp of path.
+func statFile(path string) stamp {
+	info, err := os.Stat(path)
+	if err != nil {
+		return stamp{}
+	}
+	return stamp{modTime: info.ModTime().UnixNano(), size: info.Size(), exists: true}
+}
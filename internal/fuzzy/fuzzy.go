@@ -0,0 +1,64 @@
+// Package fuzzy ranks strings against a typed query, as used by file
+// mention completion and the pickers.
+package fuzzy
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Score matches query as a case-insensitive subsequence of s. Consecutive
+// characters and matches at the start of words or path elements score
+// higher; longer strings score slightly lower. An empty query matches
+// everything, shortest first.
+func Score(query, s string) (int, bool) {
+	if query == "" {
+		return -len(s), true
+	}
+	q := []rune(strings.ToLower(query))
+	r := []rune(s)
+	score, qi, prev := 0, 0, -2
+	for i := 0; i < len(r) && qi < len(q); i++ {
+		if unicode.ToLower(r[i]) != q[qi] {
+			continue
+		}
+		score++
+		if prev == i-1 {
+			score += 5 // consecutive
+		}
+		if i == 0 || strings.ContainsRune("/._- ", r[i-1]) {
+			score += 3 // start of a path element or word
+		}
+		prev = i
+		qi++
+	}
+	if qi < len(q) {
+		return 0, false
+	}
+	return score - len(r)/10, true
+}
+
+// Filter returns the items that match query, best matches first. Equal
+// scores keep their original order.
+func Filter(query string, items []string) []string {
+	type scored struct {
+		item  string
+		score int
+	}
+	var found []scored
+	for _, item := range items {
+		if s, ok := Score(query, item); ok {
+			found = append(found, scored{item, s})
+		}
+	}
+	sort.SliceStable(found, func(i, j int) bool {
+		return found[i].score > found[j].score
+	})
+
+	out := make([]string, len(found))
+	for i, f := range found {
+		out[i] = f.item
+	}
+	return out
+}
@@ -0,0 +1,79 @@
+package fuzzy
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestScore(t *testing.T) {
+	tests := []struct {
+		query, s string
+		match    bool
+	}{
+		{"", "anything", true},
+		{"rev", "review", true},
+		{"RV", "review", true},
+		{"rdf", "review-diff", true},
+		{"vr", "review", false},
+		{"reviews", "review", false},
+		{"é", "café", true},
+	}
+	for _, tt := range tests {
+		if _, ok := Score(tt.query, tt.s); ok != tt.match {
+			t.Errorf("Score(%q, %q) matches = %v, want %v", tt.query, tt.s, ok, tt.match)
+		}
+	}
+}
+
+func TestFilter(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		items []string
+		want  []string
+	}{
+		{
+			name:  "empty query is shortest first",
+			query: "",
+			items: []string{"explain-code", "fix", "review"},
+			want:  []string{"fix", "review", "explain-code"},
+		},
+		{
+			name:  "non-matches are dropped",
+			query: "rv",
+			items: []string{"review", "fix", "rust-verify"},
+			want:  []string{"rust-verify", "review"},
+		},
+		{
+			name:  "consecutive beats scattered",
+			query: "doc",
+			items: []string{"d-o-c", "docs"},
+			want:  []string{"docs", "d-o-c"},
+		},
+		{
+			name:  "word starts beat the middle",
+			query: "m",
+			items: []string{"ham", "main.go"},
+			want:  []string{"main.go", "ham"},
+		},
+		{
+			name:  "path elements count as word starts",
+			query: "gm",
+			items: []string{"programs", "git/main.go"},
+			want:  []string{"git/main.go", "programs"},
+		},
+		{
+			name:  "ties keep their order",
+			query: "a",
+			items: []string{"ab", "ac", "ad"},
+			want:  []string{"ab", "ac", "ad"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Filter(tt.query, tt.items); !slices.Equal(got, tt.want) {
+				t.Errorf("Filter(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+		})
+	}
+}
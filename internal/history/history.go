@@ -0,0 +1,112 @@
+// Package history keeps the prompts sent in all sessions, newest last, and
+// persists them to the data directory.
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fingergohappy/vai/internal/config"
+)
+
+// maxEntries is the number of prompts kept.
+const maxEntries = 1000
+
+// Entry is a sent prompt.
+type Entry struct {
+	Text    string    `json:"text"`
+	Session string    `json:"session,omitempty"`
+	Time    time.Time `json:"time"`
+}
+
+// History is the global prompt history.
+type History struct {
+	entries []Entry
+	path    string
+}
+
+// New creates a history persisted to path. An empty path disables
+// persistence.
+func New(path string) *History {
+	return &History{path: path}
+}
+
+// DefaultPath returns the history file in the data directory.
+func DefaultPath() string {
+	return filepath.Join(config.GetDataDir(), "history.json")
+}
+
+// Add appends a prompt, dropping an older identical entry, and saves the
+// history.
+func (h *History) Add(text, sessionID string) error {
+	if strings.TrimSpace(text) == "" {
+		return nil
+	}
+	for i, e := range h.entries {
+		if e.Text == text {
+			h.entries = append(h.entries[:i], h.entries[i+1:]...)
+			break
+		}
+	}
+	h.entries = append(h.entries, Entry{Text: text, Session: sessionID, Time: time.Now()})
+	if len(h.entries) > maxEntries {
+		h.entries = h.entries[len(h.entries)-maxEntries:]
+	}
+	return h.Save()
+}
+
+// Entries returns all entries, oldest first.
+func (h *History) Entries() []Entry {
+	return h.entries
+}
+
+// Texts returns the prompt texts, oldest first.
+func (h *History) Texts() []string {
+	texts := make([]string, len(h.entries))
+	for i, e := range h.entries {
+		texts[i] = e.Text
+	}
+	return texts
+}
+
+// Load reads the history from disk. A missing file is not an error.
+func (h *History) Load() error {
+	if h.path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(h.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var entries []Entry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return fmt.Errorf("parse %s: %w", h.path, err)
+	}
+	h.entries = entries
+	return nil
+}
+
+// Save writes the history to disk.
+func (h *History) Save() error {
+	if h.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(h.entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(h.path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(h.path, data, 0600)
+}
@@ -0,0 +1,73 @@
+package history
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestAdd(t *testing.T) {
+	tests := []struct {
+		name string
+		add  []string
+		want []string
+	}{
+		{"oldest first", []string{"one", "two"}, []string{"one", "two"}},
+		{"blank prompts are skipped", []string{"one", "  \n", ""}, []string{"one"}},
+		{"repeats move to the end", []string{"one", "two", "one"}, []string{"two", "one"}},
+		{"whitespace makes a different prompt", []string{"one", "one "}, []string{"one", "one "}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := New("")
+			for _, text := range tt.add {
+				if err := h.Add(text, "s1"); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got := h.Texts(); !slices.Equal(got, tt.want) {
+				t.Errorf("Texts() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddKeepsMaxEntries(t *testing.T) {
+	h := New("")
+	for i := range maxEntries + 5 {
+		if err := h.Add(fmt.Sprint(i), ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	texts := h.Texts()
+	if len(texts) != maxEntries || texts[0] != "5" || texts[len(texts)-1] != fmt.Sprint(maxEntries+4) {
+		t.Errorf("kept %d entries from %q to %q", len(texts), texts[0], texts[len(texts)-1])
+	}
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data", "history.json")
+	h := New(path)
+	for _, text := range []string{"first", "second\nline"} {
+		if err := h.Add(text, "s1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	loaded := New(path)
+	if err := loaded.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if got := loaded.Texts(); !slices.Equal(got, []string{"first", "second\nline"}) {
+		t.Errorf("loaded %q", got)
+	}
+	if e := loaded.Entries()[0]; e.Session != "s1" || e.Time.IsZero() {
+		t.Errorf("loaded entry %+v", e)
+	}
+
+	missing := New(filepath.Join(t.TempDir(), "none.json"))
+	if err := missing.Load(); err != nil || len(missing.Entries()) != 0 {
+		t.Errorf("Load() of a missing file = %v with %d entries", err, len(missing.Entries()))
+	}
+}
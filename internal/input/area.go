@@ -2,8 +2,11 @@
 package input
 
 import (
+	"strings"
+
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/x/ansi"
 )
 
 // Model is the input area Bubble Tea Model.
@@ -20,6 +23,19 @@ type Model struct {
 
 	// Ready indicates if the model is initialized.
 	ready bool
+
+	// undo and redo hold states for NORMAL mode u and Ctrl-r
+	undo []snapshot
+	redo []snapshot
+
+	// history holds sent prompts, oldest first; histIdx is the entry
+	// shown while browsing (len(history) = the draft typed before)
+	history []string
+	histIdx int
+	draft   string
+
+	// search is the active Ctrl-s reverse search, if any
+	search *search
 }
 
 // NewModel creates a new input area model.
@@ -48,6 +64,12 @@ func (m Model) Init() tea.Cmd {
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
 
+	if key, ok := msg.(tea.KeyMsg); ok && m.search != nil {
+		if m.updateSearch(key) {
+			return m, nil
+		}
+	}
+
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
 		m.textarea.SetWidth(msg.Width)
@@ -83,6 +105,34 @@ func (m *Model) SetValue(value string) {
 	m.textarea.SetValue(value)
 }
 
+// Replace sets the input value as a single undoable change.
+func (m *Model) Replace(value string) {
+	m.saveUndo()
+	m.textarea.SetValue(value)
+}
+
+// WordBeforeCursor returns the blank-separated word ending at the cursor.
+func (m *Model) WordBeforeCursor() string {
+	b := m.buffer()
+	return string(b.runes[b.wordStart():b.pos])
+}
+
+// ReplaceWordBeforeCursor replaces the word ending at the cursor with text,
+// e.g. to complete it.
+func (m *Model) ReplaceWordBeforeCursor(text string) {
+	b := m.buffer()
+	start := b.wordStart()
+	ins := []rune(text)
+	b.runes = append(b.runes[:start:start], append(ins, b.runes[b.pos:]...)...)
+	b.pos = start + len(ins)
+	m.apply(b)
+}
+
+// InsertString inserts text at the cursor.
+func (m *Model) InsertString(text string) {
+	m.textarea.InsertString(text)
+}
+
 // Focus focuses the input area.
 func (m *Model) Focus() {
 	m.focused = true
@@ -105,9 +155,31 @@ func (m *Model) Reset() {
 	m.textarea.Reset()
 }
 
+// Load replaces the input text with an unrelated prompt, e.g. the draft of
+// another session, discarding undo states and history browsing.
+func (m *Model) Load(value string) {
+	m.textarea.SetValue(value)
+	m.undo, m.redo = nil, nil
+	m.histIdx, m.draft = len(m.history), ""
+	m.search = nil
+}
+
 // SetSize sets the size of the input area.
 func (m *Model) SetSize(width, height int) {
 	m.textarea.SetWidth(width)
 	m.textarea.SetHeight(height)
 	m.ready = true
 }
+
+// Rows returns the number of rows the text takes when wrapped at width.
+// It counts character wrapping, so word wrapping may need a row more.
+func (m Model) Rows(width int) int {
+	if width < 1 {
+		width = 1
+	}
+	rows := 0
+	for _, line := range strings.Split(m.textarea.Value(), "\n") {
+		rows += max((ansi.StringWidth(line)+width-1)/width, 1)
+	}
+	return rows
+}
@@ -0,0 +1,145 @@
+package input
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// search is an incremental reverse search through the prompt history.
+type search struct {
+	query  string
+	idx    int    // index of the current match, len(history) = none
+	failed bool   // the query has no (further) match
+	orig   string // input value before the search, restored on cancel
+}
+
+// SetHistory replaces the prompt history, oldest first, and starts
+// browsing from the draft again.
+func (m *Model) SetHistory(entries []string) {
+	m.history = entries
+	m.histIdx = len(entries)
+	m.draft = ""
+}
+
+// HistoryPrev moves the cursor up a line, or recalls the previous prompt
+// when it is on the first line (Up, Ctrl-p).
+func (m *Model) HistoryPrev() {
+	if m.textarea.Line() > 0 {
+		m.textarea.CursorUp()
+		return
+	}
+	m.browse(-1)
+}
+
+// HistoryNext moves the cursor down a line, or recalls the next prompt
+// when it is on the last line (Down, Ctrl-n). Moving past the newest entry
+// restores the draft.
+func (m *Model) HistoryNext() {
+	if m.textarea.Line() < m.textarea.LineCount()-1 {
+		m.textarea.CursorDown()
+		return
+	}
+	m.browse(1)
+}
+
+// browse moves delta entries through the history, keeping the text typed
+// before browsing as the draft.
+func (m *Model) browse(delta int) {
+	idx := max(0, min(m.histIdx+delta, len(m.history)))
+	if idx == m.histIdx {
+		return
+	}
+	if m.histIdx == len(m.history) {
+		m.draft = m.textarea.Value()
+	}
+	m.histIdx = idx
+	if idx == len(m.history) {
+		m.textarea.SetValue(m.draft)
+	} else {
+		m.textarea.SetValue(m.history[idx])
+	}
+}
+
+// StartSearch begins an incremental reverse search (Ctrl-s). Keys go to
+// the search until it is accepted or cancelled.
+func (m *Model) StartSearch() {
+	m.search = &search{idx: len(m.history), orig: m.textarea.Value()}
+}
+
+// Searching returns true while a reverse search is active.
+func (m Model) Searching() bool {
+	return m.search != nil
+}
+
+// SearchStatus describes the active search for the command line, like a
+// shell's reverse-i-search prompt.
+func (m Model) SearchStatus() string {
+	if m.search == nil {
+		return ""
+	}
+	label := "reverse-i-search"
+	if m.search.failed {
+		label = "failing " + label
+	}
+	return fmt.Sprintf("(%s)`%s'", label, m.search.query)
+}
+
+// updateSearch handles a key during a reverse search. Ctrl-s (or Ctrl-r)
+// finds the next older match, Enter and Esc accept it and Ctrl-g cancels;
+// other special keys accept it and return false so the textarea handles
+// them as usual.
+func (m *Model) updateSearch(key tea.KeyMsg) bool {
+	s := m.search
+	switch key.Type {
+	case tea.KeyRunes, tea.KeySpace:
+		s.query += string(key.Runes)
+		m.findMatch(s.idx)
+	case tea.KeyBackspace:
+		if s.query != "" {
+			r := []rune(s.query)
+			s.query = string(r[:len(r)-1])
+		}
+		m.findMatch(len(m.history) - 1)
+	case tea.KeyCtrlS, tea.KeyCtrlR:
+		m.findMatch(s.idx - 1)
+	case tea.KeyCtrlG:
+		m.textarea.SetValue(s.orig)
+		m.search = nil
+	case tea.KeyEnter, tea.KeyEsc:
+		m.acceptSearch()
+	default:
+		m.acceptSearch()
+		return false
+	}
+	return true
+}
+
+// findMatch shows the newest entry at or before from containing the query.
+func (m *Model) findMatch(from int) {
+	s := m.search
+	s.failed = false
+	if s.query == "" {
+		return
+	}
+	for i := min(from, len(m.history)-1); i >= 0; i-- {
+		if strings.Contains(m.history[i], s.query) {
+			s.idx = i
+			m.textarea.SetValue(m.history[i])
+			return
+		}
+	}
+	s.failed = true
+}
+
+// acceptSearch ends the search, keeping the match as the input.
+func (m *Model) acceptSearch() {
+	if m.search.idx < len(m.history) {
+		if m.histIdx == len(m.history) {
+			m.draft = m.search.orig
+		}
+		m.histIdx = m.search.idx
+	}
+	m.search = nil
+}
@@ -0,0 +1,257 @@
+// Package input provides the input area component.
+package input
+
+import "strings"
+
+// InsertAt tells BeginInsert where INSERT mode starts.
+type InsertAt int
+
+const (
+	InsertAtCursor    InsertAt = iota // i
+	InsertAfterCursor                 // a
+	InsertLineStart                   // I
+	InsertLineEnd                     // A
+	InsertBelow                       // o
+	InsertAbove                       // O
+)
+
+// maxUndo is the number of undo steps kept.
+const maxUndo = 100
+
+// snapshot is an undo state.
+type snapshot struct {
+	value string
+	pos   int
+}
+
+// buffer reads the textarea contents and cursor.
+func (m *Model) buffer() *buffer {
+	b := &buffer{runes: []rune(m.textarea.Value())}
+	li := m.textarea.LineInfo()
+	b.pos = b.offset(m.textarea.Line(), li.StartColumn+li.ColumnOffset)
+	return b
+}
+
+// setCursor moves the textarea cursor to offset pos.
+func (m *Model) setCursor(b *buffer) {
+	row, col := b.rowCol(b.pos)
+	for i := 0; m.textarea.Line() > row && i < len(b.runes); i++ {
+		m.textarea.CursorUp()
+	}
+	for i := 0; m.textarea.Line() < row && i < len(b.runes); i++ {
+		m.textarea.CursorDown()
+	}
+	m.textarea.SetCursor(col)
+}
+
+// apply writes the buffer back into the textarea.
+func (m *Model) apply(b *buffer) {
+	m.textarea.SetValue(string(b.runes))
+	m.setCursor(b)
+}
+
+// saveUndo records the current state before a change.
+func (m *Model) saveUndo() {
+	b := m.buffer()
+	m.undo = append(m.undo, snapshot{value: string(b.runes), pos: b.pos})
+	if len(m.undo) > maxUndo {
+		m.undo = m.undo[len(m.undo)-maxUndo:]
+	}
+	m.redo = nil
+}
+
+// Undo reverts the last change (u). It returns false if there is none.
+func (m *Model) Undo() bool {
+	return m.step(&m.undo, &m.redo)
+}
+
+// Redo reapplies the last undone change (Ctrl-r). It returns false if
+// there is none.
+func (m *Model) Redo() bool {
+	return m.step(&m.redo, &m.undo)
+}
+
+// step pops a state from one stack, pushing the current state on the other.
+func (m *Model) step(from, to *[]snapshot) bool {
+	if len(*from) == 0 {
+		return false
+	}
+	cur := m.buffer()
+	*to = append(*to, snapshot{value: string(cur.runes), pos: cur.pos})
+	s := (*from)[len(*from)-1]
+	*from = (*from)[:len(*from)-1]
+
+	b := &buffer{runes: []rune(s.value), pos: s.pos}
+	b.clampNormal()
+	m.apply(b)
+	return true
+}
+
+// Move moves the cursor with a NORMAL mode motion. It returns false for
+// unknown motions and text objects.
+func (m *Model) Move(motion string, count int) bool {
+	mo, ok := lookupMotion(motion)
+	if !ok || mo.kind == object {
+		return false
+	}
+	b := m.buffer()
+	b.pos = mo.move(b, motionCount(motion, count))
+	b.clampNormal()
+	m.setCursor(b)
+	return true
+}
+
+// Delete deletes the text covered by a motion (d{motion}) and returns it.
+// Linewise text ends in a newline.
+func (m *Model) Delete(motion string, count int) (string, bool) {
+	return m.cut(motion, count, false)
+}
+
+// Change deletes the text covered by a motion (c{motion}) and leaves the
+// cursor where INSERT mode should start.
+func (m *Model) Change(motion string, count int) (string, bool) {
+	return m.cut(motion, count, true)
+}
+
+// cut removes a motion's range, saving an undo step.
+func (m *Model) cut(motion string, count int, change bool) (string, bool) {
+	b := m.buffer()
+	start, end, lines, ok := b.span(motion, count, change)
+	if !ok {
+		return "", false
+	}
+	text := string(b.runes[start:end])
+	if lines {
+		text = strings.Trim(text, "\n") + "\n"
+		if change && end > start {
+			// cc keeps an empty line to type on
+			if b.runes[end-1] == '\n' {
+				end--
+			} else if b.runes[start] == '\n' {
+				start++
+			}
+		}
+	}
+
+	m.saveUndo()
+	b.runes = append(b.runes[:start:start], b.runes[end:]...)
+	b.pos = start
+	if lines && !change {
+		b.pos = b.firstNonBlank(min(start, len(b.runes)))
+	}
+	if !change {
+		b.clampNormal()
+	}
+	m.apply(b)
+	return text, true
+}
+
+// Yank returns the text covered by a motion (y{motion}) without changing
+// it. The cursor moves to the start of the range, as in Vim.
+func (m *Model) Yank(motion string, count int) (string, bool) {
+	b := m.buffer()
+	start, end, lines, ok := b.span(motion, count, false)
+	if !ok {
+		return "", false
+	}
+	text := string(b.runes[start:end])
+	if lines {
+		text = strings.Trim(text, "\n") + "\n"
+	} else {
+		b.pos = start
+		b.clampNormal()
+		m.setCursor(b)
+	}
+	return text, true
+}
+
+// DeleteChar deletes count characters under and after the cursor (x).
+func (m *Model) DeleteChar(count int) string {
+	b := m.buffer()
+	end := min(b.pos+max(count, 1), b.lineEnd(b.pos))
+	if end <= b.pos {
+		return ""
+	}
+	m.saveUndo()
+	text := string(b.runes[b.pos:end])
+	b.runes = append(b.runes[:b.pos:b.pos], b.runes[end:]...)
+	b.clampNormal()
+	m.apply(b)
+	return text
+}
+
+// Put inserts text count times after the cursor (p), or before it (P).
+// Text ending in a newline is put as whole lines below or above.
+func (m *Model) Put(text string, before bool, count int) {
+	if text == "" {
+		return
+	}
+	m.saveUndo()
+	b := m.buffer()
+	ins := []rune(strings.Repeat(text, max(count, 1)))
+
+	var at int
+	switch {
+	case strings.HasSuffix(text, "\n") && before:
+		at = b.lineStart(b.pos)
+	case strings.HasSuffix(text, "\n"):
+		at = b.lineEnd(b.pos)
+		if at == len(b.runes) {
+			// Below the last line: move the newline to the front
+			ins = append([]rune{'\n'}, ins[:len(ins)-1]...)
+		} else {
+			at++
+		}
+	case before || len(b.runes) == 0 || b.pos == b.lineEnd(b.pos):
+		at = b.pos
+	default:
+		at = b.pos + 1
+	}
+
+	b.runes = append(b.runes[:at:at], append(ins, b.runes[at:]...)...)
+	if strings.HasSuffix(text, "\n") {
+		b.pos = b.firstNonBlank(min(at+1, len(b.runes)))
+		if ins[0] != '\n' {
+			b.pos = b.firstNonBlank(at)
+		}
+	} else {
+		b.pos = at + len(ins) - 1
+	}
+	b.clampNormal()
+	m.apply(b)
+}
+
+// BeginInsert saves an undo step and positions the cursor for INSERT mode.
+func (m *Model) BeginInsert(at InsertAt) {
+	m.saveUndo()
+	b := m.buffer()
+	switch at {
+	case InsertAfterCursor:
+		b.pos = min(b.pos+1, b.lineEnd(b.pos))
+	case InsertLineStart:
+		b.pos = b.firstNonBlank(b.pos)
+	case InsertLineEnd:
+		b.pos = b.lineEnd(b.pos)
+	case InsertBelow:
+		b.pos = b.lineEnd(b.pos)
+		b.runes = append(b.runes[:b.pos:b.pos], append([]rune{'\n'}, b.runes[b.pos:]...)...)
+		b.pos++
+	case InsertAbove:
+		b.pos = b.lineStart(b.pos)
+		b.runes = append(b.runes[:b.pos:b.pos], append([]rune{'\n'}, b.runes[b.pos:]...)...)
+	default:
+		return
+	}
+	m.apply(b)
+}
+
+// EndInsert moves the cursor back onto the last inserted character, as
+// Vim does when leaving INSERT mode.
+func (m *Model) EndInsert() {
+	b := m.buffer()
+	if b.pos > b.lineStart(b.pos) {
+		b.pos--
+	}
+	b.clampNormal()
+	m.setCursor(b)
+}
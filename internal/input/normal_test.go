@@ -0,0 +1,146 @@
+package input
+
+import "testing"
+
+func TestOperators(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		op     string // d, c or y
+		motion string
+		count  int
+		want   string
+		reg    string // the text deleted or yanked
+	}{
+		{"dw", "foo |bar baz", "d", "word-forward", 0, "foo |baz", "bar "},
+		{"dw on the last word of a line", "foo |bar\nbaz", "d", "word-forward", 0, "foo| \nbaz", "bar"},
+		{"2dw", "|one two three", "d", "word-forward", 2, "|three", "one two "},
+		{"cw changes to the word end", "|foo bar", "c", "word-forward", 0, "| bar", "foo"},
+		{"cw on a one-character word", "|a bc", "c", "word-forward", 0, "| bc", "a"},
+		{"2cw", "|one two three", "c", "word-forward", 2, "| three", "one two"},
+		{"cw on blanks", "foo|  bar", "c", "word-forward", 0, "foo|bar", "  "},
+		{"ciw", "fo|o.bar", "c", "inner-word", 0, "|.bar", "foo"},
+		{"diw on punctuation", "foo|..bar", "d", "inner-word", 0, "foo|bar", ".."},
+		{"daw takes trailing blanks", "foo |bar baz", "d", "a-word", 0, "foo |baz", "bar "},
+		{"daw on the last word takes leading blanks", "foo |bar", "d", "a-word", 0, "fo|o", " bar"},
+		{"de", "|foo bar", "d", "word-end", 0, "| bar", "foo"},
+		{"db", "foo ba|r", "d", "word-backward", 0, "foo |r", "ba"},
+		{"d$", "on|e two", "d", "line-end", 0, "o|n", "e two"},
+		{"d$ on a later line", "one\nt|wo", "d", "line-end", 0, "one\n|t", "wo"},
+		{"dl", "a|bc", "d", "char-right", 0, "a|c", "b"},
+		{"dh", "a|bc", "d", "char-left", 0, "|bc", "a"},
+		{"dk", "one\n|two\nthree", "d", "line-up", 0, "|three", "one\ntwo\n"},
+		{"dd", "one\n|two\nthree", "d", "line", 0, "one\n|three", "two\n"},
+		{"dd on the last line", "one\n|two", "d", "line", 0, "|one", "two\n"},
+		{"dd on the only line", "|one", "d", "line", 0, "|", "one\n"},
+		{"2dd", "|one\ntwo\nthree", "d", "line", 2, "|three", "one\ntwo\n"},
+		{"dj", "|one\ntwo\nthree", "d", "line-down", 0, "|three", "one\ntwo\n"},
+		{"dG", "one\n|two\nthree", "d", "last-line", 0, "|one", "two\nthree\n"},
+		{"cc keeps an empty line", "one\n  |two\nthree", "c", "line", 0, "one\n|\nthree", "  two\n"},
+		{"yw", "foo |bar", "y", "word-forward", 0, "foo |bar", "bar"},
+		{"yiw moves to the word start", "fo|o bar", "y", "inner-word", 0, "|foo bar", "foo"},
+		{"yb", "foo ba|r", "y", "word-backward", 0, "foo |bar", "ba"},
+		{"yy", "|one\ntwo", "y", "line", 0, "|one\ntwo", "one\n"},
+		{"2yy", "one\n|two\nthree", "y", "line", 2, "one\n|two\nthree", "two\nthree\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := editor(t, tt.text)
+			ops := map[string]func(string, int) (string, bool){"d": m.Delete, "c": m.Change, "y": m.Yank}
+			reg, ok := ops[tt.op](tt.motion, tt.count)
+			if !ok {
+				t.Fatalf("%s%s: not a motion", tt.op, tt.motion)
+			}
+			if got := show(m); got != tt.want || reg != tt.reg {
+				t.Errorf("%s = %q with %q, want %q with %q", tt.name, got, reg, tt.want, tt.reg)
+			}
+		})
+	}
+
+	if _, ok := editor(t, "|foo").Delete("no-such-motion", 1); ok {
+		t.Error("Delete with an unknown motion = true")
+	}
+}
+
+func TestDeleteChar(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		count int
+		want  string
+		reg   string
+	}{
+		{"x", "a|bc", 0, "a|c", "b"},
+		{"2x", "|abc", 2, "|c", "ab"},
+		{"x stops at the line end", "a|bc\nd", 5, "|a\nd", "bc"},
+		{"x on an empty line", "one\n|\ntwo", 1, "one\n|\ntwo", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := editor(t, tt.text)
+			reg := m.DeleteChar(tt.count)
+			if got := show(m); got != tt.want || reg != tt.reg {
+				t.Errorf("%s = %q with %q, want %q with %q", tt.name, got, reg, tt.want, tt.reg)
+			}
+		})
+	}
+}
+
+func TestPut(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		reg    string
+		before bool
+		count  int
+		want   string
+	}{
+		{"p", "a|bc", "X", false, 0, "ab|Xc"},
+		{"P", "a|bc", "X", true, 0, "a|Xbc"},
+		{"3p", "|a", "x", false, 3, "axx|x"},
+		{"p into an empty prompt", "|", "x", false, 0, "|x"},
+		{"p of lines", "|one\ntwo", "new\n", false, 0, "one\n|new\ntwo"},
+		{"P of lines", "one\n|two", "new\n", true, 0, "one\n|new\ntwo"},
+		{"p below the last line", "o|ne", "  new\n", false, 0, "one\n  |new"},
+		{"2p of lines", "|one", "x\n", false, 2, "one\n|x\nx"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := editor(t, tt.text)
+			m.Put(tt.reg, tt.before, tt.count)
+			if got := show(m); got != tt.want {
+				t.Errorf("%s = %q, want %q", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUndo(t *testing.T) {
+	m := editor(t, "one |two three")
+	steps := []struct {
+		name string
+		do   func() bool
+		want string
+	}{
+		{"dw", func() bool { _, ok := m.Delete("word-forward", 1); return ok }, "one |three"},
+		{"x", func() bool { return m.DeleteChar(1) != "" }, "one |hree"},
+		{"p", func() bool { m.Put("X", false, 1); return true }, "one h|Xree"},
+		{"u", m.Undo, "one |hree"},
+		{"u", m.Undo, "one |three"},
+		{"Ctrl-r", m.Redo, "one |hree"},
+		{"u", m.Undo, "one |three"},
+		{"u", m.Undo, "one |two three"},
+		{"u with nothing to undo", func() bool { return !m.Undo() }, "one |two three"},
+		{"Ctrl-r", m.Redo, "one |three"},
+		{"a change drops the redo steps", func() bool { m.Put("Y", true, 1); return !m.Redo() }, "one |Ythree"},
+		{"u", m.Undo, "one |three"},
+	}
+	for i, s := range steps {
+		if !s.do() {
+			t.Fatalf("step %d (%s) failed", i, s.name)
+		}
+		if got := show(m); got != s.want {
+			t.Fatalf("step %d (%s) = %q, want %q", i, s.name, got, s.want)
+		}
+	}
+}
@@ -1,72 +1,358 @@
 // Package input provides the input area component.
 package input
 
-// VimMotion handles Vim-style movement in INSERT mode.
-type VimMotion struct {
-	// cursor position tracking
-	cursorLine int
-	cursorCol  int
+import (
+	"strings"
+	"unicode"
+)
+
+// motionKind tells an operator how to turn a motion into a range.
+type motionKind int
+
+const (
+	exclusive motionKind = iota // the character under the new cursor is excluded
+	inclusive                   // ... is included (e, $)
+	linewise                    // whole lines are affected (j, k, gg, G, dd)
+	object                      // a text object (iw, aw); not a cursor motion
+)
+
+// Motion describes a NORMAL mode motion or text object. Name is the action
+// name keys are bound to.
+type Motion struct {
+	Name string
+	Desc string
+	kind motionKind
+	move func(b *buffer, count int) int
+}
+
+// Motions lists the motions and text objects of the prompt editor.
+var Motions = []Motion{
+	{Name: "char-left", Desc: "Move left [count] characters", kind: exclusive, move: func(b *buffer, n int) int {
+		return max(b.pos-n, b.lineStart(b.pos))
+	}},
+	{Name: "char-right", Desc: "Move right [count] characters", kind: exclusive, move: func(b *buffer, n int) int {
+		return min(b.pos+n, b.lineEnd(b.pos))
+	}},
+	{Name: "line-down", Desc: "Move down [count] lines", kind: linewise, move: func(b *buffer, n int) int {
+		row, col := b.rowCol(b.pos)
+		return b.offset(row+n, col)
+	}},
+	{Name: "line-up", Desc: "Move up [count] lines", kind: linewise, move: func(b *buffer, n int) int {
+		row, col := b.rowCol(b.pos)
+		return b.offset(row-n, col)
+	}},
+	{Name: "word-forward", Desc: "Move to the start of the [count]th next word", kind: exclusive, move: func(b *buffer, n int) int {
+		return b.repeat(n, func(p int) int { return b.wordForward(p, false) })
+	}},
+	{Name: "word-backward", Desc: "Move to the start of the [count]th previous word", kind: exclusive, move: func(b *buffer, n int) int {
+		return b.repeat(n, func(p int) int { return b.wordBackward(p, false) })
+	}},
+	{Name: "word-end", Desc: "Move to the end of the [count]th word", kind: inclusive, move: func(b *buffer, n int) int {
+		return b.repeat(n, func(p int) int { return b.wordEnd(p, false) })
+	}},
+	{Name: "bigword-forward", Desc: "Move to the start of the [count]th next WORD", kind: exclusive, move: func(b *buffer, n int) int {
+		return b.repeat(n, func(p int) int { return b.wordForward(p, true) })
+	}},
+	{Name: "bigword-backward", Desc: "Move to the start of the [count]th previous WORD", kind: exclusive, move: func(b *buffer, n int) int {
+		return b.repeat(n, func(p int) int { return b.wordBackward(p, true) })
+	}},
+	{Name: "bigword-end", Desc: "Move to the end of the [count]th WORD", kind: inclusive, move: func(b *buffer, n int) int {
+		return b.repeat(n, func(p int) int { return b.wordEnd(p, true) })
+	}},
+	{Name: "line-start", Desc: "Move to the first character of the line", kind: exclusive, move: func(b *buffer, n int) int {
+		return b.lineStart(b.pos)
+	}},
+	{Name: "first-non-blank", Desc: "Move to the first non-blank character of the line", kind: exclusive, move: func(b *buffer, n int) int {
+		return b.firstNonBlank(b.pos)
+	}},
+	{Name: "line-end", Desc: "Move to the end of the line ([count]-1 lines down)", kind: inclusive, move: func(b *buffer, n int) int {
+		row, _ := b.rowCol(b.pos)
+		start := b.offset(row+n-1, 0)
+		return max(b.lineEnd(start)-1, start)
+	}},
+	{Name: "first-line", Desc: "Move to the first line, or line [count]", kind: linewise, move: func(b *buffer, n int) int {
+		return b.firstNonBlank(b.offset(max(n, 1)-1, 0))
+	}},
+	{Name: "last-line", Desc: "Move to the last line, or line [count]", kind: linewise, move: func(b *buffer, n int) int {
+		if n == 0 {
+			n = strings.Count(string(b.runes), "\n") + 1
+		}
+		return b.firstNonBlank(b.offset(n-1, 0))
+	}},
+	{Name: "inner-word", Desc: "Text object: the word under the cursor", kind: object},
+	{Name: "a-word", Desc: "Text object: the word under the cursor and trailing blanks", kind: object},
+	{Name: "line", Desc: "Text object: [count] whole lines (dd, cc, yy)", kind: object},
+}
+
+// lookupMotion finds a motion by name.
+func lookupMotion(name string) (Motion, bool) {
+	for _, mo := range Motions {
+		if mo.Name == name {
+			return mo, true
+		}
+	}
+	return Motion{}, false
+}
+
+// motionCount returns the count a motion moves by: 1 without a count,
+// except that gg and G without one go to the first and last line.
+func motionCount(name string, count int) int {
+	if name == "first-line" || name == "last-line" {
+		return count
+	}
+	return max(count, 1)
+}
+
+// buffer is the textarea content as runes with the cursor as an offset,
+// which is what NORMAL mode commands operate on.
+type buffer struct {
+	runes []rune
+	pos   int
+}
+
+// repeat applies step n times (at least once).
+func (b *buffer) repeat(n int, step func(p int) int) int {
+	p := b.pos
+	for range max(n, 1) {
+		p = step(p)
+	}
+	return p
+}
+
+// wordStart returns the start of the blank-separated word ending at the
+// cursor.
+func (b *buffer) wordStart() int {
+	p := b.pos
+	for p > 0 && !unicode.IsSpace(b.runes[p-1]) {
+		p--
+	}
+	return p
+}
+
+// lineStart returns the offset of the first character of the line at p.
+func (b *buffer) lineStart(p int) int {
+	for p > 0 && b.runes[p-1] != '\n' {
+		p--
+	}
+	return p
+}
+
+// lineEnd returns the offset of the newline ending the line at p, or the
+// length of the text on the last line.
+func (b *buffer) lineEnd(p int) int {
+	for p < len(b.runes) && b.runes[p] != '\n' {
+		p++
+	}
+	return p
+}
+
+// firstNonBlank returns the first non-blank character of the line at p.
+func (b *buffer) firstNonBlank(p int) int {
+	p = b.lineStart(p)
+	end := b.lineEnd(p)
+	for p < end && (b.runes[p] == ' ' || b.runes[p] == '\t') {
+		p++
+	}
+	return p
 }
 
-// NewVimMotion creates a new Vim motion handler.
-func NewVimMotion() *VimMotion {
-	return &VimMotion{
-		cursorLine: 0,
-		cursorCol:  0,
+// rowCol converts an offset to a line and column.
+func (b *buffer) rowCol(p int) (row, col int) {
+	start := 0
+	for i := 0; i < p && i < len(b.runes); i++ {
+		if b.runes[i] == '\n' {
+			row++
+			start = i + 1
+		}
 	}
+	return row, p - start
 }
 
-// MoveLeft moves the cursor left.
-func (v *VimMotion) MoveLeft() {
-	if v.cursorCol > 0 {
-		v.cursorCol--
+// offset converts a line and column to an offset, clamping both.
+func (b *buffer) offset(row, col int) int {
+	p := 0
+	for r := 0; r < row; r++ {
+		end := b.lineEnd(p)
+		if end >= len(b.runes) {
+			break
+		}
+		p = end + 1
 	}
+	return min(p+max(col, 0), b.lineEnd(p))
 }
 
-// MoveRight moves the cursor right.
-func (v *VimMotion) MoveRight() {
-	v.cursorCol++
+// clampNormal keeps the cursor on a character, as NORMAL mode requires.
+func (b *buffer) clampNormal() {
+	b.pos = max(min(b.pos, len(b.runes)), 0)
+	if b.pos == b.lineEnd(b.pos) && b.pos > b.lineStart(b.pos) {
+		b.pos--
+	}
 }
 
-// MoveToStartOfLine moves the cursor to the start of the line.
-func (v *VimMotion) MoveToStartOfLine() {
-	v.cursorCol = 0
+// charClass groups characters for word motions: 0 blank, 1 punctuation,
+// 2 word characters. With big (WORD motions) everything non-blank is 1.
+func charClass(r rune, big bool) int {
+	switch {
+	case unicode.IsSpace(r):
+		return 0
+	case big:
+		return 1
+	case r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r):
+		return 2
+	default:
+		return 1
+	}
 }
 
-// MoveToEndOfLine moves the cursor to the end of the line.
-func (v *VimMotion) MoveToEndOfLine() {
-	// TODO: Determine line length
-	v.cursorCol = 80 // Placeholder
+// class returns the character class at p.
+func (b *buffer) class(p int, big bool) int {
+	return charClass(b.runes[p], big)
 }
 
-// MoveToNextWord moves the cursor to the start of the next word.
-func (v *VimMotion) MoveToNextWord() {
-	// TODO: Implement word boundary detection
-	v.cursorCol += 5 // Placeholder
+// wordForward returns the start of the next word after p (w).
+func (b *buffer) wordForward(p int, big bool) int {
+	n := len(b.runes)
+	if p >= n {
+		return n
+	}
+	if c := b.class(p, big); c != 0 {
+		for p < n && b.class(p, big) == c {
+			p++
+		}
+	}
+	for p < n && b.class(p, big) == 0 {
+		p++
+	}
+	return p
 }
 
-// MoveToPrevWord moves the cursor to the start of the previous word.
-func (v *VimMotion) MoveToPrevWord() {
-	// TODO: Implement word boundary detection
-	v.cursorCol -= 5 // Placeholder
-	if v.cursorCol < 0 {
-		v.cursorCol = 0
+// wordBackward returns the start of the word before p (b).
+func (b *buffer) wordBackward(p int, big bool) int {
+	if p <= 0 {
+		return 0
+	}
+	p--
+	for p > 0 && b.class(p, big) == 0 {
+		p--
 	}
+	c := b.class(p, big)
+	for p > 0 && b.class(p-1, big) == c {
+		p--
+	}
+	return p
 }
 
-// MoveToEndOfWord moves the cursor to the end of the current word.
-func (v *VimMotion) MoveToEndOfWord() {
-	// TODO: Implement word boundary detection
-	v.cursorCol += 4 // Placeholder
+// wordEnd returns the end of the word after p (e).
+func (b *buffer) wordEnd(p int, big bool) int {
+	n := len(b.runes)
+	if p >= n-1 {
+		return max(n-1, 0)
+	}
+	p++
+	for p < n && b.class(p, big) == 0 {
+		p++
+	}
+	if p >= n {
+		return n - 1
+	}
+	c := b.class(p, big)
+	for p+1 < n && b.class(p+1, big) == c {
+		p++
+	}
+	return p
 }
 
-// Cursor returns the current cursor position.
-func (v *VimMotion) Cursor() (line, col int) {
-	return v.cursorLine, v.cursorCol
+// wordObject returns the range of the word under the cursor (iw), plus
+// trailing or else leading blanks for aw.
+func (b *buffer) wordObject(around bool) (start, end int) {
+	if len(b.runes) == 0 {
+		return 0, 0
+	}
+	p := min(b.pos, len(b.runes)-1)
+	c := b.class(p, false)
+	lo, hi := b.lineStart(p), b.lineEnd(p)
+	start, end = p, p+1
+	for start > lo && b.class(start-1, false) == c {
+		start--
+	}
+	for end < hi && b.class(end, false) == c {
+		end++
+	}
+	if !around {
+		return start, end
+	}
+	trail := end
+	for trail < hi && b.class(trail, false) == 0 {
+		trail++
+	}
+	if trail > end {
+		return start, trail
+	}
+	for start > lo && b.class(start-1, false) == 0 {
+		start--
+	}
+	return start, end
 }
 
-// SetCursor sets the cursor position.
-func (v *VimMotion) SetCursor(line, col int) {
-	v.cursorLine = line
-	v.cursorCol = col
+// lineRange returns the range of count whole lines starting at the cursor
+// line, including one separating newline.
+func (b *buffer) lineRange(count int) (start, end int) {
+	row, _ := b.rowCol(b.pos)
+	start = b.offset(row, 0)
+	end = b.lineEnd(b.offset(row+max(count, 1)-1, 0))
+	switch {
+	case end < len(b.runes):
+		end++ // take the trailing newline
+	case start > 0:
+		start-- // last line: take the preceding newline instead
+	}
+	return start, end
+}
+
+// span returns the range an operator covers with the given motion, and
+// whether it is linewise. change applies Vim's cw-is-ce rule.
+func (b *buffer) span(name string, count int, change bool) (start, end int, lines bool, ok bool) {
+	mo, ok := lookupMotion(name)
+	if !ok {
+		return 0, 0, false, false
+	}
+
+	switch name {
+	case "inner-word", "a-word":
+		start, end = b.wordObject(name == "a-word")
+		return start, end, false, true
+	case "line":
+		start, end = b.lineRange(count)
+		return start, end, true, true
+	case "word-forward", "bigword-forward":
+		big := name == "bigword-forward"
+		if change && b.pos < len(b.runes) && b.class(b.pos, big) != 0 {
+			// cw changes to the end of the word, like ce
+			end := b.repeat(count, func(p int) int { return b.wordEnd(p, big) })
+			if count <= 1 && b.pos+1 < len(b.runes) && b.class(b.pos+1, big) != b.class(b.pos, big) {
+				end = b.pos // a one-character word
+			}
+			return b.pos, end + 1, false, true
+		}
+		end = mo.move(b, count)
+		// dw on the last word of a line stops at the line end
+		if eol := b.lineEnd(b.pos); end > eol && eol > b.pos {
+			end = eol
+		}
+		return b.pos, end, false, true
+	}
+
+	target := mo.move(b, motionCount(name, count))
+	start, end = min(b.pos, target), max(b.pos, target)
+	switch mo.kind {
+	case inclusive:
+		end = min(end+1, len(b.runes))
+	case linewise:
+		first, _ := b.rowCol(start)
+		last, _ := b.rowCol(end)
+		lb := buffer{runes: b.runes, pos: start}
+		start, end = lb.lineRange(last - first + 1)
+		return start, end, true, true
+	}
+	return start, end, false, true
 }
@@ -0,0 +1,79 @@
+package input
+
+import (
+	"strings"
+	"testing"
+)
+
+// editor returns an input area holding text, with the cursor at the "|"
+// in it.
+func editor(t *testing.T, text string) *Model {
+	t.Helper()
+	pos := strings.Index(text, "|")
+	if pos < 0 {
+		t.Fatalf("no cursor in %q", text)
+	}
+	value := text[:pos] + text[pos+1:]
+	m := NewModel()
+	m.SetValue(value)
+	m.setCursor(&buffer{runes: []rune(value), pos: len([]rune(text[:pos]))})
+	return &m
+}
+
+// show returns the text of the input area with "|" at the cursor.
+func show(m *Model) string {
+	b := m.buffer()
+	return string(b.runes[:b.pos]) + "|" + string(b.runes[b.pos:])
+}
+
+func TestMove(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		motion string
+		count  int
+		want   string
+	}{
+		{"w stops at punctuation", "|foo.bar baz", "word-forward", 0, "foo|.bar baz"},
+		{"W skips punctuation", "|foo.bar baz", "bigword-forward", 0, "foo.bar |baz"},
+		{"2w", "|one two three", "word-forward", 2, "one two |three"},
+		{"w to the next line", "one |two\nthree", "word-forward", 0, "one two\n|three"},
+		{"w on the last word", "one tw|o", "word-forward", 0, "one tw|o"},
+		{"b", "one two th|ree", "word-backward", 0, "one two |three"},
+		{"2b", "one two th|ree", "word-backward", 2, "one |two three"},
+		{"B", "foo.ba|r", "bigword-backward", 0, "|foo.bar"},
+		{"e", "|one two", "word-end", 0, "on|e two"},
+		{"e at a word end", "on|e two", "word-end", 0, "one tw|o"},
+		{"E", "|foo.bar baz", "bigword-end", 0, "foo.ba|r baz"},
+		{"h at the line start", "one\n|two", "char-left", 0, "one\n|two"},
+		{"3l stops at the line end", "|ab\ncd", "char-right", 3, "a|b\ncd"},
+		{"0", "  on|e", "line-start", 0, "|  one"},
+		{"^", "  on|e", "first-non-blank", 0, "  |one"},
+		{"$", "|one two", "line-end", 0, "one tw|o"},
+		{"2$", "|a\nbc", "line-end", 2, "a\nb|c"},
+		{"j keeps the column", "a|bc\ndef", "line-down", 0, "abc\nd|ef"},
+		{"j on a shorter line", "ab|c\nd", "line-down", 0, "abc\n|d"},
+		{"k", "abc\nd|ef", "line-up", 0, "a|bc\ndef"},
+		{"gg", "one\n  two\nth|ree", "first-line", 0, "|one\n  two\nthree"},
+		{"2gg", "one\n  two\nth|ree", "first-line", 2, "one\n  |two\nthree"},
+		{"G", "o|ne\ntwo\n  three", "last-line", 0, "one\ntwo\n  |three"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := editor(t, tt.text)
+			if !m.Move(tt.motion, tt.count) {
+				t.Fatalf("Move(%s) = false", tt.motion)
+			}
+			if got := show(m); got != tt.want {
+				t.Errorf("Move(%s, %d) = %q, want %q", tt.motion, tt.count, got, tt.want)
+			}
+		})
+	}
+
+	m := editor(t, "fo|o bar")
+	for _, motion := range []string{"inner-word", "line", "no-such-motion"} {
+		if m.Move(motion, 1) {
+			t.Errorf("Move(%s) = true, want false", motion)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/fingergohappy/vai/internal/config"
+)
+
+// anthropicVersion is the API version sent with every request.
+const anthropicVersion = "2023-06-01"
+
+// anthropicMaxTokens limits the length of a reply, which the API requires.
+const anthropicMaxTokens = 4096
+
+// Anthropic streams from the Anthropic messages API.
+type Anthropic struct {
+	baseURL string
+	apiKey  apiKey
+	client  *http.Client
+}
+
+// Name returns the provider name.
+func (p *Anthropic) Name() string {
+	return "anthropic"
+}
+
+// Stream sends the conversation and streams the reply.
+func (p *Anthropic) Stream(ctx context.Context, req Request, fn func(text string) error) (err error) {
+	defer func() { err = config.RedactError(err) }()
+
+	type message struct {
+		Role    string `json:"role"`
+		Content string `json:"content"`
+	}
+	body := struct {
+		Model     string    `json:"model"`
+		MaxTokens int       `json:"max_tokens"`
+		System    string    `json:"system,omitempty"`
+		Messages  []message `json:"messages"`
+		Stream    bool      `json:"stream"`
+	}{Model: req.Model, MaxTokens: anthropicMaxTokens, System: req.System, Stream: true}
+	for _, m := range req.Messages {
+		body.Messages = append(body.Messages, message{Role: m.Role, Content: m.Content})
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/messages", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("anthropic-version", anthropicVersion)
+	key, err := p.apiKey()
+	if err != nil {
+		return fmt.Errorf("anthropic: %w", err)
+	}
+	if !key.IsZero() {
+		httpReq.Header.Set("x-api-key", key.Reveal())
+	}
+
+	resp, err := p.client.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("anthropic: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return statusError("anthropic", resp)
+	}
+
+	return readEvents(resp.Body, func(event, data string) error {
+		switch event {
+		case "content_block_delta":
+			var ev struct {
+				Delta struct {
+					Type string `json:"type"`
+					Text string `json:"text"`
+				} `json:"delta"`
+			}
+			if err := json.Unmarshal([]byte(data), &ev); err != nil {
+				return fmt.Errorf("anthropic: bad stream event: %w", err)
+			}
+			if ev.Delta.Type == "text_delta" && ev.Delta.Text != "" {
+				return fn(ev.Delta.Text)
+			}
+		case "error":
+			return fmt.Errorf("anthropic: %s", errorMessage([]byte(data)))
+		}
+		return nil
+	})
+}
@@ -0,0 +1,95 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/fingergohappy/vai/internal/config"
+)
+
+// OpenAI streams from the OpenAI chat completions API, or any server
+// compatible with it (Ollama, vLLM, gateways).
+type OpenAI struct {
+	name    string
+	baseURL string
+	apiKey  apiKey
+	client  *http.Client
+}
+
+// Name returns the provider name.
+func (p *OpenAI) Name() string {
+	return p.name
+}
+
+// Stream sends the conversation and streams the reply.
+func (p *OpenAI) Stream(ctx context.Context, req Request, fn func(text string) error) (err error) {
+	defer func() { err = config.RedactError(err) }()
+
+	type message struct {
+		Role    string `json:"role"`
+		Content string `json:"content"`
+	}
+	body := struct {
+		Model    string    `json:"model"`
+		Messages []message `json:"messages"`
+		Stream   bool      `json:"stream"`
+	}{Model: req.Model, Stream: true}
+	if req.System != "" {
+		body.Messages = append(body.Messages, message{Role: "system", Content: req.System})
+	}
+	for _, m := range req.Messages {
+		body.Messages = append(body.Messages, message{Role: m.Role, Content: m.Content})
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/chat/completions", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	key, err := p.apiKey()
+	if err != nil {
+		return fmt.Errorf("%s: %w", p.name, err)
+	}
+	if !key.IsZero() {
+		httpReq.Header.Set("Authorization", "Bearer "+key.Reveal())
+	}
+
+	resp, err := p.client.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("%s: %w", p.name, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return statusError(p.name, resp)
+	}
+
+	return readEvents(resp.Body, func(event, data string) error {
+		if data == "[DONE]" {
+			return nil
+		}
+		var chunk struct {
+			Choices []struct {
+				Delta struct {
+					Content string `json:"content"`
+				} `json:"delta"`
+			} `json:"choices"`
+		}
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			if msg := errorMessage([]byte(data)); msg != "" {
+				return fmt.Errorf("%s: %s", p.name, msg)
+			}
+			return fmt.Errorf("%s: bad stream event: %w", p.name, err)
+		}
+		if len(chunk.Choices) > 0 && chunk.Choices[0].Delta.Content != "" {
+			return fn(chunk.Choices[0].Delta.Content)
+		}
+		return nil
+	})
+}
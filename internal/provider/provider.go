@@ -0,0 +1,104 @@
+// Package provider sends conversations to AI chat APIs and streams the
+// replies back.
+package provider
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/fingergohappy/vai/internal/config"
+)
+
+// Roles of conversation messages.
+const (
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+)
+
+// Message is a conversation message in plain text.
+type Message struct {
+	Role    string
+	Content string
+}
+
+// Request is a conversation to complete. System is the system prompt,
+// sent in the form each API expects.
+type Request struct {
+	Model    string
+	System   string
+	Messages []Message
+}
+
+// Provider streams the reply to a conversation. fn is called with each
+// piece of text as it arrives; an error from fn stops the stream.
+type Provider interface {
+	Name() string
+	Stream(ctx context.Context, req Request, fn func(text string) error) error
+}
+
+// New creates the provider named in cfg.
+func New(cfg config.ProviderConfig) (Provider, error) {
+	switch cfg.Name {
+	case "openai", "":
+		return &OpenAI{
+			name:    "openai",
+			baseURL: baseURL(cfg, "https://api.openai.com/v1"),
+			apiKey:  newAPIKey(cfg, "OPENAI_API_KEY"),
+			client:  http.DefaultClient,
+		}, nil
+	case "ollama":
+		// Ollama serves an OpenAI-compatible API and needs no key, unless
+		// one is configured for a proxy in front of it
+		return &OpenAI{
+			name:    "ollama",
+			baseURL: baseURL(cfg, "http://localhost:11434/v1"),
+			apiKey:  newAPIKey(cfg, ""),
+			client:  http.DefaultClient,
+		}, nil
+	case "anthropic":
+		return &Anthropic{
+			baseURL: baseURL(cfg, "https://api.anthropic.com/v1"),
+			apiKey:  newAPIKey(cfg, "ANTHROPIC_API_KEY"),
+			client:  http.DefaultClient,
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown provider: %s", cfg.Name)
+}
+
+// apiKey resolves the API key when a request needs it, so that a key
+// command does not run before then.
+type apiKey func() (config.Secret, error)
+
+// newAPIKey returns the key source configured in cfg; env is the variable
+// used when cfg sets none.
+func newAPIKey(cfg config.ProviderConfig, env string) apiKey {
+	return func() (config.Secret, error) {
+		return cfg.APIKey(env)
+	}
+}
+
+// baseURL returns the configured endpoint or the provider default.
+func baseURL(cfg config.ProviderConfig, def string) string {
+	if cfg.BaseURL != "" {
+		return strings.TrimSuffix(cfg.BaseURL, "/")
+	}
+	return def
+}
+
+// statusError turns a failed HTTP response into an error, including the
+// message from the body where the API provides one. Stream removes API keys
+// from the message, which some APIs echo back.
+func statusError(name string, resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	msg := strings.TrimSpace(string(body))
+	if m := errorMessage(body); m != "" {
+		msg = m
+	}
+	if msg == "" {
+		return fmt.Errorf("%s: %s", name, resp.Status)
+	}
+	return fmt.Errorf("%s: %s: %s", name, resp.Status, msg)
+}
@@ -0,0 +1,117 @@
+package provider
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// event is a dispatched server-sent event.
+type event struct {
+	name, data string
+}
+
+func TestReadEvents(t *testing.T) {
+	tests := []struct {
+		name   string
+		stream string
+		want   []event
+	}{
+		{
+			name:   "data only",
+			stream: "data: {\"a\":1}\n\ndata: [DONE]\n\n",
+			want:   []event{{"", `{"a":1}`}, {"", "[DONE]"}},
+		},
+		{
+			name:   "named events",
+			stream: "event: message_start\ndata: {}\n\nevent: ping\ndata: {}\n\n",
+			want:   []event{{"message_start", "{}"}, {"ping", "{}"}},
+		},
+		{
+			name:   "multi-line data",
+			stream: "data: one\ndata: two\ndata:three\n\n",
+			want:   []event{{"", "one\ntwo\nthree"}},
+		},
+		{
+			name:   "only one space is stripped",
+			stream: "data:   indented\n\n",
+			want:   []event{{"", "  indented"}},
+		},
+		{
+			name:   "comments and unknown fields",
+			stream: ": keep-alive\nid: 7\nretry: 100\ndata: x\n\n",
+			want:   []event{{"", "x"}},
+		},
+		{
+			name:   "event without data is dropped",
+			stream: "event: ping\n\ndata: x\n\n",
+			want:   []event{{"", "x"}},
+		},
+		{
+			name:   "last event without blank line",
+			stream: "data: a\n\ndata: b",
+			want:   []event{{"", "a"}, {"", "b"}},
+		},
+		{
+			name:   "CRLF line endings",
+			stream: "event: e\r\ndata: x\r\n\r\n",
+			want:   []event{{"e", "x"}},
+		},
+		{
+			name:   "empty stream",
+			stream: "",
+			want:   nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []event
+			err := readEvents(strings.NewReader(tt.stream), func(name, data string) error {
+				got = append(got, event{name, data})
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("events = %q, want %q", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("event %d = %q, want %q", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestReadEventsStops(t *testing.T) {
+	stop := errors.New("stop")
+	calls := 0
+	err := readEvents(strings.NewReader("data: a\n\ndata: b\n\n"), func(name, data string) error {
+		calls++
+		return stop
+	})
+	if !errors.Is(err, stop) || calls != 1 {
+		t.Errorf("readEvents() = %v after %d calls, want stop after 1", err, calls)
+	}
+}
+
+func TestErrorMessage(t *testing.T) {
+	tests := []struct {
+		body string
+		want string
+	}{
+		{`{"error": {"message": "Invalid API key", "type": "auth"}}`, "Invalid API key"},
+		{`{"type": "error", "error": {"type": "overloaded_error", "message": "Overloaded"}}`, "Overloaded"},
+		{`{"error": "model not found"}`, "model not found"},
+		{`{"error": {"code": 5}}`, ""},
+		{`{"choices": []}`, ""},
+		{`not json`, ""},
+	}
+	for _, tt := range tests {
+		if got := errorMessage([]byte(tt.body)); got != tt.want {
+			t.Errorf("errorMessage(%s) = %q, want %q", tt.body, got, tt.want)
+		}
+	}
+}
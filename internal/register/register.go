@@ -0,0 +1,294 @@
+// Package register provides Vim-style registers and the yank history ring.
+package register
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"unicode"
+
+	"github.com/fingergohappy/vai/internal/clipboard"
+	"github.com/fingergohappy/vai/internal/config"
+)
+
+const (
+	// Unnamed is the default register used when no register is given.
+	Unnamed = '"'
+
+	// LastYank always holds the most recent yank.
+	LastYank = '0'
+
+	// Clipboard is the system clipboard register.
+	Clipboard = '+'
+
+	// Primary is the X primary selection register.
+	Primary = '*'
+
+	// Tmux is the tmux paste buffer register.
+	Tmux = '&'
+
+	// BlackHole discards everything written to it.
+	BlackHole = '_'
+
+	// ringSize is the number of numbered history registers ("1 to "9).
+	ringSize = 9
+)
+
+// Entry is a single register and its content, used for listing.
+type Entry struct {
+	Name    rune
+	Content string
+}
+
+// Registers holds register contents and persists them to the data directory.
+type Registers struct {
+	values    map[rune]string
+	clipboard clipboard.Clipboard
+	tmux      clipboard.Clipboard
+	path      string
+}
+
+// New creates a register set that copies "+ and "* through cb and "&
+// through tmux, and persists to path. An empty path disables persistence.
+func New(cb clipboard.Clipboard, path string) *Registers {
+	return &Registers{
+		values:    make(map[rune]string),
+		clipboard: cb,
+		tmux:      clipboard.NewTmux(),
+		path:      path,
+	}
+}
+
+// SetClipboard replaces the clipboard behind "+ and "*.
+func (r *Registers) SetClipboard(cb clipboard.Clipboard) {
+	r.clipboard = cb
+}
+
+// DefaultPath returns the registers file in the data directory.
+func DefaultPath() string {
+	return filepath.Join(config.GetDataDir(), "registers.json")
+}
+
+// Valid returns true if name is a register that can be read or written.
+func Valid(name rune) bool {
+	switch {
+	case name == Unnamed, name == Clipboard, name == Primary, name == Tmux, name == BlackHole:
+		return true
+	case name >= '0' && name <= '9':
+		return true
+	case name >= 'a' && name <= 'z', name >= 'A' && name <= 'Z':
+		return true
+	}
+	return false
+}
+
+// Yank stores yanked text in the named register following Vim semantics:
+//   - every yank lands in the unnamed register
+//   - an unnamed yank also updates "0
+//   - an uppercase name appends to the lowercase register
+//   - "+ and "* are also copied to the system clipboard, "& to tmux
+func (r *Registers) Yank(name rune, text string) error {
+	return r.store(name, text, false)
+}
+
+// Delete stores deleted or changed text in the named register. It works as
+// Yank does, except that an unnamed delete shifts the "1-"9 ring instead of
+// updating "0.
+func (r *Registers) Delete(name rune, text string) error {
+	return r.store(name, text, true)
+}
+
+// store writes text to a register for Yank and Delete.
+func (r *Registers) store(name rune, text string, isDelete bool) error {
+	if name == 0 {
+		name = Unnamed
+	}
+	if !Valid(name) {
+		return fmt.Errorf("invalid register: %q", name)
+	}
+	if name == BlackHole {
+		return nil
+	}
+
+	var copyErr error
+	switch {
+	case name == Unnamed && isDelete:
+		r.shiftRing(text)
+	case name == Unnamed:
+		r.values[LastYank] = text
+	case external(name):
+		if cb := r.backend(name); cb != nil {
+			copyErr = cb.Copy(target(name), text)
+		}
+	case unicode.IsUpper(name):
+		name = unicode.ToLower(name)
+		text = r.values[name] + text
+	}
+
+	r.values[name] = text
+	r.values[Unnamed] = text
+
+	if err := r.Save(); err != nil {
+		return err
+	}
+	return copyErr
+}
+
+// shiftRing pushes text onto the numbered history ring.
+func (r *Registers) shiftRing(text string) {
+	for i := ringSize; i > 1; i-- {
+		if prev, ok := r.values[rune('0'+i-1)]; ok {
+			r.values[rune('0'+i)] = prev
+		}
+	}
+	r.values['1'] = text
+}
+
+// Get returns the content of the named register.
+func (r *Registers) Get(name rune) (string, bool) {
+	if name == 0 {
+		name = Unnamed
+	}
+	if name == BlackHole {
+		return "", false
+	}
+	text, ok := r.values[unicode.ToLower(name)]
+	return text, ok
+}
+
+// Read returns the content of a register to put into the prompt. "+ and
+// "* are read from the system clipboard and "& from tmux; when they cannot
+// paste (e.g. with OSC 52) the text last yanked into them is used.
+func (r *Registers) Read(name rune) (string, error) {
+	if name == 0 {
+		name = Unnamed
+	}
+	if cb := r.backend(name); cb != nil {
+		text, err := cb.Paste(target(name))
+		if err == nil {
+			return text, nil
+		}
+		if stored, ok := r.Get(name); ok {
+			return stored, nil
+		}
+		return "", err
+	}
+	text, ok := r.Get(name)
+	if !ok {
+		return "", fmt.Errorf("register \"%c is empty", name)
+	}
+	return text, nil
+}
+
+// backend returns the clipboard behind "+, "* or "&, or nil for other
+// registers.
+func (r *Registers) backend(name rune) clipboard.Clipboard {
+	switch name {
+	case Clipboard, Primary:
+		return r.clipboard
+	case Tmux:
+		return r.tmux
+	}
+	return nil
+}
+
+// external reports whether a register is backed by a clipboard.
+func external(name rune) bool {
+	return name == Clipboard || name == Primary || name == Tmux
+}
+
+// target returns the clipboard behind "+ or "*.
+func target(name rune) clipboard.Target {
+	if name == Primary {
+		return clipboard.TargetPrimary
+	}
+	return clipboard.TargetClipboard
+}
+
+// Entries returns all non-empty registers in display order.
+func (r *Registers) Entries() []Entry {
+	entries := make([]Entry, 0, len(r.values))
+	for name, content := range r.values {
+		if content == "" {
+			continue
+		}
+		entries = append(entries, Entry{Name: name, Content: content})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return order(entries[i].Name) < order(entries[j].Name)
+	})
+	return entries
+}
+
+// order ranks register names the way :registers lists them.
+func order(name rune) int {
+	switch {
+	case name == Unnamed:
+		return 0
+	case name >= '0' && name <= '9':
+		return 1 + int(name-'0')
+	case name >= 'a' && name <= 'z':
+		return 20 + int(name-'a')
+	case name == Primary:
+		return 50
+	case name == Clipboard:
+		return 51
+	case name == Tmux:
+		return 52
+	default:
+		return 100 + int(name)
+	}
+}
+
+// Load reads registers from disk. A missing file is not an error.
+func (r *Registers) Load() error {
+	if r.path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(r.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var stored map[string]string
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return fmt.Errorf("parse %s: %w", r.path, err)
+	}
+	for key, content := range stored {
+		runes := []rune(key)
+		if len(runes) == 1 && Valid(runes[0]) && !external(runes[0]) {
+			r.values[runes[0]] = content
+		}
+	}
+	return nil
+}
+
+// Save writes registers to disk. "+, "* and "& are left out: they belong
+// to the clipboards behind them and may hold passwords or tokens.
+func (r *Registers) Save() error {
+	if r.path == "" {
+		return nil
+	}
+
+	stored := make(map[string]string, len(r.values))
+	for name, content := range r.values {
+		if !external(name) {
+			stored[string(name)] = content
+		}
+	}
+
+	data, err := json.MarshalIndent(stored, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(r.path, data, 0600)
+}
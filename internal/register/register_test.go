@@ -0,0 +1,169 @@
+package register
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/fingergohappy/vai/internal/clipboard"
+)
+
+// fakeClipboard records copies instead of touching the system clipboard.
+type fakeClipboard struct {
+	copied map[clipboard.Target]string
+}
+
+func newFakeClipboard() *fakeClipboard {
+	return &fakeClipboard{copied: make(map[clipboard.Target]string)}
+}
+
+func (f *fakeClipboard) Copy(target clipboard.Target, text string) error {
+	f.copied[target] = text
+	return nil
+}
+
+func (f *fakeClipboard) Paste(target clipboard.Target) (string, error) {
+	return f.copied[target], nil
+}
+
+func (f *fakeClipboard) Available() bool { return true }
+
+// op is a yank or delete into a register.
+type op struct {
+	delete bool
+	name   rune
+	text   string
+}
+
+func apply(t *testing.T, r *Registers, ops []op) {
+	t.Helper()
+	for _, o := range ops {
+		var err error
+		if o.delete {
+			err = r.Delete(o.name, o.text)
+		} else {
+			err = r.Yank(o.name, o.text)
+		}
+		if err != nil {
+			t.Fatalf("store %q in %q: %v", o.text, o.name, err)
+		}
+	}
+}
+
+func TestRegisters(t *testing.T) {
+	tests := []struct {
+		name string
+		ops  []op
+		want map[rune]string // "" means the register is empty
+	}{
+		{
+			name: "unnamed yank sets \"0",
+			ops:  []op{{name: 0, text: "one"}},
+			want: map[rune]string{Unnamed: "one", LastYank: "one", '1': ""},
+		},
+		{
+			name: "unnamed delete shifts the ring and keeps \"0",
+			ops: []op{
+				{name: 0, text: "yanked"},
+				{delete: true, name: 0, text: "first"},
+				{delete: true, name: 0, text: "second"},
+			},
+			want: map[rune]string{Unnamed: "second", LastYank: "yanked", '1': "second", '2': "first"},
+		},
+		{
+			name: "named yank leaves \"0 and the ring",
+			ops:  []op{{name: 0, text: "zero"}, {name: 'a', text: "named"}},
+			want: map[rune]string{Unnamed: "named", 'a': "named", LastYank: "zero", '1': ""},
+		},
+		{
+			name: "named delete leaves the ring",
+			ops:  []op{{delete: true, name: 'b', text: "gone"}},
+			want: map[rune]string{Unnamed: "gone", 'b': "gone", '1': ""},
+		},
+		{
+			name: "uppercase appends",
+			ops:  []op{{name: 'a', text: "foo"}, {name: 'A', text: "bar"}},
+			want: map[rune]string{'a': "foobar", Unnamed: "foobar"},
+		},
+		{
+			name: "uppercase creates an empty register",
+			ops:  []op{{name: 'C', text: "new"}},
+			want: map[rune]string{'c': "new"},
+		},
+		{
+			name: "black hole discards",
+			ops:  []op{{name: 'a', text: "keep"}, {name: BlackHole, text: "lost"}},
+			want: map[rune]string{Unnamed: "keep", BlackHole: ""},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := New(newFakeClipboard(), "")
+			apply(t, r, tt.ops)
+			for name, want := range tt.want {
+				if got, _ := r.Get(name); got != want {
+					t.Errorf("register %q = %q, want %q", name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRingHoldsNineDeletes(t *testing.T) {
+	r := New(newFakeClipboard(), "")
+	for _, text := range []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j"} {
+		if err := r.Delete(0, text); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := map[rune]string{'1': "j", '5': "f", '9': "b"}
+	for name, text := range want {
+		if got, _ := r.Get(name); got != text {
+			t.Errorf("register %q = %q, want %q", name, got, text)
+		}
+	}
+}
+
+func TestInvalidRegister(t *testing.T) {
+	r := New(newFakeClipboard(), "")
+	for _, name := range []rune{'-', '!', 'é'} {
+		if err := r.Yank(name, "x"); err == nil {
+			t.Errorf("Yank into %q: no error", name)
+		}
+	}
+}
+
+func TestExternalRegisters(t *testing.T) {
+	cb, tmux := newFakeClipboard(), newFakeClipboard()
+	path := filepath.Join(t.TempDir(), "registers.json")
+	r := New(cb, path)
+	r.tmux = tmux
+	apply(t, r, []op{
+		{name: Clipboard, text: "clip"},
+		{name: Primary, text: "primary"},
+		{name: Tmux, text: "buffer"},
+		{name: 'a', text: "saved"},
+	})
+
+	if got := cb.copied[clipboard.TargetClipboard]; got != "clip" {
+		t.Errorf("clipboard = %q, want %q", got, "clip")
+	}
+	if got := cb.copied[clipboard.TargetPrimary]; got != "primary" {
+		t.Errorf("primary selection = %q, want %q", got, "primary")
+	}
+	if got := tmux.copied[clipboard.TargetClipboard]; got != "buffer" {
+		t.Errorf("tmux buffer = %q, want %q", got, "buffer")
+	}
+
+	loaded := New(newFakeClipboard(), path)
+	if err := loaded.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := loaded.Get('a'); got != "saved" {
+		t.Errorf("loaded \"a = %q, want %q", got, "saved")
+	}
+	for _, name := range []rune{Clipboard, Primary, Tmux} {
+		if got, ok := loaded.Get(name); ok {
+			t.Errorf("register %q was persisted: %q", name, got)
+		}
+	}
+}
@@ -70,9 +70,14 @@ func (m *Model) AddSession(session Session) {
 	m.Sessions = append(m.Sessions, session)
 }
 
-// SetCurrent sets the current session by ID.
+// SetCurrent sets the current session by ID and selects it in the list.
 func (m *Model) SetCurrent(id string) {
 	m.CurrentID = id
+	for i := range m.Sessions {
+		if m.Sessions[i].ID == id {
+			m.SelectedIndex = i
+		}
+	}
 }
 
 // Current returns the current session.
@@ -85,14 +90,73 @@ func (m *Model) Current() *Session {
 	return nil
 }
 
+// SetSessions replaces all sessions, e.g. after loading them from a Store.
+func (m *Model) SetSessions(sessions []Session) {
+	m.Sessions = sessions
+	if m.SelectedIndex >= len(sessions) {
+		m.SelectedIndex = 0
+	}
+}
+
+// Put inserts a session, or replaces the session with the same ID.
+func (m *Model) Put(sess Session) {
+	for i := range m.Sessions {
+		if m.Sessions[i].ID == sess.ID {
+			m.Sessions[i] = sess
+			return
+		}
+	}
+	m.AddSession(sess)
+}
+
+// Select moves the list selection to index, clamped to the list.
+func (m *Model) Select(index int) {
+	m.SelectedIndex = max(min(index, len(m.Sessions)-1), 0)
+}
+
+// Selected returns the selected session, or nil if the list is empty.
+func (m *Model) Selected() *Session {
+	if m.SelectedIndex < 0 || m.SelectedIndex >= len(m.Sessions) {
+		return nil
+	}
+	return &m.Sessions[m.SelectedIndex]
+}
+
+// Find returns the session whose title or ID equals name.
+func (m *Model) Find(name string) *Session {
+	for i := range m.Sessions {
+		if m.Sessions[i].Title == name || m.Sessions[i].ID == name {
+			return &m.Sessions[i]
+		}
+	}
+	return nil
+}
+
+// Titles returns the titles of all sessions.
+func (m *Model) Titles() []string {
+	titles := make([]string, len(m.Sessions))
+	for i, sess := range m.Sessions {
+		titles[i] = sess.Title
+	}
+	return titles
+}
+
+// CountTitle returns the number of sessions with the given title.
+func (m *Model) CountTitle(title string) int {
+	n := 0
+	for _, sess := range m.Sessions {
+		if sess.Title == title {
+			n++
+		}
+	}
+	return n
+}
+
 // GetCurrentTitle returns the title of the current session.
 // Returns "New Chat" if there is no current session.
 func (m *Model) GetCurrentTitle() string {
-	if len(m.Sessions) == 0 {
-		return "New Chat"
-	}
-	if m.SelectedIndex >= 0 && m.SelectedIndex < len(m.Sessions) {
-		return m.Sessions[m.SelectedIndex].Title
+	if sess := m.Current(); sess != nil {
+		return sess.Title
 	}
 	return "New Chat"
 }
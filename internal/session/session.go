@@ -1,24 +1,29 @@
 // Package session provides session management and persistence.
 package session
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 // Session represents a single chat session.
 type Session struct {
-	ID        string    // Unique session identifier
-	Title     string    // Session title
-	Messages  []Message // Ordered list of messages
-	CreatedAt time.Time // Session creation timestamp
-	UpdatedAt time.Time // Last update timestamp
-	Model     string    // AI model used (e.g., "gpt-4", "claude-3")
+	ID        string    `json:"id"`               // Unique session identifier
+	Title     string    `json:"title"`            // Session title
+	Messages  []Message `json:"messages"`         // Ordered list of messages
+	CreatedAt time.Time `json:"created_at"`       // Session creation timestamp
+	UpdatedAt time.Time `json:"updated_at"`       // Last update timestamp
+	Model     string    `json:"model"`            // AI model used (e.g., "gpt-4", "claude-3")
+	Draft     string    `json:"draft,omitempty"`  // Unsent prompt text
+	System    string    `json:"system,omitempty"` // System prompt sent before the messages
 }
 
 // Message represents a message in a session.
 type Message struct {
-	ID        string      // Unique message identifier
-	Role      string      // "user" or "assistant"
-	Content   []Block     // Structured content blocks
-	CreatedAt time.Time   // Message timestamp
+	ID        string    `json:"id"`         // Unique message identifier
+	Role      string    `json:"role"`       // "user" or "assistant"
+	Content   []Block   `json:"-"`          // Structured content blocks
+	CreatedAt time.Time `json:"created_at"` // Message timestamp
 }
 
 // Block represents a content block in a message.
@@ -36,8 +41,59 @@ const (
 
 	// CodeBlock is a code block with syntax.
 	CodeBlock
+
+	// ContextBlock is an attached file or other context.
+	ContextBlock
 )
 
+// TextContent is a plain text block stored in a session.
+type TextContent struct {
+	Text string
+}
+
+// Kind returns the block type.
+func (b *TextContent) Kind() BlockType {
+	return TextBlock
+}
+
+// Render returns the text unchanged; display is handled by the chat buffer.
+func (b *TextContent) Render(width int) string {
+	return b.Text
+}
+
+// CodeContent is a code block stored in a session.
+type CodeContent struct {
+	Lang  string
+	Lines []string
+}
+
+// Kind returns the block type.
+func (b *CodeContent) Kind() BlockType {
+	return CodeBlock
+}
+
+// Render returns the code lines; display is handled by the chat buffer.
+func (b *CodeContent) Render(width int) string {
+	return strings.Join(b.Lines, "\n")
+}
+
+// ContextContent is attached context stored in a session.
+type ContextContent struct {
+	Path  string
+	Lang  string
+	Lines []string
+}
+
+// Kind returns the block type.
+func (b *ContextContent) Kind() BlockType {
+	return ContextBlock
+}
+
+// Render returns the attached lines; display is handled by the chat buffer.
+func (b *ContextContent) Render(width int) string {
+	return strings.Join(b.Lines, "\n")
+}
+
 // NewSession creates a new session with a generated title.
 func NewSession() Session {
 	now := time.Now()
@@ -54,7 +110,7 @@ func NewSession() Session {
 // generateID generates a unique ID for a session.
 // TODO: Implement proper ID generation (UUID or similar).
 func generateID() string {
-	return "session-" + time.Now().Format("20060102150405")
+	return "session-" + time.Now().Format("20060102150405.000000")
 }
 
 // AddMessage adds a message to the session.
@@ -0,0 +1,163 @@
+// Package session provides session management and persistence.
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/fingergohappy/vai/internal/config"
+)
+
+// Store persists sessions as one JSON file per session.
+type Store struct {
+	dir string
+}
+
+// NewStore creates a store rooted at dir.
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// DefaultStore returns a store in the sessions data directory.
+func DefaultStore() *Store {
+	return NewStore(config.GetSessionsDir())
+}
+
+// path returns the file path of a session.
+func (s *Store) path(id string) string {
+	return filepath.Join(s.dir, id+".json")
+}
+
+// Save writes a session to disk, replacing any previous version.
+// The file is written to a temporary name first so a crash never
+// leaves a truncated session behind.
+func (s *Store) Save(sess Session) error {
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(sess, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := s.path(sess.ID) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path(sess.ID))
+}
+
+// Load reads a session by ID.
+func (s *Store) Load(id string) (Session, error) {
+	data, err := os.ReadFile(s.path(id))
+	if err != nil {
+		return Session{}, err
+	}
+
+	var sess Session
+	if err := json.Unmarshal(data, &sess); err != nil {
+		return Session{}, fmt.Errorf("parse session %s: %w", id, err)
+	}
+	return sess, nil
+}
+
+// List loads all sessions, most recently updated first.
+// Files that fail to parse are skipped.
+func (s *Store) List() ([]Session, error) {
+	entries, err := os.ReadDir(s.dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var sessions []Session
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		sess, err := s.Load(strings.TrimSuffix(name, ".json"))
+		if err != nil {
+			continue
+		}
+		sessions = append(sessions, sess)
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].UpdatedAt.After(sessions[j].UpdatedAt)
+	})
+	return sessions, nil
+}
+
+// Delete removes a session from disk.
+func (s *Store) Delete(id string) error {
+	err := os.Remove(s.path(id))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// storedBlock is the on-disk form of a Block.
+type storedBlock struct {
+	Kind  string   `json:"kind"`
+	Path  string   `json:"path,omitempty"`
+	Text  string   `json:"text,omitempty"`
+	Lang  string   `json:"lang,omitempty"`
+	Lines []string `json:"lines,omitempty"`
+}
+
+// MarshalJSON encodes a message with its content blocks.
+func (m Message) MarshalJSON() ([]byte, error) {
+	type plain Message
+	blocks := make([]storedBlock, 0, len(m.Content))
+	for _, block := range m.Content {
+		switch b := block.(type) {
+		case *TextContent:
+			blocks = append(blocks, storedBlock{Kind: "text", Text: b.Text})
+		case *CodeContent:
+			blocks = append(blocks, storedBlock{Kind: "code", Lang: b.Lang, Lines: b.Lines})
+		case *ContextContent:
+			blocks = append(blocks, storedBlock{Kind: "context", Path: b.Path, Lang: b.Lang, Lines: b.Lines})
+		default:
+			return nil, fmt.Errorf("cannot store block of type %T", block)
+		}
+	}
+	return json.Marshal(struct {
+		plain
+		Blocks []storedBlock `json:"blocks"`
+	}{plain(m), blocks})
+}
+
+// UnmarshalJSON decodes a message with its content blocks.
+func (m *Message) UnmarshalJSON(data []byte) error {
+	type plain Message
+	var stored struct {
+		plain
+		Blocks []storedBlock `json:"blocks"`
+	}
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return err
+	}
+
+	*m = Message(stored.plain)
+	m.Content = make([]Block, 0, len(stored.Blocks))
+	for _, b := range stored.Blocks {
+		switch b.Kind {
+		case "code":
+			m.Content = append(m.Content, &CodeContent{Lang: b.Lang, Lines: b.Lines})
+		case "context":
+			m.Content = append(m.Content, &ContextContent{Path: b.Path, Lang: b.Lang, Lines: b.Lines})
+		default:
+			m.Content = append(m.Content, &TextContent{Text: b.Text})
+		}
+	}
+	return nil
+}
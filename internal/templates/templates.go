@@ -0,0 +1,242 @@
+// Package templates provides the prompt template library: text/template
+// files in the templates directory that expand into the input area.
+package templates
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/fingergohappy/vai/internal/attach"
+	"github.com/fingergohappy/vai/internal/config"
+)
+
+// Ext is the extension given to saved templates. Files with any other
+// extension, or none, are templates too.
+const Ext = ".tmpl"
+
+// ErrExists is returned when saving over an existing template.
+var ErrExists = errors.New("template already exists")
+
+// Library is a directory of prompt templates, one file per template,
+// named after the file without its extension.
+type Library struct {
+	dir string
+}
+
+// NewLibrary creates a library rooted at dir.
+func NewLibrary(dir string) *Library {
+	return &Library{dir: dir}
+}
+
+// DefaultLibrary returns the library in the templates config directory.
+func DefaultLibrary() *Library {
+	return NewLibrary(filepath.Join(config.GetConfigDir(), "templates"))
+}
+
+// Dir returns the templates directory.
+func (l *Library) Dir() string {
+	return l.dir
+}
+
+// Names returns the template names, sorted. A missing directory has no
+// templates.
+func (l *Library) Names() ([]string, error) {
+	entries, err := os.ReadDir(l.dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	seen := make(map[string]bool)
+	for _, e := range entries {
+		if e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		name := strings.TrimSuffix(e.Name(), filepath.Ext(e.Name()))
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// path returns the file of an existing template, or "" if there is none.
+func (l *Library) path(name string) string {
+	entries, err := os.ReadDir(l.dir)
+	if err != nil {
+		return ""
+	}
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		if strings.TrimSuffix(e.Name(), filepath.Ext(e.Name())) == name {
+			return filepath.Join(l.dir, e.Name())
+		}
+	}
+	return ""
+}
+
+// Load reads the text of a template.
+func (l *Library) Load(name string) (string, error) {
+	path := l.path(name)
+	if path == "" {
+		return "", fmt.Errorf("no template named %q", name)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// Save writes text as the template name. An existing template is only
+// replaced if overwrite is true.
+func (l *Library) Save(name, text string, overwrite bool) error {
+	if err := checkName(name); err != nil {
+		return err
+	}
+	path := l.path(name)
+	if path != "" && !overwrite {
+		return fmt.Errorf("%s: %w", name, ErrExists)
+	}
+	if path == "" {
+		path = filepath.Join(l.dir, name+Ext)
+	}
+	if err := os.MkdirAll(l.dir, 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(text), 0644)
+}
+
+// checkName rejects names that are not a plain file name.
+func checkName(name string) error {
+	switch {
+	case name == "":
+		return errors.New("template name is empty")
+	case strings.HasPrefix(name, "."), strings.ContainsAny(name, `/\`):
+		return fmt.Errorf("invalid template name %q", name)
+	}
+	return nil
+}
+
+// Data is what a template refers to as {{.Selection}}, {{.Clipboard}} and
+// {{.File "path"}}.
+type Data struct {
+	// Selection is the selected text, or the last yanked text
+	Selection string
+	// ReadClipboard reads the system clipboard; it is only called by
+	// templates that use {{.Clipboard}}
+	ReadClipboard func() (string, error)
+	// MaxFileSize limits {{.File}} as it limits attachments, in bytes
+	MaxFileSize int64
+}
+
+// Clipboard returns the contents of the system clipboard.
+func (d Data) Clipboard() (string, error) {
+	if d.ReadClipboard == nil {
+		return "", errors.New("no clipboard")
+	}
+	return d.ReadClipboard()
+}
+
+// File returns the contents of a text file. Relative paths are relative
+// to the working directory and ~/ is the home directory.
+func (d Data) File(path string) (string, error) {
+	f, err := attach.Read(path, d.MaxFileSize)
+	if err != nil {
+		return "", err
+	}
+	return f.Content, nil
+}
+
+// Template is a parsed prompt template.
+type Template struct {
+	tmpl *template.Template
+
+	// input answers while executing, and the questions asked
+	answers map[string]string
+	asked   []string
+}
+
+// Parse parses the text of the template name.
+func Parse(name, text string) (*Template, error) {
+	t := &Template{}
+	tmpl, err := template.New(name).Funcs(template.FuncMap{"input": t.input}).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	t.tmpl = tmpl
+	return t, nil
+}
+
+// input is the {{input "question"}} function. It returns the answer to
+// question and records that it was asked.
+func (t *Template) input(question string) string {
+	if _, ok := t.answers[question]; !ok && !slices.Contains(t.asked, question) {
+		t.asked = append(t.asked, question)
+	}
+	return t.answers[question]
+}
+
+// questionData stands in for Data while collecting questions: a file
+// named by an answer is not known yet, and the clipboard and files are
+// only read once the template is expanded.
+type questionData struct {
+	Selection string
+}
+
+// Clipboard returns an empty clipboard.
+func (questionData) Clipboard() string { return "" }
+
+// File returns an empty file.
+func (questionData) File(path string) string { return "" }
+
+// Questions returns the questions the template asks with input, in order
+// and without repeats, by executing it with empty answers and without
+// reading the clipboard or files. Questions that are only asked for some
+// answers are not found.
+func (t *Template) Questions(data Data) ([]string, error) {
+	t.answers, t.asked = nil, nil
+	if err := t.tmpl.Execute(io.Discard, questionData{Selection: data.Selection}); err != nil {
+		return nil, execError(err)
+	}
+	return t.asked, nil
+}
+
+// Execute expands the template with data and the answers to its
+// questions.
+func (t *Template) Execute(data Data, answers map[string]string) (string, error) {
+	t.answers, t.asked = answers, nil
+	var b strings.Builder
+	if err := t.tmpl.Execute(&b, data); err != nil {
+		return "", execError(err)
+	}
+	return b.String(), nil
+}
+
+// execError strips the position prefix text/template adds to errors from
+// functions, leaving e.g. "template review: open x: no such file".
+func execError(err error) error {
+	var e template.ExecError
+	if errors.As(err, &e) {
+		if _, cause, ok := strings.Cut(e.Err.Error(), "error calling "); ok {
+			if _, msg, ok := strings.Cut(cause, ": "); ok {
+				return fmt.Errorf("template %s: %s", e.Name, msg)
+			}
+		}
+	}
+	return err
+}
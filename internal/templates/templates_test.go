@@ -0,0 +1,185 @@
+package templates
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestQuestions(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{"none", "Explain {{.Selection}}", nil},
+		{"in order", `{{input "Language?"}} {{input "Focus?"}}`, []string{"Language?", "Focus?"}},
+		{"no repeats", `{{input "Name?"}} and {{input "Name?"}}`, []string{"Name?"}},
+		{
+			name: "only for some answers",
+			text: `{{if input "Tests?"}}{{input "Framework?"}}{{end}}`,
+			want: []string{"Tests?"},
+		},
+		{"file named by an answer", `{{.File (input "Which file?")}}`, []string{"Which file?"}},
+		{"file and clipboard", `{{.File "/no/such/file"}}{{.Clipboard}}{{input "Why?"}}`, []string{"Why?"}},
+		{"selection", `{{if .Selection}}{{input "Explain?"}}{{end}}`, []string{"Explain?"}},
+	}
+	data := Data{
+		Selection: "x",
+		ReadClipboard: func() (string, error) {
+			t.Error("Questions() read the clipboard")
+			return "", nil
+		},
+		MaxFileSize: 1024,
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := Parse(tt.name, tt.text)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := tmpl.Questions(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Questions() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExecute(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "notes.txt")
+	if err := os.WriteFile(file, []byte("file text"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	data := Data{
+		Selection:     "func f() {}",
+		ReadClipboard: func() (string, error) { return "clip", nil },
+		MaxFileSize:   1024,
+	}
+
+	tests := []struct {
+		name    string
+		text    string
+		answers map[string]string
+		want    string
+	}{
+		{"selection", "Review:\n{{.Selection}}", nil, "Review:\nfunc f() {}"},
+		{"clipboard", "{{.Clipboard}}!", nil, "clip!"},
+		{"file", `{{.File "` + file + `"}}`, nil, "file text"},
+		{"answers", `In {{input "Language?"}}: {{.Selection}}`, map[string]string{"Language?": "Go"}, "In Go: func f() {}"},
+		{"missing answer", `[{{input "Q?"}}]`, nil, "[]"},
+		{
+			name:    "answer selects a branch",
+			text:    `{{if eq (input "Tests?") "yes"}}with {{input "Framework?"}}{{else}}none{{end}}`,
+			answers: map[string]string{"Tests?": "yes", "Framework?": "testify"},
+			want:    "with testify",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := Parse(tt.name, tt.text)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := tmpl.Execute(data, tt.answers)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Execute() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExecuteErrors(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing.txt")
+	tests := []struct {
+		name string
+		text string
+		data Data
+		want string
+	}{
+		{"no clipboard", "{{.Clipboard}}", Data{}, "template no clipboard: no clipboard"},
+		{
+			name: "clipboard error",
+			text: "{{.Clipboard}}",
+			data: Data{ReadClipboard: func() (string, error) { return "", errors.New("xclip not found") }},
+			want: "template clipboard error: xclip not found",
+		},
+		{"missing file", `{{.File "` + missing + `"}}`, Data{MaxFileSize: 1024}, "template missing file: "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := Parse(tt.name, tt.text)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := tmpl.Questions(tt.data); err != nil {
+				t.Errorf("Questions() = %v, want no error before expanding", err)
+			}
+			if _, err := tmpl.Execute(tt.data, nil); err == nil || !strings.HasPrefix(err.Error(), tt.want) {
+				t.Errorf("Execute() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+
+	if _, err := Parse("bad", "{{.Selection"); err == nil {
+		t.Error("Parse of an unclosed action: no error")
+	}
+}
+
+func TestLibrary(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "templates")
+	lib := NewLibrary(dir)
+
+	if names, err := lib.Names(); err != nil || names != nil {
+		t.Fatalf("Names() of a missing directory = %q, %v", names, err)
+	}
+	if err := lib.Save("review", "Review {{.Selection}}", false); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "explain.md"), []byte("Explain"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".hidden"), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	names, err := lib.Names()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"explain", "review"}; !slices.Equal(names, want) {
+		t.Errorf("Names() = %q, want %q", names, want)
+	}
+
+	if err := lib.Save("review", "new", false); !errors.Is(err, ErrExists) {
+		t.Errorf("Save over an existing template: %v, want ErrExists", err)
+	}
+	if err := lib.Save("explain", "Explain briefly", true); err != nil {
+		t.Fatal(err)
+	}
+	if text, err := lib.Load("explain"); err != nil || text != "Explain briefly" {
+		t.Errorf("Load(explain) = %q, %v", text, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "explain"+Ext)); !os.IsNotExist(err) {
+		t.Error("overwriting explain.md created explain" + Ext)
+	}
+	if _, err := lib.Load("missing"); err == nil {
+		t.Error("Load(missing): no error")
+	}
+
+	for _, name := range []string{"", ".hidden", "a/b", `a\b`} {
+		if err := lib.Save(name, "x", true); err == nil {
+			t.Errorf("Save(%q): no error", name)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package theme
+
+// builtin holds the built-in themes, all in 256-color indexes so they
+// look the same on terminals without true color.
+var builtin = map[string]Theme{
+	"dark": {Colors: map[string]string{
+		NormalMode:      "252",
+		InsertMode:      "142",
+		VisualMode:      "33",
+		Border:          "240",
+		BorderFocus:     "151",
+		User:            "142",
+		Assistant:       "33",
+		UserText:        "244",
+		AssistText:      "252",
+		CodeFg:          "230",
+		CodeBg:          "235",
+		CodeNumber:      "142",
+		Muted:           "243",
+		CursorLine:      "237",
+		SearchFg:        "235",
+		SearchBg:        "142",
+		SearchCurrentBg: "214",
+		Info:            "86",
+		Error:           "196",
+		Warning:         "214",
+		TitleFg:         "252",
+		TitleBg:         "235",
+		CommandFg:       "252",
+		MenuFg:          "235",
+		MenuBg:          "151",
+		PopupBorder:     "151",
+		PopupTitle:      "151",
+		PopupHint:       "240",
+	}},
+
+	"light": {Colors: map[string]string{
+		NormalMode:      "238",
+		InsertMode:      "28",
+		VisualMode:      "25",
+		Border:          "249",
+		BorderFocus:     "30",
+		User:            "28",
+		Assistant:       "25",
+		UserText:        "240",
+		AssistText:      "235",
+		CodeFg:          "236",
+		CodeBg:          "254",
+		CodeNumber:      "28",
+		Muted:           "245",
+		CursorLine:      "253",
+		SearchFg:        "255",
+		SearchBg:        "28",
+		SearchCurrentBg: "166",
+		Info:            "30",
+		Error:           "160",
+		Warning:         "130",
+		TitleFg:         "235",
+		TitleBg:         "252",
+		CommandFg:       "235",
+		MenuFg:          "255",
+		MenuBg:          "30",
+		PopupBorder:     "30",
+		PopupTitle:      "30",
+		PopupHint:       "245",
+	}},
+
+	"high-contrast": {Colors: map[string]string{
+		NormalMode:      "15",
+		InsertMode:      "10",
+		VisualMode:      "14",
+		Border:          "15",
+		BorderFocus:     "11",
+		User:            "10",
+		Assistant:       "14",
+		UserText:        "15",
+		AssistText:      "15",
+		CodeFg:          "15",
+		CodeBg:          "0",
+		CodeNumber:      "11",
+		Muted:           "250",
+		CursorLine:      "238",
+		SearchFg:        "0",
+		SearchBg:        "11",
+		SearchCurrentBg: "9",
+		Info:            "10",
+		Error:           "9",
+		Warning:         "11",
+		TitleFg:         "0",
+		TitleBg:         "15",
+		CommandFg:       "15",
+		MenuFg:          "0",
+		MenuBg:          "11",
+		PopupBorder:     "11",
+		PopupTitle:      "11",
+		PopupHint:       "250",
+	}},
+}
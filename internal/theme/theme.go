@@ -0,0 +1,221 @@
+// Package theme provides the color themes used by the UI.
+package theme
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"gopkg.in/yaml.v3"
+
+	"github.com/fingergohappy/vai/internal/config"
+)
+
+// Color keys. Every key has a value in each built-in theme.
+const (
+	NormalMode      = "normal_mode"       // NORMAL mode pane border
+	InsertMode      = "insert_mode"       // INSERT mode pane border
+	VisualMode      = "visual_mode"       // VISUAL mode pane border
+	Border          = "border"            // unfocused pane borders
+	BorderFocus     = "border_focus"      // focused pane border
+	User            = "user"              // user message border and label
+	Assistant       = "assistant"         // assistant message border and label
+	UserText        = "user_text"         // user message text
+	AssistText      = "assist_text"       // assistant message text
+	CodeFg          = "code_fg"           // code block text
+	CodeBg          = "code_bg"           // code block background
+	CodeNumber      = "code_number"       // code block numbers
+	Muted           = "muted"             // draft markers and attachment summaries
+	CursorLine      = "cursor_line"       // cursor line background
+	SearchFg        = "search_fg"         // search match text
+	SearchBg        = "search_bg"         // search match background
+	SearchCurrentBg = "search_current_bg" // current search match background
+	Info            = "info"              // command line messages
+	Error           = "error"             // command line errors
+	Warning         = "warning"           // config problem banner
+	TitleFg         = "title_fg"          // title bar text
+	TitleBg         = "title_bg"          // title bar background
+	CommandFg       = "command_fg"        // command line text
+	MenuFg          = "menu_fg"           // selected completion text
+	MenuBg          = "menu_bg"           // selected completion background
+	PopupBorder     = "popup_border"      // popup border
+	PopupTitle      = "popup_title"       // popup title
+	PopupHint       = "popup_hint"        // popup footer hint
+)
+
+// Theme maps color keys to lipgloss color values: a 256-color index such
+// as "142" or a hex color such as "#a6e3a1".
+type Theme struct {
+	Name   string
+	Colors map[string]string
+}
+
+// Color returns the color for key.
+func (t Theme) Color(key string) lipgloss.Color {
+	return lipgloss.Color(t.Colors[key])
+}
+
+// clone returns a copy of t that can be modified independently.
+func (t Theme) clone(name string) Theme {
+	colors := make(map[string]string, len(t.Colors))
+	for k, v := range t.Colors {
+		colors[k] = v
+	}
+	return Theme{Name: name, Colors: colors}
+}
+
+// Set overrides colors of the theme. Unknown keys and invalid values are
+// skipped and reported together; the valid ones are still applied.
+func (t *Theme) Set(colors map[string]string) error {
+	keys := make([]string, 0, len(colors))
+	for key := range colors {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var errs []error
+	for _, key := range keys {
+		if _, ok := builtin["dark"].Colors[key]; !ok {
+			errs = append(errs, fmt.Errorf("unknown color %q", key))
+			continue
+		}
+		value, err := ParseColor(colors[key])
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", key, err))
+			continue
+		}
+		t.Colors[key] = value
+	}
+	return errors.Join(errs...)
+}
+
+// ParseColor validates a color value and returns it in the form lipgloss
+// expects. Accepted are 256-color indexes (0-255) and hex colors (#rgb or
+// #rrggbb).
+func ParseColor(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if strings.HasPrefix(value, "#") {
+		hex := value[1:]
+		if len(hex) == 3 {
+			hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+		}
+		if _, err := strconv.ParseUint(hex, 16, 32); err == nil && len(hex) == 6 {
+			return "#" + strings.ToLower(hex), nil
+		}
+	} else if n, err := strconv.Atoi(value); err == nil && n >= 0 && n <= 255 {
+		return strconv.Itoa(n), nil
+	}
+	return "", fmt.Errorf("invalid color %q (want 0-255 or #rrggbb)", value)
+}
+
+// Default returns the built-in dark theme.
+func Default() Theme {
+	return builtin["dark"].clone("dark")
+}
+
+// DefaultDir returns the directory holding user theme files.
+func DefaultDir() string {
+	return filepath.Join(config.GetConfigDir(), "themes")
+}
+
+// Load resolves the configured theme and applies its color overrides. On
+// error it still returns a usable theme: the dark theme if the named one
+// cannot be found, with every valid color applied.
+func Load(cfg config.ThemeConfig, dir string) (Theme, error) {
+	t, err := Named(cfg.Name, dir)
+	if t.Colors == nil {
+		t = Default()
+	}
+	return t, errors.Join(err, t.Set(cfg.Colors))
+}
+
+// Named returns the theme called name:
+//   - auto (or empty, or default): dark or light, following the terminal
+//     background
+//   - dark, light, high-contrast: a built-in theme
+//   - anything else: the file {dir}/{name}.yaml
+//
+// A theme file with invalid colors is returned along with the error; an
+// unknown theme has nil Colors.
+func Named(name, dir string) (Theme, error) {
+	return named(name, dir, 0)
+}
+
+// maxExtends limits chains of themes extending each other.
+const maxExtends = 8
+
+func named(name, dir string, depth int) (Theme, error) {
+	switch name {
+	case "", "auto", "default":
+		if lipgloss.HasDarkBackground() {
+			return builtin["dark"].clone("dark"), nil
+		}
+		return builtin["light"].clone("light"), nil
+	}
+	if t, ok := builtin[name]; ok {
+		return t.clone(name), nil
+	}
+	if depth >= maxExtends {
+		return Theme{}, fmt.Errorf("theme %s: too many nested extends", name)
+	}
+	return loadFile(name, dir, depth)
+}
+
+// file is the format of a user theme file:
+//
+//	extends: dark        # base theme, default dark
+//	colors:
+//	  user: "#a6e3a1"
+//	  assistant: "39"
+type file struct {
+	Extends string            `yaml:"extends"`
+	Colors  map[string]string `yaml:"colors"`
+}
+
+// loadFile reads a user theme file.
+func loadFile(name, dir string, depth int) (Theme, error) {
+	if strings.ContainsAny(name, `/\`) {
+		return Theme{}, fmt.Errorf("unknown theme: %s", name)
+	}
+	path := filepath.Join(dir, name+".yaml")
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return Theme{}, fmt.Errorf("unknown theme: %s", name)
+	}
+	if err != nil {
+		return Theme{}, err
+	}
+
+	var f file
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return Theme{}, fmt.Errorf("%s: %w", path, err)
+	}
+	if f.Extends == "" {
+		f.Extends = "dark"
+	}
+	base, baseErr := named(f.Extends, dir, depth+1)
+	if base.Colors == nil {
+		return Theme{}, fmt.Errorf("%s: %w", path, baseErr)
+	}
+
+	t := base.clone(name)
+	if err := errors.Join(baseErr, t.Set(f.Colors)); err != nil {
+		return t, fmt.Errorf("%s: %w", path, err)
+	}
+	return t, nil
+}
+
+// Names returns the built-in theme names and the user themes in dir.
+func Names(dir string) []string {
+	names := []string{"auto", "dark", "light", "high-contrast"}
+	paths, _ := filepath.Glob(filepath.Join(dir, "*.yaml"))
+	for _, path := range paths {
+		names = append(names, strings.TrimSuffix(filepath.Base(path), ".yaml"))
+	}
+	return names
+}
@@ -0,0 +1,142 @@
+package theme
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/fingergohappy/vai/internal/config"
+)
+
+func TestParseColor(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+		ok    bool
+	}{
+		{"39", "39", true},
+		{" 0 ", "0", true},
+		{"255", "255", true},
+		{"256", "", false},
+		{"-1", "", false},
+		{"#89B4FA", "#89b4fa", true},
+		{"#abc", "#aabbcc", true},
+		{"#abcd", "", false},
+		{"#ggg", "", false},
+		{"blue", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		got, err := ParseColor(tt.value)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("ParseColor(%q) = %q, %v, want %q (ok %v)", tt.value, got, err, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestSet(t *testing.T) {
+	th := Default()
+	err := th.Set(map[string]string{"user": "#fff", "assistant": "999", "nope": "1"})
+	if err == nil {
+		t.Fatal("Set() with bad colors: no error")
+	}
+	for _, want := range []string{`unknown color "nope"`, `assistant: invalid color "999"`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Set() error %q does not mention %q", err, want)
+		}
+	}
+	if th.Colors["user"] != "#ffffff" {
+		t.Errorf("valid color not applied: user = %q", th.Colors["user"])
+	}
+	if th.Colors["assistant"] != Default().Colors["assistant"] {
+		t.Errorf("invalid color applied: assistant = %q", th.Colors["assistant"])
+	}
+}
+
+func TestNamed(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"mocha":  "extends: dark\ncolors:\n  user: \"#a6e3a1\"\n",
+		"paper":  "extends: light\n",
+		"latte":  "extends: mocha\ncolors:\n  assistant: \"39\"\n",
+		"broken": "colors:\n  user: red\n",
+		"orphan": "extends: missing\n",
+		"loop":   "extends: loop\n",
+		"syntax": "colors: [\n",
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name+".yaml"), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	dark, light := builtin["dark"].Colors, builtin["light"].Colors
+
+	tests := []struct {
+		name    string
+		colors  map[string]string // expected colors that differ from dark
+		base    map[string]string // the theme the rest comes from
+		wantErr string
+		usable  bool // a theme is returned along with the error
+	}{
+		{name: "high-contrast", base: builtin["high-contrast"].Colors},
+		{name: "mocha", colors: map[string]string{"user": "#a6e3a1"}, base: dark},
+		{name: "paper", base: light},
+		{name: "latte", colors: map[string]string{"user": "#a6e3a1", "assistant": "39"}, base: dark},
+		{name: "broken", wantErr: `user: invalid color "red"`, usable: true, base: dark},
+		{name: "orphan", wantErr: "unknown theme: missing"},
+		{name: "loop", wantErr: "too many nested extends"},
+		{name: "syntax", wantErr: "syntax.yaml"},
+		{name: "none", wantErr: "unknown theme: none"},
+		{name: "../mocha", wantErr: "unknown theme: ../mocha"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			th, err := Named(tt.name, dir)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Named() error = %v, want %q", err, tt.wantErr)
+				}
+				if (th.Colors != nil) != tt.usable {
+					t.Errorf("Named() returned colors = %v, want %v", th.Colors != nil, tt.usable)
+				}
+				if !tt.usable {
+					return
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			for key, base := range tt.base {
+				want := base
+				if c, ok := tt.colors[key]; ok {
+					want = c
+				}
+				if th.Colors[key] != want {
+					t.Errorf("%s = %q, want %q", key, th.Colors[key], want)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadFallsBack(t *testing.T) {
+	th, err := Load(config.ThemeConfig{Name: "none", Colors: map[string]string{"user": "1"}}, t.TempDir())
+	if err == nil {
+		t.Error("Load() of an unknown theme: no error")
+	}
+	if th.Name != "dark" || th.Colors["user"] != "1" {
+		t.Errorf("Load() = %s with user %q, want dark with user 1", th.Name, th.Colors["user"])
+	}
+}
+
+func TestNames(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "mocha.yaml"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"auto", "dark", "light", "high-contrast", "mocha"}
+	if got := Names(dir); !slices.Equal(got, want) {
+		t.Errorf("Names() = %q, want %q", got, want)
+	}
+}
@@ -11,6 +11,10 @@ type Layout struct {
 	// Height is the total terminal height.
 	Height int
 
+	// Vertical reports whether the session list is stacked above the
+	// chat buffer instead of beside it.
+	Vertical bool
+
 	// TitleBar is the layout for the title bar (top).
 	TitleBar PaneLayout
 
@@ -22,6 +26,32 @@ type Layout struct {
 
 	// InputArea is the layout for the input area (bottom).
 	InputArea PaneLayout
+
+	// CommandLine is the layout for the search/command line (last row).
+	CommandLine PaneLayout
+}
+
+// LayoutOptions control how CalculateLayout divides the terminal.
+type LayoutOptions struct {
+	// History shows the session list pane.
+	History bool
+
+	// HistoryWidth is the session list width in columns; 0 picks a fifth
+	// of the terminal (minimum 20 chars).
+	HistoryWidth int
+
+	// InputHeight is the input area height in rows, including its border.
+	InputHeight int
+
+	// Vertical stacks the session list, chat buffer and input area.
+	Vertical bool
+
+	// Zoom gives the focused pane the whole area between the title bar
+	// and the command line.
+	Zoom bool
+
+	// Focus is the focused pane, used by Zoom.
+	Focus Focus
 }
 
 // PaneLayout represents the position and size of a single pane.
@@ -32,57 +62,91 @@ type PaneLayout struct {
 	Height int // Pane height
 }
 
-// CalculateLayout computes the layout based on terminal size.
-func CalculateLayout(msg tea.WindowSizeMsg) Layout {
+// CalculateLayout computes the layout based on terminal size and options.
+// Hidden panes get a zero size.
+func CalculateLayout(msg tea.WindowSizeMsg, opts LayoutOptions) Layout {
 	width := msg.Width
 	height := msg.Height
 
 	titleBarHeight := 1
-	inputHeight := 5
+	commandLineHeight := 1
 
-	// Session list: 20% width (minimum 20 chars)
-	sessionWidth := width * 20 / 100
-	if sessionWidth < 20 {
-		sessionWidth = 20
-	}
-	if sessionWidth > width-40 {
-		sessionWidth = width / 3
-	}
+	// Panes share the rows between the title bar and the command line
+	paneHeight := max(height-titleBarHeight-commandLineHeight, 0)
 
-	// Chat buffer: remaining width
-	chatWidth := width - sessionWidth
+	var session, chat, input PaneLayout
+	switch {
+	case opts.Zoom:
+		full := PaneLayout{Width: width, Height: paneHeight}
+		switch opts.Focus {
+		case FocusHistory:
+			session = full
+		case FocusInput:
+			input = full
+		default:
+			chat = full
+		}
 
-	contentHeight := height - titleBarHeight - inputHeight
-	if contentHeight < 0 {
-		contentHeight = 0
+	default:
+		inputHeight := min(max(opts.InputHeight, 0), paneHeight)
+		input = PaneLayout{Y: paneHeight - inputHeight, Width: width, Height: inputHeight}
+		contentHeight := paneHeight - inputHeight
+
+		switch {
+		case !opts.History:
+			chat = PaneLayout{Width: width, Height: contentHeight}
+
+		case opts.Vertical:
+			// Session list: a quarter of the rows (minimum 3)
+			sessionHeight := min(max(contentHeight/4, 3), contentHeight)
+			session = PaneLayout{Width: width, Height: sessionHeight}
+			chat = PaneLayout{Y: sessionHeight, Width: width, Height: contentHeight - sessionHeight}
+
+		default:
+			sessionWidth := opts.HistoryWidth
+			if sessionWidth == 0 {
+				// Session list: 20% width (minimum 20 chars)
+				sessionWidth = max(width*20/100, 20)
+				if sessionWidth > width-40 {
+					sessionWidth = width / 3
+				}
+			} else if sessionWidth > width-20 {
+				// Leave the chat buffer at least 20 chars
+				sessionWidth = max(width-20, width/3)
+			}
+			session = PaneLayout{Width: sessionWidth, Height: contentHeight}
+			chat = PaneLayout{X: sessionWidth, Width: width - sessionWidth, Height: contentHeight}
+		}
+	}
+
+	// Pane positions above are relative to the first pane row
+	for _, p := range []*PaneLayout{&session, &chat, &input} {
+		p.Y += titleBarHeight
 	}
 
 	return Layout{
-		Width:  width,
-		Height: height,
+		Width:    width,
+		Height:   height,
+		Vertical: opts.Vertical && opts.History && !opts.Zoom,
 		TitleBar: PaneLayout{
 			X:      0,
 			Y:      0,
 			Width:  width,
 			Height: titleBarHeight,
 		},
-		SessionList: PaneLayout{
+		SessionList: session,
+		ChatBuffer:  chat,
+		InputArea:   input,
+		CommandLine: PaneLayout{
 			X:      0,
-			Y:      titleBarHeight,
-			Width:  sessionWidth,
-			Height: contentHeight,
-		},
-		ChatBuffer: PaneLayout{
-			X:      sessionWidth,
-			Y:      titleBarHeight,
-			Width:  chatWidth,
-			Height: contentHeight,
-		},
-		InputArea: PaneLayout{
-			X:      0,
-			Y:      titleBarHeight + contentHeight,
+			Y:      titleBarHeight + paneHeight,
 			Width:  width,
-			Height: inputHeight,
+			Height: commandLineHeight,
 		},
 	}
 }
+
+// Visible reports whether the pane takes any space.
+func (p PaneLayout) Visible() bool {
+	return p.Width > 0 && p.Height > 0
+}
@@ -0,0 +1,32 @@
+package ui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Overlay draws box over base with its top left corner at column x and
+// row y, keeping the base visible around it. Parts of box outside base
+// are cut off.
+func Overlay(base, box string, x, y int) string {
+	lines := strings.Split(base, "\n")
+	for i, row := range strings.Split(box, "\n") {
+		n := y + i
+		if n < 0 || n >= len(lines) {
+			continue
+		}
+		line := lines[n]
+		width := lipgloss.Width(line)
+		if x >= width {
+			continue
+		}
+		row = ansi.Truncate(row, width-x, "")
+		left := ansi.Truncate(line, x, "")
+		left += strings.Repeat(" ", x-lipgloss.Width(left))
+		right := ansi.TruncateLeft(line, x+lipgloss.Width(row), "")
+		lines[n] = left + row + right
+	}
+	return strings.Join(lines, "\n")
+}
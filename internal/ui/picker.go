@@ -0,0 +1,138 @@
+package ui
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/fingergohappy/vai/internal/fuzzy"
+)
+
+// Picker is a modal list narrowed by fuzzy search as the user types, such
+// as the :template picker. Enter picks the selected item, Esc cancels.
+type Picker struct {
+	Title string
+
+	items    []string
+	query    string
+	matches  []string
+	selected int
+	offset   int // first visible match
+
+	styles *Styles
+}
+
+// NewPicker creates a picker over items, in the given order until a query
+// is typed.
+func NewPicker(styles *Styles, title string, items []string) *Picker {
+	p := &Picker{
+		Title:  title,
+		items:  items,
+		styles: styles,
+	}
+	p.filter()
+	return p
+}
+
+// Query returns the typed search text.
+func (p *Picker) Query() string {
+	return p.query
+}
+
+// Selected returns the selected item, or "" if nothing matches.
+func (p *Picker) Selected() string {
+	if p.selected < len(p.matches) {
+		return p.matches[p.selected]
+	}
+	return ""
+}
+
+// HandleKey handles a key press. It returns done once the picker is
+// closed, with the picked item, or "" if it was cancelled.
+func (p *Picker) HandleKey(key tea.KeyMsg) (choice string, done bool) {
+	switch key.Type {
+	case tea.KeyEsc, tea.KeyCtrlC:
+		return "", true
+	case tea.KeyEnter:
+		return p.Selected(), true
+	case tea.KeyUp, tea.KeyCtrlP, tea.KeyCtrlK, tea.KeyShiftTab:
+		p.move(-1)
+	case tea.KeyDown, tea.KeyCtrlN, tea.KeyCtrlJ, tea.KeyTab:
+		p.move(1)
+	case tea.KeyBackspace:
+		if r := []rune(p.query); len(r) > 0 {
+			p.query = string(r[:len(r)-1])
+			p.filter()
+		}
+	case tea.KeyCtrlU:
+		p.query = ""
+		p.filter()
+	case tea.KeySpace:
+		p.query += " "
+		p.filter()
+	case tea.KeyRunes:
+		p.query += string(key.Runes)
+		p.filter()
+	}
+	return "", false
+}
+
+// filter narrows the items to those matching the query and selects the
+// best match.
+func (p *Picker) filter() {
+	if p.query == "" {
+		p.matches = p.items
+	} else {
+		p.matches = fuzzy.Filter(p.query, p.items)
+	}
+	p.selected = 0
+	p.offset = 0
+}
+
+// move moves the selection by delta, wrapping around.
+func (p *Picker) move(delta int) {
+	if n := len(p.matches); n > 0 {
+		p.selected = (p.selected + delta + n) % n
+	}
+}
+
+// Render renders the picker centered in an area of the given size.
+func (p *Picker) Render(width, height int) string {
+	frameX, frameY := p.styles.Popup.GetFrameSize()
+	innerW := width - frameX - 4
+	innerH := height - frameY - 2
+	if innerW < 1 || innerH < 1 {
+		return ""
+	}
+
+	// Title, query, blank line and hint leave the rest for the list
+	rows := max(innerH-4, 1)
+	if p.selected < p.offset {
+		p.offset = p.selected
+	} else if p.selected >= p.offset+rows {
+		p.offset = p.selected - rows + 1
+	}
+
+	lines := []string{
+		p.styles.PopupTitle.Render(p.Title),
+		truncate("> "+p.query+"█", innerW),
+	}
+	end := min(p.offset+rows, len(p.matches))
+	for i := p.offset; i < end; i++ {
+		item := truncate(p.matches[i], innerW-2)
+		if i == p.selected {
+			pad := strings.Repeat(" ", max(innerW-2-lipgloss.Width(item), 0))
+			lines = append(lines, p.styles.CommandMenu.Render("  "+item+pad))
+		} else {
+			lines = append(lines, "  "+item)
+		}
+	}
+	if len(p.matches) == 0 {
+		lines = append(lines, p.styles.PopupHint.Render("  no matches"))
+	}
+	lines = append(lines, "", p.styles.PopupHint.Render(truncate("Enter pick · Esc cancel", innerW)))
+
+	box := p.styles.Popup.Width(innerW + 2).Render(strings.Join(lines, "\n"))
+	return lipgloss.Place(width, height, lipgloss.Center, lipgloss.Center, box)
+}
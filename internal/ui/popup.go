@@ -0,0 +1,61 @@
+// Package ui provides shared UI components and utilities for the vai application.
+package ui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Popup is a modal box listing read-only lines, such as :registers output.
+type Popup struct {
+	Title string
+	Lines []string
+
+	styles *Styles
+}
+
+// NewPopup creates a new popup with the given title and lines.
+func NewPopup(styles *Styles, title string, lines []string) *Popup {
+	return &Popup{
+		Title:  title,
+		Lines:  lines,
+		styles: styles,
+	}
+}
+
+// Render renders the popup centered in an area of the given size.
+// Lines are truncated to fit the area.
+func (p *Popup) Render(width, height int) string {
+	frameX, frameY := p.styles.Popup.GetFrameSize()
+	innerW := width - frameX - 4
+	innerH := height - frameY - 2
+	if innerW < 1 || innerH < 1 {
+		return ""
+	}
+
+	lines := []string{p.styles.PopupTitle.Render(p.Title), ""}
+	lines = append(lines, p.Lines...)
+	lines = append(lines, "", p.styles.PopupHint.Render("Press any key to continue"))
+	if len(lines) > innerH {
+		lines = lines[:innerH]
+	}
+	for i, line := range lines {
+		lines[i] = truncate(line, innerW)
+	}
+
+	box := p.styles.Popup.Render(strings.Join(lines, "\n"))
+	return lipgloss.Place(width, height, lipgloss.Center, lipgloss.Center, box)
+}
+
+// truncate shortens s to at most width cells, adding an ellipsis when cut.
+func truncate(s string, width int) string {
+	if lipgloss.Width(s) <= width {
+		return s
+	}
+	r := []rune(s)
+	for len(r) > 0 && lipgloss.Width(string(r))+1 > width {
+		r = r[:len(r)-1]
+	}
+	return string(r) + "…"
+}
@@ -3,6 +3,8 @@ package ui
 
 import (
 	"github.com/charmbracelet/lipgloss"
+
+	"github.com/fingergohappy/vai/internal/theme"
 )
 
 // Styles contains all Lipgloss style definitions for the application.
@@ -19,6 +21,7 @@ type Styles struct {
 
 	// Panes
 	SessionList   lipgloss.Style
+	DraftMarker   lipgloss.Style
 	ChatBuffer    lipgloss.Style
 	InputArea     lipgloss.Style
 	FocusedBorder lipgloss.Style
@@ -32,77 +35,119 @@ type Styles struct {
 	// Messages
 	InfoMessage  lipgloss.Style
 	ErrorMessage lipgloss.Style
+	Banner       lipgloss.Style
 
 	// Title bar
 	TitleBar lipgloss.Style
+
+	// Command line
+	CommandLine lipgloss.Style
+	CommandMenu lipgloss.Style
+
+	// Popup
+	Popup      lipgloss.Style
+	PopupTitle lipgloss.Style
+	PopupHint  lipgloss.Style
 }
 
-// DefaultStyles returns the default style definitions.
+// DefaultStyles returns the styles of the default (dark) theme.
 func DefaultStyles() *Styles {
+	return NewStyles(theme.Default())
+}
+
+// NewStyles returns the style definitions for a theme.
+func NewStyles(t theme.Theme) *Styles {
 	return &Styles{
 		// Mode colors
-		NormalMode: lipgloss.Color("252"), // White
-		InsertMode: lipgloss.Color("142"), // Green
-		VisualMode: lipgloss.Color("33"),  // Blue
+		NormalMode: t.Color(theme.NormalMode),
+		InsertMode: t.Color(theme.InsertMode),
+		VisualMode: t.Color(theme.VisualMode),
 
 		// Mode border styles
 		NormalModeBorder: lipgloss.NewStyle().
 			Border(lipgloss.NormalBorder()).
-			BorderForeground(lipgloss.Color("240")), // Gray
+			BorderForeground(t.Color(theme.Border)),
 
 		InsertModeBorder: lipgloss.NewStyle().
 			Border(lipgloss.NormalBorder()).
-			BorderForeground(lipgloss.Color("142")), // Green
+			BorderForeground(t.Color(theme.InsertMode)),
 
 		VisualModeBorder: lipgloss.NewStyle().
 			Border(lipgloss.NormalBorder()).
-			BorderForeground(lipgloss.Color("33")), // Blue
+			BorderForeground(t.Color(theme.VisualMode)),
 
 		// Panes
 		SessionList: lipgloss.NewStyle().
 			Border(lipgloss.NormalBorder()).
-			BorderForeground(lipgloss.Color("240")),
+			BorderForeground(t.Color(theme.Border)),
+
+		DraftMarker: lipgloss.NewStyle().
+			Foreground(t.Color(theme.Muted)).
+			Italic(true),
 
 		ChatBuffer: lipgloss.NewStyle().
 			Border(lipgloss.NormalBorder()).
-			BorderForeground(lipgloss.Color("240")),
+			BorderForeground(t.Color(theme.Border)),
 
 		InputArea: lipgloss.NewStyle().
 			Border(lipgloss.NormalBorder()).
-			BorderForeground(lipgloss.Color("240")),
+			BorderForeground(t.Color(theme.Border)),
 
 		FocusedBorder: lipgloss.NewStyle().
 			Border(lipgloss.ThickBorder()).
-			BorderForeground(lipgloss.Color("151")), // Cyan
+			BorderForeground(t.Color(theme.BorderFocus)),
 
 		// Text
 		UserText: lipgloss.NewStyle().
-			Foreground(lipgloss.Color("244")), // Gray
+			Foreground(t.Color(theme.UserText)),
 
 		AssistantText: lipgloss.NewStyle().
-			Foreground(lipgloss.Color("252")), // White
+			Foreground(t.Color(theme.AssistText)),
 
 		CodeBlock: lipgloss.NewStyle().
-			Background(lipgloss.Color("235")).
-			Foreground(lipgloss.Color("230")).
-
+			Background(t.Color(theme.CodeBg)).
+			Foreground(t.Color(theme.CodeFg)).
 			Padding(0, 1),
 
 		CodeBlockNum: lipgloss.NewStyle().
-			Foreground(lipgloss.Color("142")), // Green
+			Foreground(t.Color(theme.CodeNumber)),
 
 		// Messages
 		InfoMessage: lipgloss.NewStyle().
-			Foreground(lipgloss.Color("86")),
+			Foreground(t.Color(theme.Info)),
 
 		ErrorMessage: lipgloss.NewStyle().
-			Foreground(lipgloss.Color("196")),
+			Foreground(t.Color(theme.Error)),
+
+		Banner: lipgloss.NewStyle().
+			Foreground(t.Color(theme.Warning)),
 
 		// Title bar
 		TitleBar: lipgloss.NewStyle().
 			Bold(true).
 			Align(lipgloss.Center).
-			Foreground(lipgloss.Color("252")). // White
-			Background(lipgloss.Color("235")),  // Dark gray
+			Foreground(t.Color(theme.TitleFg)).
+			Background(t.Color(theme.TitleBg)),
+
+		// Command line
+		CommandLine: lipgloss.NewStyle().
+			Foreground(t.Color(theme.CommandFg)),
+
+		CommandMenu: lipgloss.NewStyle().
+			Foreground(t.Color(theme.MenuFg)).
+			Background(t.Color(theme.MenuBg)),
+
+		// Popup
+		Popup: lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(t.Color(theme.PopupBorder)).
+			Padding(0, 1),
+
+		PopupTitle: lipgloss.NewStyle().
+			Bold(true).
+			Foreground(t.Color(theme.PopupTitle)),
+
+		PopupHint: lipgloss.NewStyle().
+			Foreground(t.Color(theme.PopupHint)),
 	}
 }
@@ -20,9 +20,13 @@ func (t *TitleBar) SetWidth(width int) {
 	t.width = width
 }
 
-// Render renders the title bar with the given session title.
-func (t *TitleBar) Render(sessionTitle string) string {
+// Render renders the title bar with the given session title and an
+// optional status such as the search match counter ("3/17").
+func (t *TitleBar) Render(sessionTitle, status string) string {
 	title := "Sessions - " + sessionTitle
+	if status != "" {
+		title += " [" + status + "]"
+	}
 	return t.styles.TitleBar.
 		Width(t.width).
 		Height(1).
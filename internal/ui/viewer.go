@@ -0,0 +1,210 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Section is a titled group of lines shown by a Viewer.
+type Section struct {
+	Title string
+	Lines []string
+}
+
+// Viewer is a modal, scrollable and searchable list of sections, such as
+// the help overlay. j/k and Ctrl-d/Ctrl-u scroll, / filters the lines by
+// the typed text, and q or Esc closes it.
+type Viewer struct {
+	Title string
+
+	sections  []Section
+	query     string
+	searching bool // the query is being typed
+	offset    int  // first visible row
+	height    int  // rows shown at the last render, for paging
+
+	styles *Styles
+}
+
+// viewerRow is a row of the filtered content.
+type viewerRow struct {
+	text    string
+	heading bool
+}
+
+// NewViewer creates a viewer over sections.
+func NewViewer(styles *Styles, title string, sections []Section) *Viewer {
+	return &Viewer{
+		Title:    title,
+		sections: sections,
+		styles:   styles,
+	}
+}
+
+// ScrollToSection scrolls to the first section whose title starts with
+// prefix.
+func (v *Viewer) ScrollToSection(prefix string) {
+	for i, row := range v.rows() {
+		if row.heading && strings.HasPrefix(row.text, prefix) {
+			v.offset = i
+			return
+		}
+	}
+}
+
+// Search shows only the lines that contain query.
+func (v *Viewer) Search(query string) {
+	v.searching = false
+	v.setQuery(query)
+}
+
+// rows returns the content rows, keeping only the lines that contain the
+// query and the titles of their sections. A section whose title matches
+// is kept whole.
+func (v *Viewer) rows() []viewerRow {
+	query := strings.ToLower(v.query)
+	var rows []viewerRow
+	for _, s := range v.sections {
+		lines := s.Lines
+		if query != "" && !strings.Contains(strings.ToLower(s.Title), query) {
+			lines = nil
+			for _, line := range s.Lines {
+				if strings.Contains(strings.ToLower(line), query) {
+					lines = append(lines, line)
+				}
+			}
+			if len(lines) == 0 {
+				continue
+			}
+		}
+		if len(rows) > 0 {
+			rows = append(rows, viewerRow{})
+		}
+		rows = append(rows, viewerRow{text: s.Title, heading: true})
+		for _, line := range lines {
+			rows = append(rows, viewerRow{text: line})
+		}
+	}
+	return rows
+}
+
+// HandleKey handles a key press. It returns true once the viewer is
+// closed.
+func (v *Viewer) HandleKey(key tea.KeyMsg) bool {
+	if v.searching {
+		switch key.Type {
+		case tea.KeyEnter:
+			v.searching = false
+		case tea.KeyEsc, tea.KeyCtrlC:
+			v.searching = false
+			v.setQuery("")
+		case tea.KeyBackspace:
+			if r := []rune(v.query); len(r) > 0 {
+				v.setQuery(string(r[:len(r)-1]))
+			} else {
+				v.searching = false
+			}
+		case tea.KeyCtrlU:
+			v.setQuery("")
+		case tea.KeySpace:
+			v.setQuery(v.query + " ")
+		case tea.KeyRunes:
+			v.setQuery(v.query + string(key.Runes))
+		}
+		return false
+	}
+
+	page := max(v.height, 2)
+	switch key.String() {
+	case "q", "esc", "ctrl+c":
+		if v.query != "" && key.String() == "esc" {
+			v.setQuery("")
+			return false
+		}
+		return true
+	case "/":
+		v.searching = true
+		v.setQuery("")
+	case "j", "down", "ctrl+e", "enter":
+		v.scroll(1)
+	case "k", "up", "ctrl+y":
+		v.scroll(-1)
+	case "ctrl+d":
+		v.scroll(page / 2)
+	case "ctrl+u":
+		v.scroll(-page / 2)
+	case "ctrl+f", "pgdown", " ":
+		v.scroll(page)
+	case "ctrl+b", "pgup":
+		v.scroll(-page)
+	case "g", "home":
+		v.offset = 0
+	case "G", "end":
+		v.scroll(len(v.rows()))
+	}
+	return false
+}
+
+// setQuery changes the search text and scrolls back to the top.
+func (v *Viewer) setQuery(query string) {
+	v.query = query
+	v.offset = 0
+}
+
+// scroll moves the view by n rows, stopping at the ends.
+func (v *Viewer) scroll(n int) {
+	last := max(len(v.rows())-max(v.height, 1), 0)
+	v.offset = min(max(v.offset+n, 0), last)
+}
+
+// Render renders the viewer as a box of the given size.
+func (v *Viewer) Render(width, height int) string {
+	frameX, frameY := v.styles.Popup.GetFrameSize()
+	innerW := width - frameX
+	innerH := height - frameY
+	if innerW < 1 || innerH < 4 {
+		return ""
+	}
+
+	// Title, search line and footer leave the rest for the content
+	rows := v.rows()
+	v.height = innerH - 3
+	v.offset = min(v.offset, max(len(rows)-v.height, 0))
+
+	search := v.styles.PopupHint.Render("/ to search")
+	switch {
+	case v.searching:
+		search = "/" + v.query + "█"
+	case v.query != "":
+		search = "/" + v.query
+	}
+	lines := []string{v.styles.PopupTitle.Render(v.Title), truncate(search, innerW)}
+
+	end := min(v.offset+v.height, len(rows))
+	for _, row := range rows[v.offset:end] {
+		text := truncate(row.text, innerW)
+		if row.heading {
+			text = v.styles.PopupTitle.Render(text)
+		}
+		lines = append(lines, text)
+	}
+	for len(lines) < innerH-1 {
+		lines = append(lines, "")
+	}
+	if len(rows) == 0 {
+		lines[2] = v.styles.PopupHint.Render("no matches")
+	}
+
+	position := "0/0"
+	if len(rows) > 0 {
+		position = fmt.Sprintf("%d-%d/%d", v.offset+1, end, len(rows))
+	}
+	hint := "j/k scroll · / search · q close"
+	pad := max(innerW-lipgloss.Width(hint)-len(position), 1)
+	lines = append(lines, v.styles.PopupHint.Render(truncate(hint+strings.Repeat(" ", pad)+position, innerW)))
+
+	return v.styles.Popup.Width(innerW + 2).Render(strings.Join(lines, "\n"))
+}
@@ -1,56 +1,442 @@
 // Package vim provides Vim-style mode management for the vai application.
 package vim
 
-import "github.com/charmbracelet/bubbletea"
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
 
-// Keymap defines keybindings for each mode.
-type Keymap struct {
-	// NORMAL mode keybindings
-	Normal map[string]func() tea.Msg
+// FocusAny scopes a binding to every focus area.
+const FocusAny Focus = -1
+
+// leaderToken stands for <leader> until a binding is added to a keymap.
+const leaderToken = "<leader>"
+
+// Binding describes a key sequence bound to a named action.
+type Binding struct {
+	Mode   Mode
+	Focus  Focus
+	Keys   []string // Key tokens as reported by tea.KeyMsg.String()
+	Action string   // Action name (e.g. "scroll-down")
+	User   bool     // Added with Map rather than Bind
+}
+
+// node is a trie node keyed by key tokens.
+type node struct {
+	children map[string]*node
+	action   string // empty if no binding ends here
+}
 
-	// INSERT mode keybindings
-	Insert map[string]func() tea.Msg
+// Flags modify how the router treats a bound action.
+type Flags int
 
-	// VISUAL mode keybindings
-	Visual map[string]func() tea.Msg
+const (
+	// FlagOperator marks an operator (e.g. y) that waits for a motion
+	// bound in ModeOperatorPending.
+	FlagOperator Flags = 1 << iota
+
+	// FlagChar marks an action that takes the next key as a character
+	// argument (e.g. Ctrl-r {register}).
+	FlagChar
+)
+
+// scope identifies a trie: a mode plus a focus area (or FocusAny), in
+// the default or the user layer.
+type scope struct {
+	mode  Mode
+	focus Focus
+	user  bool
+}
+
+// Keymap maps key sequences to named actions, per mode and focus.
+// Each scope is a trie so multi-key sequences such as gg, ]c and
+// Ctrl-w h can share prefixes. User mappings live in a layer of their
+// own that shadows the defaults, so a mapping for every focus area
+// still wins over a default bound to one pane.
+type Keymap struct {
+	tries  map[scope]*node
+	flags  map[string]Flags
+	leader []string
 }
 
-// NewKeymap creates a new keymap with default bindings.
+// NewKeymap creates an empty keymap.
 func NewKeymap() *Keymap {
 	return &Keymap{
-		Normal: make(map[string]func() tea.Msg),
-		Insert: make(map[string]func() tea.Msg),
-		Visual: make(map[string]func() tea.Msg),
+		tries:  make(map[scope]*node),
+		flags:  make(map[string]Flags),
+		leader: []string{"\\"},
+	}
+}
+
+// SetLeader sets the keys that <leader> stands for in later bindings
+// (default: backslash).
+func (k *Keymap) SetLeader(keys string) error {
+	tokens, err := ParseKeys(keys)
+	if err != nil {
+		return fmt.Errorf("leader: %w", err)
+	}
+	for _, tok := range tokens {
+		if tok == leaderToken {
+			return fmt.Errorf("leader: cannot contain <leader>")
+		}
+	}
+	k.leader = tokens
+	return nil
+}
+
+// expandLeader replaces <leader> tokens with the leader keys.
+func (k *Keymap) expandLeader(tokens []string) []string {
+	var out []string
+	for _, tok := range tokens {
+		if tok == leaderToken {
+			out = append(out, k.leader...)
+		} else {
+			out = append(out, tok)
+		}
+	}
+	return out
+}
+
+// SetFlags sets how the router treats an action.
+func (k *Keymap) SetFlags(action string, flags Flags) {
+	k.flags[action] = flags
+}
+
+// Flags returns the flags of an action.
+func (k *Keymap) Flags(action string) Flags {
+	return k.flags[action]
+}
+
+// Bind binds a key sequence in Vim notation (e.g. "gg", "<C-w>h") to an
+// action in the given mode and focus. Use FocusAny for all focus areas.
+func (k *Keymap) Bind(mode Mode, focus Focus, keys string, action string) error {
+	tokens, err := ParseKeys(keys)
+	if err != nil {
+		return err
+	}
+	k.BindKeys(mode, focus, tokens, action)
+	return nil
+}
+
+// MustBind is like Bind but panics on error. It is meant for default bindings.
+func (k *Keymap) MustBind(mode Mode, focus Focus, keys string, action string) {
+	if err := k.Bind(mode, focus, keys, action); err != nil {
+		panic(err)
+	}
+}
+
+// BindKeys binds a sequence of key tokens to an action.
+func (k *Keymap) BindKeys(mode Mode, focus Focus, tokens []string, action string) {
+	k.bind(scope{mode, focus, false}, tokens, action)
+}
+
+// Map binds a key sequence in the user layer, which Lookup checks before
+// the default bindings.
+func (k *Keymap) Map(mode Mode, focus Focus, keys string, action string) error {
+	tokens, err := ParseKeys(keys)
+	if err != nil {
+		return err
+	}
+	k.bind(scope{mode, focus, true}, tokens, action)
+	return nil
+}
+
+// bind adds a binding to the trie of a scope.
+func (k *Keymap) bind(s scope, tokens []string, action string) {
+	n := k.trie(s, true)
+	for _, tok := range k.expandLeader(tokens) {
+		child, ok := n.children[tok]
+		if !ok {
+			child = &node{children: make(map[string]*node)}
+			n.children[tok] = child
+		}
+		n = child
+	}
+	n.action = action
+}
+
+// Unbind removes a default key sequence. It returns false if nothing was
+// bound.
+func (k *Keymap) Unbind(mode Mode, focus Focus, keys string) (bool, error) {
+	tokens, err := ParseKeys(keys)
+	if err != nil {
+		return false, err
+	}
+	return k.unbind(scope{mode, focus, false}, k.expandLeader(tokens)), nil
+}
+
+// Unmap removes a user mapping or, if there is none, the default binding
+// it would have shadowed. For FocusAny that is the default in every focus
+// area. It returns false if nothing was bound.
+func (k *Keymap) Unmap(mode Mode, focus Focus, keys string) (bool, error) {
+	tokens, err := ParseKeys(keys)
+	if err != nil {
+		return false, err
+	}
+	tokens = k.expandLeader(tokens)
+	if k.unbind(scope{mode, focus, true}, tokens) {
+		return true, nil
+	}
+	if focus != FocusAny {
+		return k.unbind(scope{mode, focus, false}, tokens), nil
 	}
+	found := false
+	for s := range k.tries {
+		if s.mode == mode && !s.user && k.unbind(s, tokens) {
+			found = true
+		}
+	}
+	return found, nil
 }
 
-// Bind adds a keybinding in the specified mode.
-func (k *Keymap) Bind(mode Mode, key string, action func() tea.Msg) {
-	switch mode {
-	case ModeNormal:
-		k.Normal[key] = action
-	case ModeInsert:
-		k.Insert[key] = action
-	case ModeVisual:
-		k.Visual[key] = action
+// unbind removes expanded key tokens from the trie of a scope.
+func (k *Keymap) unbind(s scope, tokens []string) bool {
+	n := k.find(s, tokens)
+	if n == nil || n.action == "" {
+		return false
 	}
+	n.action = ""
+	return true
 }
 
-// Lookup looks up a keybinding in the specified mode.
-// Returns the action function and true if found, nil and false otherwise.
-func (k *Keymap) Lookup(mode Mode, key string) (func() tea.Msg, bool) {
-	var bindings map[string]func() tea.Msg
-	switch mode {
-	case ModeNormal:
-		bindings = k.Normal
-	case ModeInsert:
-		bindings = k.Insert
-	case ModeVisual:
-		bindings = k.Visual
-	default:
-		return nil, false
+// find returns the node a key sequence leads to in a scope, or nil.
+func (k *Keymap) find(s scope, tokens []string) *node {
+	n := k.trie(s, false)
+	for _, tok := range tokens {
+		if n == nil {
+			return nil
+		}
+		n = n.children[tok]
 	}
+	return n
+}
+
+// mapped reports whether a key sequence is bound in a scope.
+func (k *Keymap) mapped(s scope, tokens []string) bool {
+	n := k.find(s, tokens)
+	return n != nil && n.action != ""
+}
+
+// trie returns the root node of a scope, creating it if asked to.
+func (k *Keymap) trie(s scope, create bool) *node {
+	root, ok := k.tries[s]
+	if !ok && create {
+		root = &node{children: make(map[string]*node)}
+		k.tries[s] = root
+	}
+	return root
+}
+
+// Lookup resolves a complete key sequence. It returns the action and
+// whether the sequence is also a prefix of longer bindings. User mappings
+// take precedence over defaults and, within a layer, focus-specific
+// bindings over FocusAny bindings.
+func (k *Keymap) Lookup(mode Mode, focus Focus, tokens []string) (action string, prefix bool) {
+	for _, s := range []scope{{mode, focus, true}, {mode, FocusAny, true}, {mode, focus, false}, {mode, FocusAny, false}} {
+		n := k.find(s, tokens)
+		if n == nil {
+			continue
+		}
+		if len(n.children) > 0 {
+			prefix = true
+		}
+		if action == "" && n.action != "" {
+			action = n.action
+		}
+	}
+	return action, prefix
+}
+
+// Bindings returns every binding, sorted by mode, focus and keys. A default
+// that a user mapping replaces in the same scope is left out.
+func (k *Keymap) Bindings() []Binding {
+	var out []Binding
+	for s, root := range k.tries {
+		var walk func(n *node, keys []string)
+		walk = func(n *node, keys []string) {
+			if n.action != "" && (s.user || !k.mapped(scope{s.mode, s.focus, true}, keys)) {
+				out = append(out, Binding{
+					Mode:   s.mode,
+					Focus:  s.focus,
+					Keys:   append([]string(nil), keys...),
+					Action: n.action,
+					User:   s.user,
+				})
+			}
+			for tok, child := range n.children {
+				walk(child, append(keys, tok))
+			}
+		}
+		walk(root, nil)
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		if a.Mode != b.Mode {
+			return a.Mode < b.Mode
+		}
+		if a.Focus != b.Focus {
+			return a.Focus < b.Focus
+		}
+		return FormatKeys(a.Keys) < FormatKeys(b.Keys)
+	})
+	return out
+}
+
+// keyNames maps Vim key names (lower case) to tea key strings.
+var keyNames = map[string]string{
+	"cr":       "enter",
+	"enter":    "enter",
+	"return":   "enter",
+	"esc":      "esc",
+	"tab":      "tab",
+	"s-tab":    "shift+tab",
+	"bs":       "backspace",
+	"space":    " ",
+	"lt":       "<",
+	"bar":      "|",
+	"bslash":   "\\",
+	"up":       "up",
+	"down":     "down",
+	"left":     "left",
+	"right":    "right",
+	"home":     "home",
+	"end":      "end",
+	"pageup":   "pgup",
+	"pagedown": "pgdown",
+	"del":      "delete",
+}
+
+// ParseKeys converts a key sequence in Vim notation into key tokens as
+// reported by tea.KeyMsg.String(). Plain characters stand for themselves;
+// special keys use angle brackets: <C-w>, <CR>, <Esc>, <Space>, <lt>,
+// <leader>.
+func ParseKeys(keys string) ([]string, error) {
+	var tokens []string
+	runes := []rune(keys)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		if r != '<' {
+			tokens = append(tokens, string(r))
+			continue
+		}
+
+		end := -1
+		for j := i + 1; j < len(runes); j++ {
+			if runes[j] == '>' {
+				end = j
+				break
+			}
+		}
+		if end <= i+1 {
+			// A lone '<' is a literal key
+			tokens = append(tokens, "<")
+			continue
+		}
 
-	action, ok := bindings[key]
-	return action, ok
+		tok, err := parseSpecial(string(runes[i+1 : end]))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", keys, err)
+		}
+		tokens = append(tokens, tok)
+		i = end
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("empty key sequence")
+	}
+	return tokens, nil
+}
+
+// parseSpecial converts the inside of <...> into a key token.
+func parseSpecial(name string) (string, error) {
+	lower := strings.ToLower(name)
+	if tok, ok := keyNames[lower]; ok {
+		return tok, nil
+	}
+	if lower == "leader" {
+		return leaderToken, nil
+	}
+	if strings.HasPrefix(lower, "c-") && len(lower) > 2 {
+		key := lower[2:]
+		if tok, ok := keyNames[key]; ok {
+			key = tok
+		}
+		return "ctrl+" + key, nil
+	}
+	if strings.HasPrefix(lower, "a-") || strings.HasPrefix(lower, "m-") {
+		key := name[2:]
+		if tok, ok := keyNames[strings.ToLower(key)]; ok {
+			key = tok
+		}
+		return "alt+" + key, nil
+	}
+	if n, err := strconv.Atoi(strings.TrimPrefix(lower, "f")); err == nil && lower[0] == 'f' && n >= 1 && n <= 20 {
+		return lower, nil // function keys: <F1> -> f1
+	}
+	return "", fmt.Errorf("unknown key <%s>", name)
+}
+
+// vimNames maps tea key strings to the names FormatKeys writes in angle
+// brackets. Every name parses back with ParseKeys.
+var vimNames = map[string]string{
+	"enter":     "CR",
+	"esc":       "Esc",
+	"tab":       "Tab",
+	"shift+tab": "S-Tab",
+	"backspace": "BS",
+	" ":         "Space",
+	"<":         "lt",
+	"up":        "Up",
+	"down":      "Down",
+	"left":      "Left",
+	"right":     "Right",
+	"home":      "Home",
+	"end":       "End",
+	"pgup":      "PageUp",
+	"pgdown":    "PageDown",
+	"delete":    "Del",
+}
+
+// FormatKeys renders key tokens in Vim notation, the inverse of ParseKeys.
+func FormatKeys(tokens []string) string {
+	var sb strings.Builder
+	for _, tok := range tokens {
+		switch {
+		case tok == leaderToken:
+			sb.WriteString("<leader>")
+		case vimNames[tok] != "":
+			sb.WriteString("<" + vimNames[tok] + ">")
+		case strings.HasPrefix(tok, "ctrl+"):
+			sb.WriteString("<C-" + keyName(tok[len("ctrl+"):]) + ">")
+		case strings.HasPrefix(tok, "alt+"):
+			sb.WriteString("<A-" + keyName(tok[len("alt+"):]) + ">")
+		case len(tok) > 1 && tok[0] == 'f' && isDigits(tok[1:]):
+			sb.WriteString("<F" + tok[1:] + ">")
+		case len([]rune(tok)) > 1:
+			sb.WriteString("<" + tok + ">")
+		default:
+			sb.WriteString(tok)
+		}
+	}
+	return sb.String()
+}
+
+// keyName returns the Vim name of a key after a modifier: <C-Up>, <C-w>.
+func keyName(tok string) string {
+	if name, ok := vimNames[tok]; ok {
+		return name
+	}
+	return tok
+}
+
+// isDigits reports whether s is a non-empty run of ASCII digits.
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return s != ""
 }
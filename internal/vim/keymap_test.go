@@ -0,0 +1,170 @@
+package vim
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestParseKeys(t *testing.T) {
+	tests := []struct {
+		keys string
+		want []string
+	}{
+		{"gg", []string{"g", "g"}},
+		{"<C-w>h", []string{"ctrl+w", "h"}},
+		{"<c-W>", []string{"ctrl+w"}},
+		{"<CR>", []string{"enter"}},
+		{"<S-Tab>", []string{"shift+tab"}},
+		{"<Space>x", []string{" ", "x"}},
+		{"<lt>", []string{"<"}},
+		{"<", []string{"<"}},
+		{"<C-Up>", []string{"ctrl+up"}},
+		{"<A-x>", []string{"alt+x"}},
+		{"<M-CR>", []string{"alt+enter"}},
+		{"<F12>", []string{"f12"}},
+		{"<leader>e", []string{"<leader>", "e"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.keys, func(t *testing.T) {
+			got, err := ParseKeys(tt.keys)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("ParseKeys(%q) = %q, want %q", tt.keys, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseKeysErrors(t *testing.T) {
+	for _, keys := range []string{"", "<Nope>", "<F0>", "<F21>"} {
+		if _, err := ParseKeys(keys); err == nil {
+			t.Errorf("ParseKeys(%q): no error", keys)
+		}
+	}
+}
+
+func TestFormatKeysRoundTrip(t *testing.T) {
+	tests := []struct {
+		tokens []string
+		want   string
+	}{
+		{[]string{"g", "g"}, "gg"},
+		{[]string{"ctrl+w", "h"}, "<C-w>h"},
+		{[]string{"shift+tab"}, "<S-Tab>"},
+		{[]string{"enter"}, "<CR>"},
+		{[]string{"backspace"}, "<BS>"},
+		{[]string{" "}, "<Space>"},
+		{[]string{"<"}, "<lt>"},
+		{[]string{"ctrl+up"}, "<C-Up>"},
+		{[]string{"alt+x"}, "<A-x>"},
+		{[]string{"pgdown"}, "<PageDown>"},
+		{[]string{"f1"}, "<F1>"},
+		{[]string{"<leader>", "e"}, "<leader>e"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			got := FormatKeys(tt.tokens)
+			if got != tt.want {
+				t.Fatalf("FormatKeys(%q) = %q, want %q", tt.tokens, got, tt.want)
+			}
+			back, err := ParseKeys(got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(back, tt.tokens) {
+				t.Errorf("ParseKeys(%q) = %q, want %q", got, back, tt.tokens)
+			}
+		})
+	}
+}
+
+func TestKeymapLookup(t *testing.T) {
+	k := NewKeymap()
+	k.MustBind(ModeNormal, FocusAny, "g", "goto")
+	k.MustBind(ModeNormal, FocusAny, "gg", "top")
+	k.MustBind(ModeNormal, FocusAny, "]c", "next-code")
+	k.MustBind(ModeNormal, FocusAny, "j", "down")
+	k.MustBind(ModeNormal, FocusHistory, "j", "next-session")
+	k.MustBind(ModeInsert, FocusInput, "<C-w>", "delete-word")
+
+	tests := []struct {
+		name       string
+		mode       Mode
+		focus      Focus
+		keys       []string
+		wantAction string
+		wantPrefix bool
+	}{
+		{"complete", ModeNormal, FocusBuffer, []string{"g", "g"}, "top", false},
+		{"ambiguous", ModeNormal, FocusBuffer, []string{"g"}, "goto", true},
+		{"incomplete", ModeNormal, FocusBuffer, []string{"]"}, "", true},
+		{"no match", ModeNormal, FocusBuffer, []string{"]", "x"}, "", false},
+		{"focus any", ModeNormal, FocusBuffer, []string{"j"}, "down", false},
+		{"focus first", ModeNormal, FocusHistory, []string{"j"}, "next-session", false},
+		{"other mode", ModeInsert, FocusInput, []string{"j"}, "", false},
+		{"other focus", ModeInsert, FocusBuffer, []string{"ctrl+w"}, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			action, prefix := k.Lookup(tt.mode, tt.focus, tt.keys)
+			if action != tt.wantAction || prefix != tt.wantPrefix {
+				t.Errorf("Lookup(%q) = %q, %v, want %q, %v", tt.keys, action, prefix, tt.wantAction, tt.wantPrefix)
+			}
+		})
+	}
+}
+
+func TestKeymapUnbind(t *testing.T) {
+	k := NewKeymap()
+	k.MustBind(ModeNormal, FocusAny, "gg", "top")
+
+	if ok, _ := k.Unbind(ModeNormal, FocusAny, "g"); ok {
+		t.Error("Unbind(g) removed a binding that does not exist")
+	}
+	if ok, _ := k.Unbind(ModeNormal, FocusAny, "gg"); !ok {
+		t.Error("Unbind(gg) found nothing")
+	}
+	if action, _ := k.Lookup(ModeNormal, FocusAny, []string{"g", "g"}); action != "" {
+		t.Errorf("gg still runs %q", action)
+	}
+}
+
+func TestKeymapBindingsHidesShadowedDefaults(t *testing.T) {
+	k := NewKeymap()
+	k.MustBind(ModeNormal, FocusBuffer, "j", "down")
+	k.MustBind(ModeNormal, FocusBuffer, "k", "up")
+	if err := k.Map(ModeNormal, FocusBuffer, "j", "scroll-down"); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, b := range k.Bindings() {
+		got = append(got, fmt.Sprintf("%s=%s user=%v", FormatKeys(b.Keys), b.Action, b.User))
+	}
+	want := []string{"j=scroll-down user=true", "k=up user=false"}
+	if !slices.Equal(got, want) {
+		t.Errorf("Bindings() = %q, want %q", got, want)
+	}
+}
+
+func TestKeymapLeader(t *testing.T) {
+	k := NewKeymap()
+	k.MustBind(ModeNormal, FocusAny, "<leader>a", "old")
+	if err := k.SetLeader("<Space>"); err != nil {
+		t.Fatal(err)
+	}
+	k.MustBind(ModeNormal, FocusAny, "<leader>b", "new")
+
+	if action, _ := k.Lookup(ModeNormal, FocusAny, []string{"\\", "a"}); action != "old" {
+		t.Errorf(`\a = %q, want "old"`, action)
+	}
+	if action, _ := k.Lookup(ModeNormal, FocusAny, []string{" ", "b"}); action != "new" {
+		t.Errorf("<Space>b = %q, want \"new\"", action)
+	}
+	if err := k.SetLeader("<leader>"); err == nil {
+		t.Error("SetLeader(<leader>): no error")
+	}
+}
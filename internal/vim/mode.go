@@ -13,6 +13,11 @@ const (
 
 	// ModeVisual is for text selection in the chat buffer.
 	ModeVisual
+
+	// ModeOperatorPending is entered by the router after an operator
+	// (e.g. y) while it waits for a motion. It is never the app's mode;
+	// it only scopes motion bindings in the keymap.
+	ModeOperatorPending
 )
 
 // String returns the string representation of the mode.
@@ -24,6 +29,8 @@ func (m Mode) String() string {
 		return "INSERT"
 	case ModeVisual:
 		return "VISUAL"
+	case ModeOperatorPending:
+		return "OPERATOR"
 	default:
 		return "UNKNOWN"
 	}
@@ -31,5 +38,5 @@ func (m Mode) String() string {
 
 // IsValid returns true if the mode is valid.
 func (m Mode) IsValid() bool {
-	return m >= ModeNormal && m <= ModeVisual
+	return m >= ModeNormal && m <= ModeOperatorPending
 }
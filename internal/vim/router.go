@@ -2,18 +2,102 @@
 package vim
 
 import (
+	"strconv"
+	"strings"
+	"time"
+
 	tea "github.com/charmbracelet/bubbletea"
 )
 
-// Router handles mode-aware key routing based on mode and focus.
+// DefaultTimeout is how long the router waits for the next key of an
+// ambiguous sequence, like Vim's 'timeoutlen'.
+const DefaultTimeout = time.Second
+
+// Status is the outcome of routing a key.
+type Status int
+
+const (
+	// Unhandled means no binding matched; the key should be passed on
+	// (e.g. typed into the input area).
+	Unhandled Status = iota
+
+	// Pending means the key was consumed as part of an incomplete sequence,
+	// count or register prefix.
+	Pending
+
+	// Matched means a binding was resolved and Result.Action should run.
+	Matched
+
+	// Cancelled means a pending sequence was dropped (Esc or an unbound key).
+	Cancelled
+)
+
+// Context is passed to actions along with the resolved binding.
+type Context struct {
+	Count    int    // Count prefix (0 if none); operator and motion counts multiply
+	Register rune   // Register selected with "x (0 if none)
+	Motion   string // Motion action for operators (e.g. "code-block" for yc)
+	Char     rune   // Character argument for FlagChar actions (e.g. Ctrl-r a)
+	Keys     string // The full key sequence typed, in Vim notation
+}
+
+// CountOr returns the count, or def if no count was given.
+func (c Context) CountOr(def int) int {
+	if c.Count == 0 {
+		return def
+	}
+	return c.Count
+}
+
+// Result is returned by Route.
+type Result struct {
+	Status Status
+	Action string
+	Ctx    Context
+
+	// Cmd schedules a TimeoutMsg while an ambiguous sequence is pending.
+	Cmd tea.Cmd
+
+	// Replay holds the keys of a sequence that stopped matching, or timed
+	// out, before it completed a binding. As Vim does with a failed
+	// mapping, the first is passed on as an unbound key and the rest are
+	// routed again.
+	Replay []tea.KeyMsg
+}
+
+// TimeoutMsg fires when a pending sequence has waited DefaultTimeout.
+type TimeoutMsg struct {
+	seq int
+}
+
+// Router handles mode-aware key routing based on mode and focus. It buffers
+// keys until they resolve to a binding, and tracks the count prefix, the
+// register prefix and operator-pending state.
 type Router struct {
 	keymap *Keymap
+
+	// Timeout is how long to wait for the next key of an ambiguous sequence.
+	Timeout time.Duration
+
+	keys      []string     // key tokens of the binding being typed
+	msgs      []tea.KeyMsg // the key messages behind keys, for Replay
+	typed     []string     // everything typed since the last reset, for display
+	count     string       // digits typed before the binding
+	register  rune         // register from a "x prefix
+	awaitReg  bool         // a " was typed, the next key names the register
+	operator  string       // operator waiting for a motion
+	opCount   int          // count given before the operator
+	charFor   string       // FlagChar action waiting for its character
+	seq       int          // increments on every key, to match TimeoutMsg
+	lastMode  Mode
+	lastFocus Focus
 }
 
 // NewRouter creates a new key router.
 func NewRouter() *Router {
 	return &Router{
-		keymap: NewKeymap(),
+		keymap:  NewKeymap(),
+		Timeout: DefaultTimeout,
 	}
 }
 
@@ -29,24 +113,203 @@ const (
 )
 
 // Route routes a key message based on the current mode and focus.
-// Returns the resulting message and a boolean indicating if the key was handled.
-func (r *Router) Route(msg tea.KeyMsg, mode Mode, focus Focus) (tea.Msg, bool) {
-	// Get the key string representation
+func (r *Router) Route(msg tea.KeyMsg, mode Mode, focus Focus) Result {
 	key := msg.String()
+	r.seq++
+	r.lastMode, r.lastFocus = mode, focus
+
+	// Esc always cancels a pending sequence
+	if key == "esc" && r.Busy() {
+		r.Reset()
+		return Result{Status: Cancelled}
+	}
+
+	// Character argument for Ctrl-r {reg} and similar
+	if r.charFor != "" {
+		action := r.charFor
+		r.charFor = ""
+		if msg.Type != tea.KeyRunes || len(msg.Runes) != 1 {
+			r.Reset()
+			return Result{Status: Cancelled}
+		}
+		ctx := r.context()
+		ctx.Char = msg.Runes[0]
+		r.Reset()
+		return Result{Status: Matched, Action: action, Ctx: ctx}
+	}
+
+	// Register name after "
+	if r.awaitReg {
+		r.awaitReg = false
+		r.typed = append(r.typed, key)
+		if msg.Type != tea.KeyRunes || len(msg.Runes) != 1 {
+			r.Reset()
+			return Result{Status: Cancelled}
+		}
+		r.register = msg.Runes[0]
+		return Result{Status: Pending}
+	}
+
+	// Prefixes are only read in NORMAL and VISUAL mode, between bindings
+	if mode != ModeInsert && len(r.keys) == 0 && msg.Type == tea.KeyRunes && len(msg.Runes) == 1 {
+		c := msg.Runes[0]
+		switch {
+		case (c >= '1' && c <= '9') || (c == '0' && r.count != ""):
+			// A leading 0 is a key (start of line), not a count
+			r.count += string(c)
+			r.typed = append(r.typed, key)
+			return Result{Status: Pending}
+		case c == '"' && r.operator == "" && r.register == 0:
+			if action, _ := r.keymap.Lookup(mode, focus, []string{key}); action == "" {
+				r.awaitReg = true
+				r.typed = append(r.typed, key)
+				return Result{Status: Pending}
+			}
+		}
+	}
 
-	// Look up the keybinding in the current mode
-	action, ok := r.keymap.Lookup(mode, key)
-	if !ok {
-		return nil, false
+	// After an operator, keys are looked up as motions
+	if r.operator != "" {
+		mode = ModeOperatorPending
 	}
 
-	// Execute the action
-	return action(), true
+	r.keys = append(r.keys, key)
+	r.msgs = append(r.msgs, msg)
+	r.typed = append(r.typed, key)
+	action, prefix := r.keymap.Lookup(mode, focus, r.keys)
+
+	switch {
+	case prefix:
+		// Incomplete (]) or ambiguous (g vs gg): wait for more keys; on
+		// timeout the shorter binding runs, or the sequence is dropped
+		return Result{Status: Pending, Cmd: r.timeoutCmd()}
+	case action != "":
+		return r.resolve(action)
+	}
+
+	// Nothing matched: a sequence that stopped matching is replayed, a
+	// pending operator, count or register is dropped
+	replay := len(r.keys) > 1 && r.operator == ""
+	msgs := r.msgs
+	wasBusy := len(r.keys) > 1 || r.operator != "" || r.count != "" || r.register != 0
+	r.Reset()
+	switch {
+	case replay:
+		return Result{Status: Cancelled, Replay: msgs}
+	case wasBusy:
+		return Result{Status: Cancelled}
+	}
+	return Result{Status: Unhandled}
+}
+
+// resolve turns a matched action into a result, handling operators.
+func (r *Router) resolve(action string) Result {
+	flags := r.keymap.Flags(action)
+
+	switch {
+	case flags&FlagChar != 0:
+		r.charFor = action
+		r.keys, r.msgs = nil, nil
+		return Result{Status: Pending}
+
+	case r.operator != "":
+		// A motion completes the pending operator
+		ctx := r.context()
+		ctx.Motion = action
+		op := r.operator
+		r.Reset()
+		return Result{Status: Matched, Action: op, Ctx: ctx}
+
+	case flags&FlagOperator != 0:
+		r.operator = action
+		r.opCount = r.takeCount()
+		r.keys, r.msgs = nil, nil
+		return Result{Status: Pending}
+	}
+
+	ctx := r.context()
+	r.Reset()
+	return Result{Status: Matched, Action: action, Ctx: ctx}
+}
+
+// context builds the action context from the pending state.
+func (r *Router) context() Context {
+	count := r.takeCount()
+	if r.opCount > 0 {
+		count = r.opCount * max(count, 1)
+	}
+	return Context{
+		Count:    count,
+		Register: r.register,
+		Keys:     FormatKeys(r.typed),
+	}
+}
+
+// takeCount parses and clears the typed count.
+func (r *Router) takeCount() int {
+	n, _ := strconv.Atoi(r.count)
+	r.count = ""
+	return n
+}
+
+// timeoutCmd schedules a TimeoutMsg for the current key.
+func (r *Router) timeoutCmd() tea.Cmd {
+	seq := r.seq
+	return tea.Tick(r.Timeout, func(time.Time) tea.Msg {
+		return TimeoutMsg{seq: seq}
+	})
+}
+
+// HandleTimeout resolves an ambiguous sequence to its shorter binding once
+// the timeout has passed without another key. A sequence with no shorter
+// binding is replayed.
+func (r *Router) HandleTimeout(msg TimeoutMsg) Result {
+	if msg.seq != r.seq || len(r.keys) == 0 {
+		return Result{Status: Unhandled}
+	}
+	mode := r.lastMode
+	if r.operator != "" {
+		mode = ModeOperatorPending
+	}
+	action, _ := r.keymap.Lookup(mode, r.lastFocus, r.keys)
+	if action == "" {
+		var replay []tea.KeyMsg
+		if r.operator == "" {
+			replay = r.msgs
+		}
+		r.Reset()
+		return Result{Status: Cancelled, Replay: replay}
+	}
+	return r.resolve(action)
+}
+
+// Reset clears all pending state.
+func (r *Router) Reset() {
+	r.keys = nil
+	r.msgs = nil
+	r.typed = nil
+	r.count = ""
+	r.register = 0
+	r.awaitReg = false
+	r.operator = ""
+	r.opCount = 0
+	r.charFor = ""
+}
+
+// Busy returns true while keys are pending.
+func (r *Router) Busy() bool {
+	return len(r.typed) > 0 || r.charFor != ""
+}
+
+// Pending returns the keys typed so far, for display (like Vim's showcmd).
+func (r *Router) Pending() string {
+	return strings.TrimSpace(FormatKeys(r.typed))
 }
 
 // SetKeymap sets the keymap for the router.
 func (r *Router) SetKeymap(keymap *Keymap) {
 	r.keymap = keymap
+	r.Reset()
 }
 
 // Keymap returns the current keymap.
@@ -0,0 +1,251 @@
+package vim
+
+import (
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// keyMsgs turns a key sequence in Vim notation into key messages.
+func keyMsgs(t *testing.T, keys string) []tea.KeyMsg {
+	t.Helper()
+	tokens, err := ParseKeys(keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	types := map[string]tea.KeyType{
+		"esc":    tea.KeyEsc,
+		"enter":  tea.KeyEnter,
+		"ctrl+w": tea.KeyCtrlW,
+		"ctrl+r": tea.KeyCtrlR,
+	}
+	msgs := make([]tea.KeyMsg, len(tokens))
+	for i, tok := range tokens {
+		if typ, ok := types[tok]; ok {
+			msgs[i] = tea.KeyMsg{Type: typ}
+		} else {
+			msgs[i] = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(tok)}
+		}
+	}
+	return msgs
+}
+
+// testRouter returns a router with a small keymap in the style of the
+// default one.
+func testRouter() *Router {
+	k := NewKeymap()
+	k.MustBind(ModeNormal, FocusAny, "j", "down")
+	k.MustBind(ModeNormal, FocusAny, "0", "line-start")
+	k.MustBind(ModeNormal, FocusAny, "g", "goto")
+	k.MustBind(ModeNormal, FocusAny, "gg", "top")
+	k.MustBind(ModeNormal, FocusAny, "]c", "next-code")
+	k.MustBind(ModeNormal, FocusAny, "<C-w>h", "focus-left")
+	k.MustBind(ModeNormal, FocusAny, "y", "yank")
+	k.SetFlags("yank", FlagOperator)
+	k.MustBind(ModeOperatorPending, FocusAny, "c", "code-block")
+	k.MustBind(ModeInsert, FocusInput, "<C-r>", "paste-register")
+	k.SetFlags("paste-register", FlagChar)
+
+	r := NewRouter()
+	r.SetKeymap(k)
+	return r
+}
+
+// route sends keys to r and returns the result of the last one.
+func route(t *testing.T, r *Router, keys string, mode Mode, focus Focus) Result {
+	t.Helper()
+	var res Result
+	for _, msg := range keyMsgs(t, keys) {
+		res = r.Route(msg, mode, focus)
+	}
+	return res
+}
+
+func TestRouter(t *testing.T) {
+	tests := []struct {
+		name   string
+		keys   string
+		mode   Mode
+		status Status
+		action string
+		ctx    Context
+		replay int
+	}{
+		{name: "binding", keys: "j", status: Matched, action: "down", ctx: Context{Keys: "j"}},
+		{name: "count", keys: "3j", status: Matched, action: "down", ctx: Context{Count: 3, Keys: "3j"}},
+		{name: "count with zero", keys: "10j", status: Matched, action: "down", ctx: Context{Count: 10, Keys: "10j"}},
+		{name: "leading zero is a key", keys: "0", status: Matched, action: "line-start", ctx: Context{Keys: "0"}},
+		{name: "sequence", keys: "gg", status: Matched, action: "top", ctx: Context{Keys: "gg"}},
+		{name: "ctrl sequence", keys: "<C-w>h", status: Matched, action: "focus-left", ctx: Context{Keys: "<C-w>h"}},
+		{name: "ambiguous prefix", keys: "g", status: Pending},
+		{name: "incomplete prefix", keys: "]", status: Pending},
+		{name: "register prefix", keys: `"a`, status: Pending},
+		{
+			name: "operator and motion", keys: "yc", status: Matched, action: "yank",
+			ctx: Context{Motion: "code-block", Keys: "yc"},
+		},
+		{
+			name: "register, operator and motion", keys: `"byc`, status: Matched, action: "yank",
+			ctx: Context{Register: 'b', Motion: "code-block", Keys: `"byc`},
+		},
+		{
+			name: "counts multiply", keys: "2y3c", status: Matched, action: "yank",
+			ctx: Context{Count: 6, Motion: "code-block", Keys: "2y3c"},
+		},
+		{name: "operator count only", keys: "2yc", status: Matched, action: "yank", ctx: Context{Count: 2, Motion: "code-block", Keys: "2yc"}},
+		{name: "unbound key", keys: "x", status: Unhandled},
+		{name: "failed sequence is replayed", keys: "]x", status: Cancelled, replay: 2},
+		{name: "failed motion is dropped", keys: "yx", status: Cancelled},
+		{name: "count is dropped", keys: "3x", status: Cancelled},
+		{name: "esc cancels", keys: `"a<Esc>`, status: Cancelled},
+		{name: "no counts in insert mode", keys: "3", mode: ModeInsert, status: Unhandled},
+		{
+			name: "char argument", keys: "<C-r>a", mode: ModeInsert, status: Matched, action: "paste-register",
+			ctx: Context{Char: 'a', Keys: "<C-r>"},
+		},
+		{name: "char argument must be a character", keys: "<C-r><CR>", mode: ModeInsert, status: Cancelled},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := testRouter()
+			res := route(t, r, tt.keys, tt.mode, FocusInput)
+			if res.Status != tt.status || res.Action != tt.action {
+				t.Fatalf("Route(%q) = %v %q, want %v %q", tt.keys, res.Status, res.Action, tt.status, tt.action)
+			}
+			if res.Ctx != tt.ctx {
+				t.Errorf("Route(%q) context = %+v, want %+v", tt.keys, res.Ctx, tt.ctx)
+			}
+			if len(res.Replay) != tt.replay {
+				t.Errorf("Route(%q) replays %d keys, want %d", tt.keys, len(res.Replay), tt.replay)
+			}
+			if res.Status != Pending && r.Busy() {
+				t.Errorf("Route(%q) left %q pending", tt.keys, r.Pending())
+			}
+		})
+	}
+}
+
+func TestRouterTimeout(t *testing.T) {
+	tests := []struct {
+		name   string
+		keys   string
+		status Status
+		action string
+		replay int
+	}{
+		{name: "shorter binding runs", keys: "g", status: Matched, action: "goto"},
+		{name: "incomplete sequence is replayed", keys: "]", status: Cancelled, replay: 1},
+		{name: "pending motion is dropped", keys: "y", status: Unhandled},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := testRouter()
+			r.Timeout = time.Millisecond
+			res := route(t, r, tt.keys, ModeNormal, FocusBuffer)
+			msg := TimeoutMsg{seq: r.seq}
+			if res.Cmd != nil {
+				msg = res.Cmd().(TimeoutMsg)
+			}
+			res = r.HandleTimeout(msg)
+			if res.Status != tt.status || res.Action != tt.action || len(res.Replay) != tt.replay {
+				t.Errorf("timeout after %q = %v %q with %d keys replayed, want %v %q with %d",
+					tt.keys, res.Status, res.Action, len(res.Replay), tt.status, tt.action, tt.replay)
+			}
+		})
+	}
+}
+
+func TestRouterStaleTimeout(t *testing.T) {
+	r := testRouter()
+	res := route(t, r, "g", ModeNormal, FocusBuffer)
+	stale := TimeoutMsg{seq: r.seq}
+	if res.Status != Pending {
+		t.Fatalf("g: %v, want Pending", res.Status)
+	}
+	if res := route(t, r, "g", ModeNormal, FocusBuffer); res.Action != "top" {
+		t.Fatalf("gg: %q, want top", res.Action)
+	}
+	if res := r.HandleTimeout(stale); res.Status != Unhandled {
+		t.Errorf("stale timeout: %v, want Unhandled", res.Status)
+	}
+}
+
+func TestRouterUserMappings(t *testing.T) {
+	tests := []struct {
+		name   string
+		setup  func(k *Keymap) (bool, error)
+		keys   string
+		focus  Focus
+		status Status
+		action string
+		absent bool // setup removes nothing
+	}{
+		{
+			name:   "map for every pane shadows a pane default",
+			setup:  func(k *Keymap) (bool, error) { return true, k.Map(ModeNormal, FocusAny, "k", "scroll-up") },
+			keys:   "k",
+			focus:  FocusBuffer,
+			status: Matched, action: "scroll-up",
+		},
+		{
+			name:   "pane mapping beats a mapping for every pane",
+			setup:  func(k *Keymap) (bool, error) { return true, k.Map(ModeNormal, FocusBuffer, "j", "next-line") },
+			keys:   "j",
+			focus:  FocusBuffer,
+			status: Matched, action: "next-line",
+		},
+		{
+			name:   "mapping only applies to its pane",
+			setup:  func(k *Keymap) (bool, error) { return true, k.Map(ModeNormal, FocusBuffer, "j", "next-line") },
+			keys:   "j",
+			focus:  FocusHistory,
+			status: Matched, action: "down",
+		},
+		{
+			name:   "unmap for every pane removes a pane default",
+			setup:  func(k *Keymap) (bool, error) { return k.Unmap(ModeNormal, FocusAny, "G") },
+			keys:   "G",
+			focus:  FocusBuffer,
+			status: Unhandled,
+		},
+		{
+			name: "unmap removes the mapping before the default",
+			setup: func(k *Keymap) (bool, error) {
+				if err := k.Map(ModeNormal, FocusAny, "k", "scroll-up"); err != nil {
+					return false, err
+				}
+				return k.Unmap(ModeNormal, FocusAny, "k")
+			},
+			keys:   "k",
+			focus:  FocusBuffer,
+			status: Matched, action: "up",
+		},
+		{
+			name:   "unmap of a pane default in another pane",
+			setup:  func(k *Keymap) (bool, error) { return k.Unmap(ModeNormal, FocusHistory, "G") },
+			keys:   "G",
+			focus:  FocusBuffer,
+			status: Matched, action: "bottom",
+			absent: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := testRouter()
+			r.Keymap().MustBind(ModeNormal, FocusBuffer, "k", "up")
+			r.Keymap().MustBind(ModeNormal, FocusBuffer, "G", "bottom")
+			ok, err := tt.setup(r.Keymap())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok == tt.absent {
+				t.Fatalf("setup returned %v", ok)
+			}
+			res := route(t, r, tt.keys, ModeNormal, tt.focus)
+			if res.Status != tt.status || res.Action != tt.action {
+				t.Errorf("Route(%q) = %v %q, want %v %q", tt.keys, res.Status, res.Action, tt.status, tt.action)
+			}
+		})
+	}
+}
@@ -1,6 +1,8 @@
 // Package markdown provides markdown parsing for vai.
 package markdown
 
+import "strings"
+
 // Parser parses markdown text into structured blocks.
 type Parser struct {
 	// TODO: Add parser configuration
@@ -11,18 +13,57 @@ func NewParser() *Parser {
 	return &Parser{}
 }
 
-// Parse parses markdown text and returns a list of blocks.
-// TODO: Implement actual markdown parsing.
+// Parse splits markdown text into text and fenced code blocks. Text between
+// code blocks is kept as-is apart from surrounding blank lines; an
+// unterminated fence runs to the end of the text.
+// TODO: Parse headings, lists and inline markup.
 func (p *Parser) Parse(text string) []Block {
-	// Placeholder implementation
-	// TODO: Use a proper markdown library or implement parsing
-	return []Block{
-		&TextBlock{Content: text},
+	var blocks []Block
+	var para []string
+	var code *CodeBlock
+	var codeLines []string
+
+	flushText := func() {
+		content := strings.Trim(strings.Join(para, "\n"), "\n")
+		if strings.TrimSpace(content) != "" {
+			blocks = append(blocks, &TextBlock{Content: content})
+		}
+		para = nil
 	}
+	flushCode := func() {
+		code.Content = strings.Join(codeLines, "\n")
+		blocks = append(blocks, code)
+		code, codeLines = nil, nil
+	}
+
+	for _, line := range strings.Split(text, "\n") {
+		fence := strings.HasPrefix(strings.TrimSpace(line), "```")
+		switch {
+		case code != nil && fence:
+			flushCode()
+		case code != nil:
+			codeLines = append(codeLines, line)
+		case fence:
+			flushText()
+			code = &CodeBlock{Lang: strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "```"))}
+		default:
+			para = append(para, line)
+		}
+	}
+	if code != nil {
+		flushCode()
+	}
+	flushText()
+	return blocks
 }
 
-// ParseCodeBlocks extracts code blocks from markdown text.
-// TODO: Implement code block extraction.
+// ParseCodeBlocks extracts the fenced code blocks from markdown text.
 func (p *Parser) ParseCodeBlocks(text string) []*CodeBlock {
-	return []*CodeBlock{}
+	var out []*CodeBlock
+	for _, block := range p.Parse(text) {
+		if code, ok := block.(*CodeBlock); ok {
+			out = append(out, code)
+		}
+	}
+	return out
 }
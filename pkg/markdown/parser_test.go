@@ -0,0 +1,85 @@
+package markdown
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	text := func(s string) Block { return &TextBlock{Content: s} }
+	code := func(lang, s string) Block { return &CodeBlock{Lang: lang, Content: s} }
+
+	tests := []struct {
+		name string
+		text string
+		want []Block
+	}{
+		{"empty", "", nil},
+		{"text", "Hello\nworld", []Block{text("Hello\nworld")}},
+		{
+			name: "text and code",
+			text: "Run:\n\n```bash\ncurl wttr.in\n```\n\nDone.",
+			want: []Block{text("Run:"), code("bash", "curl wttr.in"), text("Done.")},
+		},
+		{
+			name: "blank lines inside code are kept",
+			text: "```go\nfunc a() {}\n\nfunc b() {}\n```",
+			want: []Block{code("go", "func a() {}\n\nfunc b() {}")},
+		},
+		{
+			name: "indented fence",
+			text: "  ```py\n  print(1)\n  ```",
+			want: []Block{code("py", "  print(1)")},
+		},
+		{
+			name: "no language",
+			text: "```\nplain\n```",
+			want: []Block{code("", "plain")},
+		},
+		{
+			name: "unterminated fence runs to the end",
+			text: "See:\n```sh\nls\npwd",
+			want: []Block{text("See:"), code("sh", "ls\npwd")},
+		},
+		{
+			name: "empty code block",
+			text: "```\n```",
+			want: []Block{code("", "")},
+		},
+		{
+			name: "adjacent blocks",
+			text: "```a\n1\n```\n```b\n2\n```",
+			want: []Block{code("a", "1"), code("b", "2")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewParser().Parse(tt.text)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Parse() = %d blocks, want %d", len(got), len(tt.want))
+			}
+			for i := range got {
+				if !sameBlock(got[i], tt.want[i]) {
+					t.Errorf("block %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseCodeBlocks(t *testing.T) {
+	blocks := NewParser().ParseCodeBlocks("a\n```go\nx\n```\nb\n```sh\ny\n```")
+	if len(blocks) != 2 || blocks[0].Lang != "go" || blocks[1].Content != "y" {
+		t.Errorf("ParseCodeBlocks() = %+v", blocks)
+	}
+}
+
+// sameBlock compares text and code blocks by content.
+func sameBlock(a, b Block) bool {
+	switch a := a.(type) {
+	case *TextBlock:
+		b, ok := b.(*TextBlock)
+		return ok && a.Content == b.Content
+	case *CodeBlock:
+		b, ok := b.(*CodeBlock)
+		return ok && a.Lang == b.Lang && a.Content == b.Content
+	}
+	return false
+}